@@ -0,0 +1,159 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"openmanus-go/pkg/agent"
+	"openmanus-go/pkg/config"
+	"openmanus-go/pkg/llm"
+	"openmanus-go/pkg/prompts"
+	"openmanus-go/pkg/tool"
+	"openmanus-go/pkg/tool/builtin"
+
+	"github.com/spf13/cobra"
+)
+
+// NewPromptsCommand 创建提示模板命令
+func NewPromptsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "prompts",
+		Short: "提示模板管理命令",
+		Long: `查看和预览规划器、反思器使用的提示模板。
+
+模板使用 Go text/template 语法，可在 agent.prompts_dir 目录中放置同名 .tmpl 文件覆盖内置模板。
+可用变量：.Goal .Tools .Memory .Locale
+
+子命令:
+  list     - 列出所有模板及其来源
+  render   - 渲染指定目标的最终提示`,
+	}
+
+	cmd.AddCommand(newPromptsListCommand())
+	cmd.AddCommand(newPromptsRenderCommand())
+
+	return cmd
+}
+
+func newPromptsListCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "列出所有提示模板",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadPromptsConfig(cmd)
+			if err != nil {
+				return err
+			}
+
+			loader := prompts.NewLoader(cfg.Agent.PromptsDir)
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tSOURCE")
+			fmt.Fprintln(w, "----\t------")
+			for _, name := range prompts.Names() {
+				source, _, err := loader.Source(name)
+				if err != nil {
+					source = "error: " + err.Error()
+				}
+				fmt.Fprintf(w, "%s\t%s\n", name, source)
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().String("prompts-dir", "", "自定义提示模板目录（覆盖配置文件）")
+
+	return cmd
+}
+
+func newPromptsRenderCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "render <goal>",
+		Short: "渲染指定目标的最终提示",
+		Long: `渲染指定目标的提示，不调用 LLM。
+
+默认输出首轮规划请求的完整消息（系统提示 + 上下文 + 工具清单）；
+使用 --template 只渲染单个模板。`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			goal := args[0]
+			templateName, _ := cmd.Flags().GetString("template")
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			cfg, err := loadPromptsConfig(cmd)
+			if err != nil {
+				return err
+			}
+
+			toolRegistry := tool.NewRegistry()
+			if err := builtin.RegisterBuiltinTools(toolRegistry, cfg); err != nil {
+				return fmt.Errorf("failed to register builtin tools: %w", err)
+			}
+
+			// 只渲染单个模板
+			if templateName != "" {
+				rendered, err := prompts.NewLoader(cfg.Agent.PromptsDir).Render(templateName, prompts.Data{
+					Goal:   goal,
+					Tools:  toolRegistry.GetToolsManifest(),
+					Locale: cfg.Agent.Locale,
+				})
+				if err != nil {
+					return err
+				}
+				fmt.Println(rendered)
+				return nil
+			}
+
+			// 渲染首轮规划请求
+			agentConfig, err := agent.ConfigFromAppConfig(cfg)
+			if err != nil {
+				return fmt.Errorf("failed to create agent config: %w", err)
+			}
+			llmClient := llm.NewOpenAIClient(cfg.ToLLMConfig())
+			req := agent.NewBaseAgent(llmClient, toolRegistry, agentConfig).PreviewPlanningRequest(goal)
+
+			if asJSON {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(req)
+			}
+
+			for _, msg := range req.Messages {
+				fmt.Printf("===== %s =====\n", strings.ToUpper(msg.Role))
+				fmt.Println(msg.Content)
+				fmt.Println()
+			}
+			fmt.Printf("===== %d tools | ~%d prompt tokens | %d context window =====\n",
+				len(req.Tools), llm.EstimateRequestTokens(req), llm.ContextWindow(llmClient.GetModel()))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("template", "t", "", "只渲染指定模板（planner_system, reflector_system）")
+	cmd.Flags().String("prompts-dir", "", "自定义提示模板目录（覆盖配置文件）")
+	cmd.Flags().String("locale", "", "回答语言（覆盖配置文件）")
+	cmd.Flags().Bool("json", false, "以 JSON 格式输出完整请求")
+
+	return cmd
+}
+
+// loadPromptsConfig 加载配置并应用命令行覆盖
+func loadPromptsConfig(cmd *cobra.Command) (*config.Config, error) {
+	configPath, _ := cmd.Flags().GetString("config")
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if dir, _ := cmd.Flags().GetString("prompts-dir"); dir != "" {
+		cfg.Agent.PromptsDir = dir
+	}
+	if cmd.Flags().Lookup("locale") != nil {
+		if locale, _ := cmd.Flags().GetString("locale"); locale != "" {
+			cfg.Agent.Locale = locale
+		}
+	}
+	return cfg, nil
+}
@@ -44,6 +44,10 @@ Agent 将通过 Plan -> Tool Use -> Observation -> Reflection -> Next Action 的
 	cmd.Flags().StringP("temperature", "T", "", "LLM 温度（0.0-2.0）")
 	cmd.Flags().BoolP("save-trace", "S", false, "保存执行轨迹")
 	cmd.Flags().StringP("trace-path", "P", "", "轨迹保存路径（覆盖配置文件设置）")
+	cmd.Flags().Bool("llm-cache", false, "启用 LLM 响应磁盘缓存（覆盖配置文件设置）")
+	cmd.Flags().String("llm-record", "", "将 LLM 请求/响应录制到指定磁带文件")
+	cmd.Flags().String("llm-replay", "", "从指定磁带文件回放 LLM 响应（不访问 LLM 服务）")
+	cmd.Flags().String("llm-replay-mode", "strict", "磁带回放匹配模式 (strict, fuzzy)")
 
 	return cmd
 }
@@ -95,7 +99,46 @@ func runAgent(cmd *cobra.Command, args []string) error {
 	defer mcpManager.StopAll()
 
 	// 创建 LLM 客户端
-	llmClient := llm.NewOpenAIClient(cfg.ToLLMConfig())
+	var llmClient llm.Client = llm.NewOpenAIClient(cfg.ToLLMConfig())
+
+	// 磁带录制/回放（用于可复现的调试和回归测试）
+	recordPath, _ := cmd.Flags().GetString("llm-record")
+	replayPath, _ := cmd.Flags().GetString("llm-replay")
+	if recordPath != "" && replayPath != "" {
+		return fmt.Errorf("--llm-record and --llm-replay cannot be used together")
+	}
+	if replayPath != "" {
+		replayMode, _ := cmd.Flags().GetString("llm-replay-mode")
+		if replayMode != string(llm.MatchStrict) && replayMode != string(llm.MatchFuzzy) {
+			return fmt.Errorf("invalid --llm-replay-mode: %s", replayMode)
+		}
+		replayClient, err := llm.NewReplayClientFromFile(replayPath, llm.MatchMode(replayMode))
+		if err != nil {
+			return fmt.Errorf("failed to load llm cassette: %w", err)
+		}
+		llmClient = replayClient
+		logger.Infof("📼 [LLM_REPLAY] Replaying responses from %s (mode: %s)", replayPath, replayMode)
+	} else if recordPath != "" {
+		llmClient = llm.NewRecordingClient(llmClient, recordPath)
+		logger.Infof("📼 [LLM_RECORD] Recording interactions to %s", recordPath)
+	}
+
+	// 按需启用 LLM 响应缓存
+	if useCache, _ := cmd.Flags().GetBool("llm-cache"); useCache {
+		cfg.LLM.Cache.Enabled = true
+	}
+	if cfg.LLM.Cache.Enabled {
+		cacheConfig, err := cfg.ToLLMCacheConfig()
+		if err != nil {
+			return fmt.Errorf("failed to create llm cache config: %w", err)
+		}
+		cachingClient, err := llm.NewCachingClient(llmClient, cacheConfig)
+		if err != nil {
+			return fmt.Errorf("failed to create llm cache: %w", err)
+		}
+		llmClient = cachingClient
+		logger.Infof("💾 [LLM_CACHE] Response cache enabled: %s (ttl: %s)", cacheConfig.Dir, cacheConfig.TTL)
+	}
 
 	// 应用命令行覆盖
 	if temp, _ := cmd.Flags().GetString("temperature"); temp != "" {
@@ -108,6 +151,9 @@ func runAgent(cmd *cobra.Command, args []string) error {
 	if err := builtin.RegisterBuiltinTools(toolRegistry, cfg); err != nil {
 		return fmt.Errorf("failed to register builtin tools: %w", err)
 	}
+	plugins := tool.LoadPlugins(ctx, toolRegistry, cfg.Tools.Plugins)
+	defer plugins.Close()
+	tool.LoadOpenAPITools(toolRegistry, cfg.Tools.OpenAPI)
 
 	// 创建 Agent 配置
 	agentConfig, err := agent.ConfigFromAppConfig(cfg)
@@ -125,6 +171,7 @@ func runAgent(cmd *cobra.Command, args []string) error {
 
 	// 创建带 MCP 功能的 Agent
 	baseAgent := agent.NewBaseAgentWithMCP(llmClient, toolRegistry, agentConfig, cfg)
+	baseAgent.SetEventHandler(printToolProgress)
 
 	// 后台处理来自 MCP 的事件，触发 Agent 执行
 	go func() {
@@ -256,6 +303,19 @@ func runInteractiveMode(ctx context.Context, agent agent.Agent, cmd *cobra.Comma
 	}
 }
 
+// printToolProgress 显示流式工具（如 crawler crawl、elasticsearch bulk）的执行进度
+func printToolProgress(event agent.Event) {
+	if event.Type != agent.EventToolProgress || event.Progress == nil {
+		return
+	}
+	progress := event.Progress.Progress
+	if fraction := progress.Fraction(); fraction >= 0 {
+		logger.Infof("⏳ [TOOL_PROGRESS] %s %d/%d (%.0f%%) %s", event.Tool, progress.Current, progress.Total, fraction*100, progress.Message)
+	} else {
+		logger.Infof("⏳ [TOOL_PROGRESS] %s %s", event.Tool, progress.Message)
+	}
+}
+
 func printHelp() {
 	logger.Info(`
 Available commands:
@@ -5,8 +5,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
 	"text/tabwriter"
+	"time"
 
+	"openmanus-go/pkg/agent"
 	"openmanus-go/pkg/config"
 	"openmanus-go/pkg/logger"
 	"openmanus-go/pkg/tool"
@@ -25,7 +28,7 @@ func NewToolsCommand() *cobra.Command {
 子命令:
   list     - 列出所有可用工具
   info     - 显示特定工具的详细信息
-  test     - 测试工具连接`,
+  test     - 检查工具健康状态`,
 	}
 
 	cmd.AddCommand(newToolsListCommand())
@@ -54,17 +57,25 @@ func newToolsListCommand() *cobra.Command {
 			if err := builtin.RegisterBuiltinTools(registry, cfg); err != nil {
 				return fmt.Errorf("failed to register tools: %w", err)
 			}
+			plugins := tool.LoadPlugins(cmd.Context(), registry, cfg.Tools.Plugins)
+			defer plugins.Close()
+			tool.LoadOpenAPITools(registry, cfg.Tools.OpenAPI)
 
-			// 获取工具清单
+			// 获取工具清单和权限策略
 			manifest := registry.GetToolsManifest()
+			sort.Slice(manifest, func(i, j int) bool { return manifest[i].Name < manifest[j].Name })
+			policy, err := tool.NewPolicy(cfg.Tools.Policy)
+			if err != nil {
+				return err
+			}
 
 			switch format {
 			case "json":
-				return outputJSON(manifest)
+				return outputJSON(manifest, policy)
 			case "table":
-				return outputTable(manifest)
+				return outputTable(manifest, policy)
 			default:
-				return outputDefault(manifest)
+				return outputDefault(manifest, policy)
 			}
 		},
 	}
@@ -94,6 +105,9 @@ func newToolsInfoCommand() *cobra.Command {
 			if err := builtin.RegisterBuiltinTools(registry, cfg); err != nil {
 				return fmt.Errorf("failed to register tools: %w", err)
 			}
+			plugins := tool.LoadPlugins(cmd.Context(), registry, cfg.Tools.Plugins)
+			defer plugins.Close()
+			tool.LoadOpenAPITools(registry, cfg.Tools.OpenAPI)
 
 			// 获取工具信息
 			toolInstance, err := registry.Get(toolName)
@@ -119,15 +133,21 @@ func newToolsInfoCommand() *cobra.Command {
 }
 
 func newToolsTestCommand() *cobra.Command {
-	return &cobra.Command{
-		Use:   "test [tool-name]",
-		Short: "测试工具连接",
-		Long: `测试工具的连接和基本功能。
+	cmd := &cobra.Command{
+		Use:   "test [tool-name...]",
+		Short: "检查工具健康状态",
+		Long: `并发检查工具及其依赖（数据库连接、浏览器、MCP 服务器、插件进程、OpenAPI 服务等）是否可用。
 
-如果不指定工具名称，将测试所有支持测试的工具。`,
-		Args: cobra.MaximumNArgs(1),
+不指定工具名称时检查所有工具（内置、插件、OpenAPI 和 MCP 工具），未配置的可选工具标记为 skipped。
+任一检查失败时以非零状态码退出，可直接用作容器健康检查。`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			configPath, _ := cmd.Flags().GetString("config")
+			format, _ := cmd.Flags().GetString("format")
+			timeout, _ := cmd.Flags().GetDuration("timeout")
+			deadline, _ := cmd.Flags().GetDuration("deadline")
+			concurrency, _ := cmd.Flags().GetInt("concurrency")
+			skip, _ := cmd.Flags().GetStringSlice("skip")
+			cmd.SilenceUsage = true
 
 			// 加载配置
 			cfg, err := config.Load(configPath)
@@ -135,138 +155,248 @@ func newToolsTestCommand() *cobra.Command {
 				return fmt.Errorf("failed to load config: %w", err)
 			}
 
-			if len(args) > 0 {
-				return testSingleTool(args[0], cfg)
-			} else {
-				return testAllTools(cfg)
+			// 总时限覆盖插件启动、MCP 发现和所有检查，超出后未完成的检查记为失败
+			ctx := cmd.Context()
+			if deadline > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, deadline)
+				defer cancel()
+			}
+
+			reports, err := runHealthChecks(ctx, cfg, args, skip, timeout, concurrency)
+			if err != nil {
+				return err
+			}
+
+			if format == "json" {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(reports); err != nil {
+					return err
+				}
+			} else if err := outputHealthTable(reports); err != nil {
+				return err
+			}
+
+			counts := make(map[string]int)
+			for _, report := range reports {
+				counts[report.Status]++
 			}
+			logger.Infof("Summary: %d ok, %d failed, %d skipped", counts[tool.HealthOK], counts[tool.HealthFail], counts[tool.HealthSkipped])
+			if counts[tool.HealthFail] > 0 {
+				return fmt.Errorf("%d of %d tool checks failed", counts[tool.HealthFail], len(reports))
+			}
+			return nil
 		},
 	}
-}
 
-func outputDefault(manifest []tool.ToolInfo) error {
-	logger.Infof("Available Tools (%d):", len(manifest))
+	cmd.Flags().StringP("format", "f", "table", "输出格式 (table, json)")
+	cmd.Flags().Duration("timeout", 10*time.Second, "单个工具的检查超时")
+	cmd.Flags().Duration("deadline", 0, "整个检查（插件启动、MCP 发现和所有工具检查）的总时限，0 表示不限制")
+	cmd.Flags().Int("concurrency", 8, "并发检查数")
+	cmd.Flags().StringSlice("skip", nil, "跳过的工具名称 (如 browser)")
 
-	for _, toolInfo := range manifest {
-		logger.Infof("📋 %s", toolInfo.Name)
-		logger.Infof("   %s", toolInfo.Description)
+	return cmd
+}
+
+// runHealthChecks 创建工具并并发执行健康检查
+//
+// 内置工具逐个创建：未配置的可选工具（redis、mysql 等）标记为 skipped，创建失败（如浏览器无法启动）标记为 fail，
+// 不影响其他工具的检查。
+func runHealthChecks(ctx context.Context, cfg *config.Config, names, skip []string, timeout time.Duration, concurrency int) ([]tool.HealthReport, error) {
+	selected := make(map[string]bool, len(names))
+	for _, name := range names {
+		selected[name] = true
+	}
+	skipped := make(map[string]bool, len(skip))
+	for _, name := range skip {
+		skipped[name] = true
+	}
+	wanted := func(name string) bool {
+		return !skipped[name] && (len(selected) == 0 || selected[name])
 	}
 
-	return nil
-}
+	registry := tool.NewRegistry()
+	var reports []tool.HealthReport
+	for _, name := range builtin.GetBuiltinToolsList() {
+		if !wanted(name) {
+			continue
+		}
+		if err := builtin.ValidateToolConfig(name, cfg); err != nil {
+			reports = append(reports, tool.HealthReport{
+				Tool: name, Type: tool.ToolTypeBuiltin, Status: tool.HealthSkipped, Detail: "not configured: " + err.Error(),
+			})
+			continue
+		}
+		toolInstance, err := createBuiltinTool(name, cfg)
+		if err != nil {
+			reports = append(reports, tool.HealthReport{
+				Tool: name, Type: tool.ToolTypeBuiltin, Status: tool.HealthFail, Error: err.Error(),
+			})
+			continue
+		}
+		if closer, ok := toolInstance.(interface{ Close() error }); ok {
+			defer closer.Close()
+		}
+		if err := registry.Register(toolInstance); err != nil {
+			return nil, err
+		}
+	}
 
-func outputTable(manifest []tool.ToolInfo) error {
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "NAME\tDESCRIPTION")
-	fmt.Fprintln(w, "----\t-----------")
+	// 插件、OpenAPI 和 MCP 工具
+	plugins := tool.LoadPlugins(ctx, registry, cfg.Tools.Plugins)
+	defer plugins.Close()
+	tool.LoadOpenAPITools(registry, cfg.Tools.OpenAPI)
+	discoveryCtx, cancel := context.WithTimeout(ctx, timeout)
+	agent.LoadMCPTools(discoveryCtx, registry, cfg)
+	cancel()
+
+	// 没有注册任何工具的插件、OpenAPI 服务和 MCP 服务器（启动或发现失败）记为失败
+	for _, source := range toolSources(cfg) {
+		if wanted(source.ServerName) && !hasServerTools(registry, source.ServerName) {
+			source.Status = tool.HealthFail
+			source.Error = "no tools loaded (failed to start or unreachable, see logs)"
+			reports = append(reports, source)
+		}
+	}
 
-	for _, toolInfo := range manifest {
-		description := toolInfo.Description
-		if len(description) > 60 {
-			description = description[:57] + "..."
+	var targets []string
+	for _, name := range registry.ListNames() {
+		if wanted(name) {
+			targets = append(targets, name)
 		}
-		fmt.Fprintf(w, "%s\t%s\n", toolInfo.Name, description)
+	}
+	for name := range selected {
+		if _, err := registry.Get(name); err != nil && !hasReport(reports, name) {
+			return nil, fmt.Errorf("tool not found: %s", name)
+		}
+	}
+	if len(targets) > 0 {
+		checked, err := registry.CheckHealth(ctx, targets, timeout, concurrency)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, checked...)
 	}
 
-	return w.Flush()
+	sort.Slice(reports, func(i, j int) bool { return reports[i].Tool < reports[j].Tool })
+	return reports, nil
 }
 
-func outputJSON(manifest []tool.ToolInfo) error {
-	// 仍使用 JSON 直接输出，便于机器读取
-	encoder := json.NewEncoder(os.Stdout)
-	encoder.SetIndent("", "  ")
-	return encoder.Encode(manifest)
+// createBuiltinTool 创建内置工具，创建过程中的 panic（如浏览器无法启动）转为错误
+func createBuiltinTool(name string, cfg *config.Config) (toolInstance tool.Tool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("failed to create tool: %v", r)
+		}
+	}()
+	toolInstance, err = builtin.CreateToolFromConfig(name, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tool: %w", err)
+	}
+	return toolInstance, nil
 }
 
-func testSingleTool(toolName string, cfg *config.Config) error {
-	logger.Infof("Testing tool: %s", toolName)
-
-	// 验证工具配置
-	if err := builtin.ValidateToolConfig(toolName, cfg); err != nil {
-		logger.Errorf("❌ Configuration validation failed: %v", err)
-		return err
+// toolSources 配置中提供工具的插件、OpenAPI 服务和 MCP 服务器
+func toolSources(cfg *config.Config) []tool.HealthReport {
+	var sources []tool.HealthReport
+	for _, plugin := range cfg.Tools.Plugins {
+		sources = append(sources, tool.HealthReport{Tool: plugin.Name, Type: tool.ToolTypePlugin, ServerName: plugin.Name})
 	}
-
-	// 创建工具实例
-	toolInstance, err := builtin.CreateToolFromConfig(toolName, cfg)
-	if err != nil {
-		logger.Errorf("❌ Failed to create tool: %v", err)
-		return err
+	for _, service := range cfg.Tools.OpenAPI {
+		sources = append(sources, tool.HealthReport{Tool: service.Name, Type: tool.ToolTypeOpenAPI, ServerName: service.Name})
 	}
-
-	// 执行特定的测试
-	switch toolName {
-	case "redis":
-		return testRedisTool(toolInstance)
-	case "mysql":
-		return testMySQLTool(toolInstance)
-	case "browser":
-		return testBrowserTool(toolInstance)
-	default:
-		logger.Infof("✅ Tool '%s' created successfully", toolName)
-		logger.Infof("   Type: %T", toolInstance)
-		logger.Infof("   Description: %s", toolInstance.Description())
-		return nil
+	for serverName := range cfg.MCP.Servers {
+		sources = append(sources, tool.HealthReport{Tool: serverName, Type: tool.ToolTypeMCP, ServerName: serverName})
 	}
+	return sources
 }
 
-func testAllTools(cfg *config.Config) error {
-	logger.Infof("Testing all available tools...")
+// hasServerTools 注册表中是否有来自该插件、服务或服务器的工具
+func hasServerTools(registry *tool.Registry, serverName string) bool {
+	for _, info := range registry.GetToolsManifest() {
+		if info.ServerName == serverName {
+			return true
+		}
+	}
+	return false
+}
 
-	toolNames := builtin.GetBuiltinToolsList()
-	successCount := 0
+// hasReport 是否已有该工具的检查报告
+func hasReport(reports []tool.HealthReport, name string) bool {
+	for _, report := range reports {
+		if report.Tool == name {
+			return true
+		}
+	}
+	return false
+}
 
-	for _, toolName := range toolNames {
-		logger.Infof("🔧 Testing %s... ", toolName)
+func outputHealthTable(reports []tool.HealthReport) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTYPE\tSTATUS\tLATENCY\tDETAIL")
+	fmt.Fprintln(w, "----\t----\t------\t-------\t------")
 
-		if err := builtin.ValidateToolConfig(toolName, cfg); err != nil {
-			logger.Errorf("❌ Config invalid: %v", err)
-			continue
+	for _, report := range reports {
+		latency := "-"
+		if report.Status != tool.HealthSkipped {
+			latency = report.Latency.Round(time.Millisecond).String()
 		}
-
-		if _, err := builtin.CreateToolFromConfig(toolName, cfg); err != nil {
-			logger.Errorf("❌ Failed: %v", err)
-			continue
+		detail := report.Detail
+		if report.Error != "" {
+			detail = report.Error
 		}
-
-		logger.Infof("✅ OK")
-		successCount++
+		if len(detail) > 80 {
+			detail = detail[:77] + "..."
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", report.Tool, report.Type, report.Status, latency, detail)
 	}
 
-	logger.Infof("Summary: %d/%d tools passed basic tests", successCount, len(toolNames))
+	return w.Flush()
+}
 
-	if successCount < len(toolNames) {
-		logger.Infof("💡 Tip: Use 'openmanus tools info <tool-name>' for detailed information")
-		logger.Infof("   Some tools may require additional configuration (Redis, MySQL, etc.)")
+func outputDefault(manifest []tool.ToolInfo, policy *tool.Policy) error {
+	logger.Infof("Available Tools (%d):", len(manifest))
+
+	for _, toolInfo := range manifest {
+		logger.Infof("📋 %s", toolInfo.Name)
+		logger.Infof("   %s", toolInfo.Description)
+		logger.Infof("   🔒 %s", policy.Describe(toolInfo.Name))
 	}
 
 	return nil
 }
 
-func testRedisTool(toolInstance tool.Tool) error {
-	if redisTool, ok := toolInstance.(*builtin.RedisTool); ok {
-		if err := redisTool.Ping(context.TODO()); err != nil {
-			logger.Errorf("❌ Redis connection failed: %v", err)
-			return err
+func outputTable(manifest []tool.ToolInfo, policy *tool.Policy) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tPERMISSIONS\tDESCRIPTION")
+	fmt.Fprintln(w, "----\t-----------\t-----------")
+
+	for _, toolInfo := range manifest {
+		description := toolInfo.Description
+		if len(description) > 60 {
+			description = description[:57] + "..."
 		}
-		logger.Infof("✅ Redis connection successful")
+		fmt.Fprintf(w, "%s\t%s\t%s\n", toolInfo.Name, policy.Describe(toolInfo.Name), description)
 	}
-	return nil
+
+	return w.Flush()
 }
 
-func testMySQLTool(toolInstance tool.Tool) error {
-	if mysqlTool, ok := toolInstance.(*builtin.MySQLTool); ok {
-		if err := mysqlTool.Ping(context.TODO()); err != nil {
-			logger.Errorf("❌ MySQL connection failed: %v", err)
-			return err
-		}
-		logger.Infof("✅ MySQL connection successful")
-	}
-	return nil
+// toolWithPermissions JSON 输出中附带有效权限的工具信息
+type toolWithPermissions struct {
+	tool.ToolInfo
+	Permissions string `json:"permissions"`
 }
 
-func testBrowserTool(toolInstance tool.Tool) error {
-	logger.Infof("✅ Browser tool created (headless mode)")
-	logger.Infof("   Note: Browser functionality requires system dependencies")
-	return nil
+func outputJSON(manifest []tool.ToolInfo, policy *tool.Policy) error {
+	items := make([]toolWithPermissions, len(manifest))
+	for i, toolInfo := range manifest {
+		items[i] = toolWithPermissions{ToolInfo: toolInfo, Permissions: policy.Describe(toolInfo.Name)}
+	}
+
+	// 仍使用 JSON 直接输出，便于机器读取
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(items)
 }
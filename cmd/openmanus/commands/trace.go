@@ -2,6 +2,7 @@ package commands
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -60,6 +61,7 @@ func newTraceShowCommand() *cobra.Command {
 
 	cmd.Flags().BoolP("steps", "s", false, "显示所有步骤详情")
 	cmd.Flags().BoolP("observations", "o", false, "显示观测结果")
+	cmd.Flags().BoolP("reasoning", "r", false, "显示推理模型的思考过程")
 
 	return cmd
 }
@@ -162,6 +164,7 @@ func runTraceShow(cmd *cobra.Command, args []string) error {
 
 	showSteps, _ := cmd.Flags().GetBool("steps")
 	showObservations, _ := cmd.Flags().GetBool("observations")
+	showReasoning, _ := cmd.Flags().GetBool("reasoning")
 
 	// 显示基本信息
 	logger.Infof("📄 Trace: %s", traceID)
@@ -177,31 +180,85 @@ func runTraceShow(cmd *cobra.Command, args []string) error {
 		logger.Info("🔍 Steps:")
 		for i, step := range trace.Steps {
 			status := "✅"
-			if step.Observation != nil && step.Observation.ErrMsg != "" {
-				status = "❌"
+			if step.Observation != nil {
+				switch step.Observation.Envelope().Status {
+				case state.ObservationError:
+					status = "❌"
+				case state.ObservationPartial:
+					status = "✂️"
+				}
+			}
+
+			callMark := ""
+			if step.LLMCall != nil && step.LLMCall.CacheHit {
+				callMark = " 💾 (cached)"
+			} else if step.LLMCall != nil && step.LLMCall.Cost > 0 {
+				callMark = fmt.Sprintf(" 💰 %.4f", step.LLMCall.Cost)
 			}
 
-			logger.Infof("  %d. %s %s", i+1, status, step.Action.Name)
+			logger.Infof("  %d. %s %s%s", i+1, status, step.Action.Name, callMark)
 
 			if step.Action.Reason != "" {
 				logger.Infof("     Reason: %s", step.Action.Reason)
 			}
 
+			if showReasoning && step.Reasoning != "" {
+				logger.Infof("     Reasoning:")
+				for _, line := range strings.Split(strings.TrimSpace(step.Reasoning), "\n") {
+					logger.Infof("       %s", line)
+				}
+			}
+
 			if showSteps && len(step.Action.Args) > 0 {
 				logger.Infof("     Args: %+v", step.Action.Args)
 			}
 
 			if showObservations && step.Observation != nil {
-				if step.Observation.ErrMsg != "" {
-					logger.Infof("     Error: %s", step.Observation.ErrMsg)
-				} else if step.Observation.Output != nil {
-					logger.Infof("     Output: %+v", step.Observation.Output)
+				envelope := step.Observation.Envelope()
+				if envelope.Error != "" {
+					logger.Infof("     Error: %s", envelope.Error)
+				}
+				if len(envelope.Data) > 0 && (envelope.Error == "" || step.Observation.Partial) {
+					logger.Infof("     Data: %+v", envelope.Data)
+				}
+				if step.Observation.Summary != "" {
+					logger.Infof("     Summary: %s", step.Observation.Summary)
+				}
+				latencyNote := ""
+				if step.Observation.Cache != "" {
+					latencyNote += fmt.Sprintf(" (cache %s)", step.Observation.Cache)
+				}
+				if step.Observation.Wait > 0 {
+					latencyNote += fmt.Sprintf(" (queued %dms)", step.Observation.Wait)
+				}
+				logger.Infof("     Latency: %dms%s", step.Observation.Latency, latencyNote)
+				if len(step.Observation.Artifacts) > 0 {
+					logger.Infof("     Artifacts: %s", strings.Join(step.Observation.Artifacts, ", "))
 				}
-				logger.Infof("     Latency: %dms", step.Observation.Latency)
 			}
 		}
 	}
 
+	if len(trace.Usage) > 0 {
+		total := trace.TotalUsage()
+		logger.Info("")
+		logger.Infof("💰 Usage: %d LLM calls | %d prompt + %d completion tokens | cost %.4f",
+			total.Calls, total.PromptTokens, total.CompletionTokens, total.Cost)
+		models := make([]string, 0, len(trace.Usage))
+		for model := range trace.Usage {
+			models = append(models, model)
+		}
+		sort.Strings(models)
+		for _, model := range models {
+			usage := trace.Usage[model]
+			logger.Infof("  %s: %d calls | %d + %d tokens | %.4f",
+				model, usage.Calls, usage.PromptTokens, usage.CompletionTokens, usage.Cost)
+		}
+		if trace.Budget.MaxCost > 0 {
+			logger.Infof("  Budget: %.4f / %.4f", trace.Budget.UsedCost, trace.Budget.MaxCost)
+		}
+	}
+
 	if len(trace.Reflections) > 0 {
 		logger.Info("")
 		logger.Info("💭 Reflections:")
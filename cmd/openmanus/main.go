@@ -42,6 +42,7 @@ It supports:
 	rootCmd.AddCommand(commands.NewConfigCommand())
 	rootCmd.AddCommand(commands.NewToolsCommand())
 	rootCmd.AddCommand(commands.NewTraceCommand())
+	rootCmd.AddCommand(commands.NewPromptsCommand())
 
 	// 执行命令
 	if err := rootCmd.Execute(); err != nil {
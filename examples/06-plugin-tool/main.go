@@ -0,0 +1,157 @@
+// text-stats-plugin 是一个外部插件工具示例：通过 stdin/stdout 的 JSON 行协议提供 text_stats 工具。
+//
+// 构建并在配置中声明：
+//
+//	go build -o bin/text-stats-plugin ./examples/06-plugin-tool
+//
+//	[[tools.plugins]]
+//	name = "text_stats"
+//	command = "./bin/text-stats-plugin"
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+type request struct {
+	ID     int64           `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+type response struct {
+	ID     int64  `json:"id"`
+	Result any    `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+func main() {
+	// stdout 只用于协议，日志写到 stderr
+	log.SetOutput(os.Stderr)
+	log.SetPrefix("[text_stats] ")
+
+	encoder := json.NewEncoder(os.Stdout)
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		var req request
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			log.Printf("invalid request: %v", err)
+			continue
+		}
+
+		resp := response{ID: req.ID}
+		switch req.Method {
+		case "describe":
+			resp.Result = describe()
+		case "invoke":
+			result, err := invoke(req.Params)
+			if err != nil {
+				resp.Error = err.Error()
+			} else {
+				resp.Result = result
+			}
+		default:
+			resp.Error = fmt.Sprintf("unknown method: %s", req.Method)
+		}
+
+		if err := encoder.Encode(resp); err != nil {
+			log.Printf("failed to write response: %v", err)
+			return
+		}
+	}
+}
+
+// describe 返回插件提供的工具
+func describe() map[string]any {
+	return map[string]any{
+		"tools": []map[string]any{{
+			"name":        "text_stats",
+			"description": "统计文本的字符数、单词数、行数和最常见的单词",
+			"input_schema": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"text": map[string]any{"type": "string", "description": "要统计的文本"},
+					"top":  map[string]any{"type": "integer", "description": "返回最常见单词的数量，默认 5", "minimum": 0},
+				},
+				"required": []string{"text"},
+			},
+			"output_schema": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"chars":     map[string]any{"type": "integer"},
+					"words":     map[string]any{"type": "integer"},
+					"lines":     map[string]any{"type": "integer"},
+					"top_words": map[string]any{"type": "array"},
+				},
+			},
+		}},
+	}
+}
+
+// invoke 执行工具调用
+func invoke(raw json.RawMessage) (map[string]any, error) {
+	var params struct {
+		Tool string `json:"tool"`
+		Args struct {
+			Text string `json:"text"`
+			Top  *int   `json:"top"`
+		} `json:"args"`
+	}
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	if params.Tool != "text_stats" {
+		return nil, fmt.Errorf("unknown tool: %s", params.Tool)
+	}
+
+	top := 5
+	if params.Args.Top != nil {
+		top = *params.Args.Top
+	}
+
+	text := params.Args.Text
+	words := strings.Fields(strings.ToLower(text))
+	counts := make(map[string]int)
+	for _, word := range words {
+		counts[strings.Trim(word, ".,;:!?\"'()[]")]++
+	}
+	delete(counts, "")
+
+	type wordCount struct {
+		Word  string `json:"word"`
+		Count int    `json:"count"`
+	}
+	ranked := make([]wordCount, 0, len(counts))
+	for word, count := range counts {
+		ranked = append(ranked, wordCount{word, count})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Count != ranked[j].Count {
+			return ranked[i].Count > ranked[j].Count
+		}
+		return ranked[i].Word < ranked[j].Word
+	})
+	if len(ranked) > top {
+		ranked = ranked[:top]
+	}
+
+	lines := 0
+	if text != "" {
+		lines = strings.Count(text, "\n") + 1
+	}
+	log.Printf("processed %d words", len(words))
+	return map[string]any{
+		"chars":     len([]rune(text)),
+		"words":     len(words),
+		"lines":     lines,
+		"top_words": ranked,
+	}, nil
+}
@@ -0,0 +1,77 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"openmanus-go/pkg/llm"
+	"openmanus-go/pkg/logger"
+	"openmanus-go/pkg/tool"
+)
+
+// defaultMaxArgRepairs 工具参数无效时默认请求模型修正的次数
+const defaultMaxArgRepairs = 2
+
+// argRepairError 工具参数无法自动修复时的错误，Problems 会反馈给模型
+type argRepairError struct {
+	Problems []string
+}
+
+func (e *argRepairError) Error() string {
+	return strings.Join(e.Problems, "; ")
+}
+
+// repairToolArguments 解析并修复工具调用参数
+//
+// 依次进行：宽松 JSON 解析、按 InputSchema 类型修正、必填字段和类型检查。
+// 仍不满足 Schema 时返回 *argRepairError，列出全部问题。
+func repairToolArguments(arguments string, toolInfo *tool.ToolInfo) (map[string]any, error) {
+	args, err := llm.ParseToolCallArgumentsLenient(arguments)
+	if err != nil {
+		return nil, &argRepairError{Problems: []string{
+			fmt.Sprintf("arguments are not a valid JSON object (%v): %s", err, preview(arguments, 200)),
+		}}
+	}
+	if toolInfo == nil || toolInfo.InputSchema == nil {
+		return args, nil
+	}
+
+	args, changes := tool.CoerceArgs(args, toolInfo.InputSchema)
+	if len(changes) > 0 {
+		logger.Infof("🩹 [ARG_REPAIR] Coerced %d argument(s) for %s: %s", len(changes), toolInfo.Name, strings.Join(changes, ", "))
+		logger.Debugw("agent.plan.args_coerced", "tool", toolInfo.Name, "changes", changes)
+	}
+
+	if problems := tool.CheckArgs(args, toolInfo.InputSchema); len(problems) > 0 {
+		return nil, &argRepairError{Problems: problems}
+	}
+	return args, nil
+}
+
+// argRepairMessages 构建纠错轮次的消息：原样回放模型的工具调用，并以工具结果的形式说明参数问题
+func argRepairMessages(assistant llm.Message, toolCall llm.ToolCall, err error) []llm.Message {
+	assistant.ReasoningContent = ""
+	assistant.ToolCalls = []llm.ToolCall{toolCall}
+
+	var feedback strings.Builder
+	feedback.WriteString(fmt.Sprintf("ERROR: the arguments for tool %q are invalid and the tool was NOT executed.\n", toolCall.Function.Name))
+	feedback.WriteString("Problems:\n")
+	if repairErr, ok := err.(*argRepairError); ok {
+		for _, problem := range repairErr.Problems {
+			feedback.WriteString("- " + problem + "\n")
+		}
+	} else {
+		feedback.WriteString("- " + err.Error() + "\n")
+	}
+	feedback.WriteString("Call the tool again with a single valid JSON object that matches its parameter schema.")
+
+	return []llm.Message{
+		assistant,
+		{
+			Role:       "tool",
+			Name:       toolCall.Function.Name,
+			ToolCallID: toolCall.ID,
+			Content:    feedback.String(),
+		},
+	}
+}
@@ -0,0 +1,44 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"openmanus-go/pkg/llm"
+	"openmanus-go/pkg/logger"
+	"openmanus-go/pkg/state"
+	"openmanus-go/pkg/tool"
+)
+
+// artifactSummaryMaxTokens 工件摘要的最大输出 token 数
+const artifactSummaryMaxTokens = 400
+
+// newArtifactSummarizer 创建使用 LLM 为转存的大输出生成摘要的摘要器，返回的调用按 pricing 计算费用
+func newArtifactSummarizer(llmClient llm.Client, pricing llm.PricingTable) tool.ArtifactSummarizer {
+	return func(ctx context.Context, toolName, content string) (string, *state.LLMCall, error) {
+		req := &llm.ChatRequest{
+			Messages: []llm.Message{
+				llm.CreateSystemMessage("You summarize large tool outputs for an autonomous agent. " +
+					"Keep key facts, numbers, identifiers, names and structure (e.g. columns, record counts). " +
+					"Do not speculate. Answer in at most 10 short bullet points."),
+				llm.CreateUserMessage(fmt.Sprintf("Output of tool '%s' (may be truncated):\n\n%s", toolName, content)),
+			},
+			Temperature: 0.1,
+			MaxTokens:   artifactSummaryMaxTokens,
+		}
+
+		start := time.Now()
+		resp, err := llmClient.Chat(ctx, req)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to summarize artifact: %w", err)
+		}
+		call := newLLMCall(req, resp, time.Since(start), pricing)
+		if len(resp.Choices) == 0 {
+			return "", call, fmt.Errorf("no response choices from artifact summary")
+		}
+		logger.Debugw("agent.artifact.summary", "tool", toolName, "input_chars", len(content))
+		return strings.TrimSpace(resp.Choices[0].Message.Content), call, nil
+	}
+}
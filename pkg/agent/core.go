@@ -3,12 +3,14 @@ package agent
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
 	"openmanus-go/pkg/config"
 	"openmanus-go/pkg/llm"
 	"openmanus-go/pkg/logger"
+	"openmanus-go/pkg/prompts"
 	"openmanus-go/pkg/state"
 	"openmanus-go/pkg/tool"
 )
@@ -43,6 +45,7 @@ type BaseAgent struct {
 	reflector    *Reflector
 	config       *Config
 	mcpExecutor  *MCPExecutor // MCP 执行器
+	eventHandler EventHandler // 执行事件（工具开始、进度、结束）的接收者
 }
 
 // Config Agent 配置
@@ -53,6 +56,33 @@ type Config struct {
 	ReflectionSteps int           `json:"reflection_steps" mapstructure:"reflection_steps"` // 每隔几步进行反思
 	MaxRetries      int           `json:"max_retries" mapstructure:"max_retries"`
 	RetryBackoff    time.Duration `json:"retry_backoff" mapstructure:"retry_backoff"`
+
+	// StructuredOutput 规划决策和反思结果使用的结构化输出模式（none, json_object, json_schema）
+	StructuredOutput string `json:"structured_output" mapstructure:"structured_output"`
+
+	// MaxCost 单次运行的费用上限（按 Pricing 计算），0 表示不限制
+	MaxCost float64 `json:"max_cost" mapstructure:"max_cost"`
+
+	// Pricing 模型价格表（每 1K token）
+	Pricing llm.PricingTable `json:"pricing,omitempty" mapstructure:"pricing"`
+
+	// ToolProtocol 工具调用协议：auto（默认，原生函数调用，不支持时回退 ReAct）、native、react
+	ToolProtocol string `json:"tool_protocol" mapstructure:"tool_protocol"`
+
+	// PromptsDir 自定义提示模板目录，同名 .tmpl 文件覆盖内置模板
+	PromptsDir string `json:"prompts_dir" mapstructure:"prompts_dir"`
+
+	// Locale 回答使用的语言（如 zh-CN），空表示不限制
+	Locale string `json:"locale" mapstructure:"locale"`
+
+	// MaxArgRepairs 工具调用参数无效且无法自动修正时，请求模型重新生成的最大次数
+	MaxArgRepairs int `json:"max_arg_repairs" mapstructure:"max_arg_repairs"`
+
+	// Vision 模型支持图片输入时，将工具附加的图片（如浏览器截图）转发给下一轮规划
+	Vision bool `json:"vision" mapstructure:"vision"`
+
+	// PartialCheckInterval 流式工具运行期间让模型判断部分结果是否已足以回答目标的间隔，0 表示不检查
+	PartialCheckInterval time.Duration `json:"partial_check_interval" mapstructure:"partial_check_interval"`
 }
 
 // DefaultConfig 返回默认配置
@@ -64,6 +94,9 @@ func DefaultConfig() *Config {
 		ReflectionSteps: 3,
 		MaxRetries:      2,
 		RetryBackoff:    time.Second,
+		MaxArgRepairs:   defaultMaxArgRepairs,
+
+		PartialCheckInterval: defaultPartialCheckInterval,
 	}
 }
 
@@ -88,6 +121,14 @@ func ConfigFromAppConfig(appConfig *config.Config) (*Config, error) {
 	if appConfig.Agent.MaxRetries > 0 {
 		agentConfig.MaxRetries = appConfig.Agent.MaxRetries
 	}
+	agentConfig.StructuredOutput = appConfig.LLM.StructuredOutput
+	agentConfig.Vision = appConfig.LLM.Vision
+	agentConfig.ToolProtocol = appConfig.LLM.ToolProtocol
+	agentConfig.MaxCost = appConfig.Agent.MaxCost
+	agentConfig.Pricing = appConfig.ToPricingTable()
+	agentConfig.PromptsDir = appConfig.Agent.PromptsDir
+	agentConfig.Locale = appConfig.Agent.Locale
+	agentConfig.MaxArgRepairs = appConfig.Agent.MaxArgRepairs
 
 	// 转换持续时间字段
 	if appConfig.Agent.MaxDuration != "" {
@@ -106,6 +147,14 @@ func ConfigFromAppConfig(appConfig *config.Config) (*Config, error) {
 		agentConfig.RetryBackoff = backoff
 	}
 
+	if appConfig.Agent.PartialCheckInterval != "" {
+		interval, err := time.ParseDuration(appConfig.Agent.PartialCheckInterval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid partial_check_interval: %w", err)
+		}
+		agentConfig.PartialCheckInterval = interval
+	}
+
 	return agentConfig, nil
 }
 
@@ -123,6 +172,8 @@ func NewBaseAgent(llmClient llm.Client, toolRegistry *tool.Registry, config *Con
 	memory := NewMemoryWithConfig(DefaultMemoryConfig())
 	planner := NewPlanner(llmClient, toolRegistry, memory)
 	reflector := NewReflector(llmClient, memory)
+	applyPlanningConfig(planner, reflector, config)
+	warnUnpricedModel(llmClient.GetModel(), config)
 
 	return &BaseAgent{
 		llmClient:    llmClient,
@@ -134,6 +185,38 @@ func NewBaseAgent(llmClient llm.Client, toolRegistry *tool.Registry, config *Con
 	}
 }
 
+// applyPlanningConfig 将 Agent 配置中与提示和 LLM 请求相关的选项应用到规划器和反思器
+func applyPlanningConfig(planner *Planner, reflector *Reflector, config *Config) {
+	promptLoader := prompts.NewLoader(config.PromptsDir)
+
+	planner.structuredOutput = config.StructuredOutput
+	planner.vision = config.Vision
+	planner.pricing = config.Pricing
+	planner.prompts = promptLoader
+	planner.locale = config.Locale
+	if config.ToolProtocol != "" {
+		planner.toolProtocol = config.ToolProtocol
+	}
+	if config.MaxArgRepairs >= 0 {
+		planner.maxArgRepairs = config.MaxArgRepairs
+	}
+
+	reflector.structuredOutput = config.StructuredOutput
+	reflector.pricing = config.Pricing
+	reflector.prompts = promptLoader
+	reflector.locale = config.Locale
+}
+
+// warnUnpricedModel 设置了费用上限但价格表中没有当前模型时发出警告：费用始终为 0，上限不会生效
+func warnUnpricedModel(model string, config *Config) {
+	if config.MaxCost <= 0 {
+		return
+	}
+	if _, ok := config.Pricing.Lookup(model); !ok {
+		logger.Warnf("💰 [BUDGET] agent.max_cost is %.4f but model %q has no entry in llm.pricing; cost will not be tracked and the limit will never be reached", config.MaxCost, model)
+	}
+}
+
 // NewBaseAgentWithMCP 创建带 MCP 功能的基础 Agent（采用统一工具集合策略）
 func NewBaseAgentWithMCP(llmClient llm.Client, toolRegistry *tool.Registry, agentConfig *Config, appConfig *config.Config) *BaseAgent {
 	if agentConfig == nil {
@@ -161,6 +244,11 @@ func NewBaseAgentWithMCP(llmClient llm.Client, toolRegistry *tool.Registry, agen
 		// 创建 MCP 执行器
 		mcpExecutor = NewMCPExecutor(appConfig, mcpDiscovery)
 
+		// 定期发现的工具变化（新增、移除、定义更新）热同步到注册表，规划器在下一步看到新的工具列表
+		mcpDiscovery.SetOnUpdate(func() {
+			registerDiscoveredMCPTools(toolRegistry, mcpDiscovery, mcpExecutor)
+		})
+
 		// 同步启动 MCP 发现服务并注册工具到统一注册表
 		// 使用 channel 来等待 MCP 工具注册完成
 		mcpReady := make(chan struct{})
@@ -178,26 +266,7 @@ func NewBaseAgentWithMCP(llmClient llm.Client, toolRegistry *tool.Registry, agen
 			time.Sleep(2 * time.Second)
 
 			// 将发现的MCP工具注册到统一注册表
-			allTools := mcpDiscovery.GetAllTools()
-			mcpToolInfos := make([]tool.ToolInfo, 0, len(allTools))
-			for _, mcpTool := range allTools {
-				mcpToolInfos = append(mcpToolInfos, tool.ToolInfo{
-					Name:         mcpTool.Name,
-					Description:  mcpTool.Description,
-					InputSchema:  mcpTool.InputSchema,
-					OutputSchema: make(map[string]any), // MCP工具通常没有预定义的输出schema
-					Type:         tool.ToolTypeMCP,
-					ServerName:   mcpTool.ServerName,
-				})
-			}
-
-			if len(mcpToolInfos) > 0 {
-				if err := toolRegistry.RegisterMCPTools(mcpToolInfos, mcpExecutor); err != nil {
-					logger.Warnw("Failed to register MCP tools", "error", err)
-				} else {
-					logger.Infow("Successfully registered MCP tools to unified registry", "count", len(mcpToolInfos))
-				}
-			}
+			registerDiscoveredMCPTools(toolRegistry, mcpDiscovery, mcpExecutor)
 		}()
 
 		// 等待 MCP 工具注册完成，但设置超时避免无限等待
@@ -209,9 +278,18 @@ func NewBaseAgentWithMCP(llmClient llm.Client, toolRegistry *tool.Registry, agen
 		}
 	}
 
+	// 按需使用 LLM 为转存的大输出生成摘要
+	if appConfig != nil && appConfig.Tools.Artifacts.Summarize {
+		if store := toolRegistry.ArtifactStore(); store != nil {
+			store.SetSummarizer(newArtifactSummarizer(llmClient, agentConfig.Pricing))
+		}
+	}
+
 	// 创建统一的工具执行器和规划器
 	toolExecutor := tool.NewExecutor(toolRegistry, 30*time.Second)
 	planner := NewPlanner(llmClient, toolRegistry, memory) // 使用统一的规划器，传入 Memory
+	applyPlanningConfig(planner, reflector, agentConfig)
+	warnUnpricedModel(llmClient.GetModel(), agentConfig)
 
 	return &BaseAgent{
 		llmClient:    llmClient,
@@ -236,6 +314,27 @@ func (a *BaseAgent) Act(ctx context.Context, action state.Action) (*state.Observ
 	return a.toolExecutor.Execute(ctx, action)
 }
 
+// actWithProgress 执行动作并发送工具事件，流式工具运行期间定期检查部分结果是否已足够
+func (a *BaseAgent) actWithProgress(ctx context.Context, goal string, trace *state.Trace, step int, action state.Action) (*state.Observation, error) {
+	a.emit(Event{Type: EventToolStarted, Step: step, Tool: action.Name})
+
+	monitor := newPartialMonitor(ctx, a, goal, step)
+	observation, err := a.toolExecutor.ExecuteWithProgress(ctx, action, monitor.handle)
+	for _, call := range monitor.finish() {
+		trace.RecordLLMUsage(call)
+	}
+
+	if err != nil {
+		a.emit(Event{Type: EventToolFinished, Step: step, Tool: action.Name, Observation: &state.Observation{Tool: action.Name, ErrMsg: err.Error()}})
+		return nil, err
+	}
+	if observation.Stopped != "" {
+		a.emit(Event{Type: EventToolStopped, Step: step, Tool: action.Name, Observation: observation, Reason: observation.Stopped})
+	}
+	a.emit(Event{Type: EventToolFinished, Step: step, Tool: action.Name, Observation: observation})
+	return observation, nil
+}
+
 // Reflect 进行反思
 func (a *BaseAgent) Reflect(ctx context.Context, trace *state.Trace) (*state.ReflectionResult, error) {
 	return a.reflector.Reflect(ctx, trace)
@@ -285,6 +384,7 @@ func (a *BaseAgent) unifiedLoop(ctx context.Context, goal string) (string, error
 			MaxSteps:    a.config.MaxSteps,
 			MaxTokens:   a.config.MaxTokens,
 			MaxDuration: a.config.MaxDuration,
+			MaxCost:     a.config.MaxCost,
 			StartTime:   time.Now(),
 		},
 		Status:    state.TraceStatusRunning,
@@ -295,10 +395,17 @@ func (a *BaseAgent) unifiedLoop(ctx context.Context, goal string) (string, error
 	// 将轨迹保存到memory中
 	a.memory.SetCurrentTrace(trace)
 
+	// 工件摘要、工具检索向量化等附带 LLM 调用同样计入用量和费用预算
+	usage := &llmUsageCollector{pricing: a.config.Pricing}
+	ctx = state.WithLLMCallRecorder(ctx, usage.record)
+
 	var finalResult string
 
 	logger.Infof("🚀 [AGENT] Starting unified execution: %s", goal)
 	logger.Infof("📊 [BUDGET] Max steps: %d | Max tokens: %d | Max duration: %s", a.config.MaxSteps, a.config.MaxTokens, a.config.MaxDuration.String())
+	if a.config.MaxCost > 0 {
+		logger.Infof("💰 [BUDGET] Max cost: %.4f", a.config.MaxCost)
+	}
 	logger.Infof("═══════════════════════════════════════════════════════════════")
 
 	for !a.ShouldStop(trace) {
@@ -326,7 +433,7 @@ func (a *BaseAgent) unifiedLoop(ctx context.Context, goal string) (string, error
 				if lastStep.Observation.ErrMsg != "" {
 					logger.Infof("📋 [LAST_RESULT] ❌ Failed: %s", lastStep.Observation.ErrMsg)
 				} else {
-					logger.Infof("📋 [LAST_RESULT] ✅ Success: %s", a.summarizeObservation(lastStep.Observation))
+					logger.Infof("📋 [LAST_RESULT] ✅ Success: %s", summarizeObservation(lastStep.Observation, observationSummaryChars))
 				}
 			}
 		}
@@ -349,6 +456,9 @@ func (a *BaseAgent) unifiedLoop(ctx context.Context, goal string) (string, error
 
 		// 添加步骤到轨迹
 		_ = trace.AddStep(action)
+		trace.UpdateLLMCall(a.planner.LastLLMCall())
+		trace.UpdateReasoning(a.planner.LastReasoning())
+		usage.flush(trace)
 
 		// 处理直接回答 - 简化处理，直接接受
 		if action.Name == "direct_answer" {
@@ -380,8 +490,8 @@ func (a *BaseAgent) unifiedLoop(ctx context.Context, goal string) (string, error
 			}
 		}
 		logger.Infof("⚡ [EXECUTING] Running %s now...", action.Name)
-		// 2. Act: 执行动作
-		observation, err := a.Act(ctx, action)
+		// 2. Act: 执行动作（流式工具的进度转发给事件处理器，部分结果已足够时提前停止）
+		observation, err := a.actWithProgress(ctx, goal, trace, stepNum, action)
 
 		if err != nil {
 			// 执行失败，但继续运行让 Agent 处理错误
@@ -401,6 +511,18 @@ func (a *BaseAgent) unifiedLoop(ctx context.Context, goal string) (string, error
 		// 详细记录执行结果并学习
 		logger.Infof("📊 [EXECUTION_COMPLETE] Tool execution finished: %s", action.Name)
 		logger.Infof("⏱️  [EXECUTION_TIME] Latency: %d ms", observation.Latency)
+		if observation.Cache == tool.CacheHit {
+			logger.Infof("💾 [TOOL_CACHE] Result served from cache")
+		}
+		if observation.Wait > 0 {
+			logger.Infof("⏳ [TOOL_LIMIT] Queued %d ms before execution", observation.Wait)
+		}
+		if observation.Partial && observation.Stopped == "" {
+			logger.Infof("✂️  [PARTIAL] Keeping partial output reported before the failure")
+		}
+		for _, artifactID := range observation.Artifacts {
+			logger.Infof("📦 [ARTIFACT] Large output stored as %s (preview kept in observation)", artifactID)
+		}
 
 		if observation.ErrMsg != "" {
 			logger.Warnf("❌ [EXECUTION_FAILED] %s failed with error:", action.Name)
@@ -444,6 +566,7 @@ func (a *BaseAgent) unifiedLoop(ctx context.Context, goal string) (string, error
 
 			// 3. Reflect: 定期反思（每N步）
 			reflectionResult, err := a.Reflect(ctx, trace)
+			trace.RecordLLMUsage(a.reflector.LastLLMCall())
 			if err != nil {
 				logger.Warnf("⚠️  [REFLECTION_ERROR] Reflection failed: %v", err)
 			} else {
@@ -488,13 +611,18 @@ func (a *BaseAgent) unifiedLoop(ctx context.Context, goal string) (string, error
 		}
 
 		// 检查预算
+		usage.flush(trace)
 		if trace.IsExceededBudget() {
 			trace.Status = state.TraceStatusFailed // 使用现有的状态
 			finalResult = fmt.Sprintf("Execution stopped due to budget limits. Completed %d steps.", len(trace.Steps))
 			logger.Warnf("💰 [BUDGET] Execution stopped due to budget limits")
+			if trace.Budget.MaxCost > 0 && trace.Budget.UsedCost >= trace.Budget.MaxCost {
+				logger.Warnf("💰 [BUDGET] Cost limit reached: %.4f / %.4f", trace.Budget.UsedCost, trace.Budget.MaxCost)
+			}
 			break
 		}
 	}
+	usage.flush(trace)
 
 	// 持久化长期记忆
 	if err := a.memory.FlushLongTerm(); err != nil {
@@ -512,6 +640,7 @@ func (a *BaseAgent) unifiedLoop(ctx context.Context, goal string) (string, error
 	logger.Infof("📋 [SUMMARY] Goal: %s", goal)
 	logger.Infof("📊 [STATS] Steps: %d/%d | Status: %s | Duration: %v",
 		len(trace.Steps), a.config.MaxSteps, trace.Status, time.Since(trace.Budget.StartTime).Round(time.Second))
+	logUsageSummary(trace)
 	if len(trace.Steps) > 0 {
 		logger.Infof("🔍 [STEPS] Execution trace:")
 		for i, step := range trace.Steps {
@@ -527,6 +656,28 @@ func (a *BaseAgent) unifiedLoop(ctx context.Context, goal string) (string, error
 	return finalResult, nil
 }
 
+// logUsageSummary 输出本次运行的 LLM 用量和费用（按模型分列）
+func logUsageSummary(trace *state.Trace) {
+	if len(trace.Usage) == 0 {
+		return
+	}
+
+	total := trace.TotalUsage()
+	logger.Infof("💰 [COST] LLM calls: %d | Tokens: %d prompt + %d completion | Cost: %.4f",
+		total.Calls, total.PromptTokens, total.CompletionTokens, total.Cost)
+	if len(trace.Usage) > 1 {
+		models := make([]string, 0, len(trace.Usage))
+		for model := range trace.Usage {
+			models = append(models, model)
+		}
+		sort.Strings(models)
+		for _, model := range models {
+			usage := trace.Usage[model]
+			logger.Infof("   %s: %d calls | %d + %d tokens | %.4f", model, usage.Calls, usage.PromptTokens, usage.CompletionTokens, usage.Cost)
+		}
+	}
+}
+
 // generateExecutionSummary 生成执行摘要
 func (a *BaseAgent) generateExecutionSummary(trace *state.Trace) string {
 	var summary strings.Builder
@@ -571,6 +722,21 @@ func (a *BaseAgent) GetConfig() *Config {
 	return a.config
 }
 
+// PreviewPlanningRequest 构建指定目标首轮规划的完整请求（不发送），用于预览提示
+func (a *BaseAgent) PreviewPlanningRequest(goal string) *llm.ChatRequest {
+	trace := &state.Trace{
+		Goal: goal,
+		Budget: state.Budget{
+			MaxSteps:    a.config.MaxSteps,
+			MaxTokens:   a.config.MaxTokens,
+			MaxDuration: a.config.MaxDuration,
+			MaxCost:     a.config.MaxCost,
+		},
+		Status: state.TraceStatusRunning,
+	}
+	return a.planner.BuildRequest(goal, trace)
+}
+
 // GetTrace 获取最近的执行轨迹（如果有的话）
 func (a *BaseAgent) GetTrace() *state.Trace {
 	return a.memory.GetCurrentTrace()
@@ -586,33 +752,18 @@ func (a *BaseAgent) LoadTrace(id string, store state.Store) (*state.Trace, error
 	return store.Load(id)
 }
 
-// summarizeObservation 总结观测结果
-func (a *BaseAgent) summarizeObservation(obs *state.Observation) string {
-	if obs.Output == nil {
-		return "No output"
-	}
+// observationSummaryChars 日志和步骤摘要中观测结果摘要的最大字符数
+const observationSummaryChars = 100
 
-	// 尝试提取关键信息
-	if result, ok := obs.Output["result"].(string); ok {
-		if len(result) > 100 {
-			return result[:100] + "..."
-		}
-		return result
+// summarizeObservation 返回观测结果的摘要：优先使用执行器按 OutputSchema 生成的摘要，旧轨迹按输出字段生成
+func summarizeObservation(obs *state.Observation, maxLen int) string {
+	if obs.Summary == "" {
+		return tool.SummarizeOutput(obs.Output, nil, maxLen)
 	}
-
-	if success, ok := obs.Output["success"].(bool); ok {
-		if success {
-			return "Operation completed successfully"
-		} else {
-			if errMsg, ok := obs.Output["error"].(string); ok {
-				return fmt.Sprintf("Operation failed: %s", errMsg)
-			}
-			return "Operation failed"
-		}
+	if runes := []rune(obs.Summary); len(runes) > maxLen {
+		return string(runes[:maxLen]) + "..."
 	}
-
-	// 默认总结
-	return "Operation completed"
+	return obs.Summary
 }
 
 // summarizeStep 基于 Action 和 Observation 生成一句话步骤摘要
@@ -627,7 +778,7 @@ func (a *BaseAgent) summarizeStep(action state.Action, obs *state.Observation) s
 		}
 		return fmt.Sprintf("[%s] Failed: %s", action.Name, errPreview)
 	}
-	return fmt.Sprintf("[%s] Success: %s", action.Name, a.summarizeObservation(obs))
+	return fmt.Sprintf("[%s] Success: %s", action.Name, summarizeObservation(obs, observationSummaryChars))
 }
 
 // getValueType 获取值的类型描述
@@ -0,0 +1,54 @@
+package agent
+
+import (
+	"time"
+
+	"openmanus-go/pkg/logger"
+	"openmanus-go/pkg/state"
+	"openmanus-go/pkg/tool"
+)
+
+// EventType Agent 事件类型
+type EventType string
+
+const (
+	EventToolStarted  EventType = "tool_started"
+	EventToolProgress EventType = "tool_progress" // 流式工具报告的进度
+	EventToolStopped  EventType = "tool_stopped"  // 部分结果已足够，工具被提前停止
+	EventToolFinished EventType = "tool_finished"
+)
+
+// Event Agent 执行过程中的事件，供 CLI 等调用方展示进度
+type Event struct {
+	Type        EventType           `json:"type"`
+	Step        int                 `json:"step"` // 从 1 开始的步骤序号
+	Tool        string              `json:"tool"`
+	Progress    *tool.ProgressEvent `json:"progress,omitempty"`    // tool_progress
+	Observation *state.Observation  `json:"observation,omitempty"` // tool_stopped / tool_finished
+	Reason      string              `json:"reason,omitempty"`      // tool_stopped
+	Time        time.Time           `json:"time"`
+}
+
+// EventHandler 接收 Agent 事件；进度事件在工具的 goroutine 中同步调用，不应阻塞
+type EventHandler func(event Event)
+
+// SetEventHandler 设置事件处理器，nil 表示不接收事件
+func (a *BaseAgent) SetEventHandler(handler EventHandler) {
+	a.eventHandler = handler
+}
+
+// emit 发送事件，处理器的 panic 只记录不传播
+func (a *BaseAgent) emit(event Event) {
+	if a.eventHandler == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Warnw("agent.event.handler_panic", "event", event.Type, "tool", event.Tool, "panic", r)
+		}
+	}()
+	a.eventHandler(event)
+}
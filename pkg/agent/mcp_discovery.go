@@ -3,12 +3,14 @@ package agent
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
 	"openmanus-go/pkg/config"
 	"openmanus-go/pkg/logger"
 	"openmanus-go/pkg/mcp/transport"
+	"openmanus-go/pkg/tool"
 )
 
 // MCPToolInfo 描述从 MCP Server 发现的工具信息
@@ -29,6 +31,7 @@ type MCPDiscoveryService struct {
 	serverTools     map[string][]*MCPToolInfo // key: server_name
 	lastUpdate      time.Time
 	updateInterval  time.Duration
+	onUpdate        func() // 定期发现完成后调用，用于同步注册表
 }
 
 // NewMCPDiscoveryService 创建新的 MCP 发现服务
@@ -41,6 +44,13 @@ func NewMCPDiscoveryService(cfg *config.Config) *MCPDiscoveryService {
 	}
 }
 
+// SetOnUpdate 设置定期发现完成后的回调（首次发现不调用）
+func (s *MCPDiscoveryService) SetOnUpdate(onUpdate func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onUpdate = onUpdate
+}
+
 // Start 启动工具发现服务
 func (s *MCPDiscoveryService) Start(ctx context.Context) error {
 	logger.Info("Starting MCP tool discovery service")
@@ -63,6 +73,13 @@ func (s *MCPDiscoveryService) Start(ctx context.Context) error {
 			case <-ticker.C:
 				if err := s.discoverAllTools(ctx); err != nil {
 					logger.Warnw("Periodic tool discovery failed", "error", err)
+					continue
+				}
+				s.mu.RLock()
+				onUpdate := s.onUpdate
+				s.mu.RUnlock()
+				if onUpdate != nil {
+					onUpdate()
 				}
 			}
 		}
@@ -238,3 +255,64 @@ func (s *MCPDiscoveryService) GetServerStatus() map[string]interface{} {
 
 	return status
 }
+
+// LoadMCPTools 同步发现配置的 MCP 服务器提供的工具并注册到注册表，返回执行器；
+// 未配置 MCP 服务器时返回 nil。用于不创建 Agent 的场景（如 tools test）
+func LoadMCPTools(ctx context.Context, registry *tool.Registry, cfg *config.Config) *MCPExecutor {
+	if cfg == nil || len(cfg.MCP.Servers) == 0 {
+		return nil
+	}
+
+	discovery := NewMCPDiscoveryService(cfg)
+	if err := discovery.discoverAllTools(ctx); err != nil {
+		logger.Warnw("Failed to discover MCP tools", "error", err)
+	}
+	executor := NewMCPExecutor(cfg, discovery)
+	registerDiscoveredMCPTools(registry, discovery, executor)
+	return executor
+}
+
+// registerDiscoveredMCPTools 将发现的 MCP 工具同步到统一注册表
+//
+// 按服务器同步：新增或定义变化的工具被注册或替换，服务器不再提供的工具被移除；
+// 本轮发现失败的服务器保持原有工具不变。
+func registerDiscoveredMCPTools(registry *tool.Registry, discovery *MCPDiscoveryService, executor *MCPExecutor) {
+	count := 0
+	for _, serverName := range discovery.discoveredServers() {
+		mcpTools := discovery.GetToolsByServer(serverName)
+		mcpToolInfos := make([]tool.ToolInfo, 0, len(mcpTools))
+		for _, mcpTool := range mcpTools {
+			mcpToolInfos = append(mcpToolInfos, tool.ToolInfo{
+				Name:         mcpTool.Name,
+				Description:  mcpTool.Description,
+				InputSchema:  mcpTool.InputSchema,
+				OutputSchema: make(map[string]any), // MCP工具通常没有预定义的输出schema
+				Type:         tool.ToolTypeMCP,
+				ServerName:   mcpTool.ServerName,
+			})
+		}
+
+		if err := registry.SyncMCPTools(serverName, mcpToolInfos, executor); err != nil {
+			logger.Warnw("Failed to register MCP tools", "server", serverName, "error", err)
+			continue
+		}
+		count += len(mcpToolInfos)
+	}
+
+	if count > 0 {
+		logger.Infow("Successfully registered MCP tools to unified registry", "count", count, "version", registry.Version())
+	}
+}
+
+// discoveredServers 返回本轮发现成功的服务器名称（按名称排序）
+func (s *MCPDiscoveryService) discoveredServers() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	servers := make([]string, 0, len(s.serverTools))
+	for serverName := range s.serverTools {
+		servers = append(servers, serverName)
+	}
+	sort.Strings(servers)
+	return servers
+}
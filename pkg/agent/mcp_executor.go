@@ -3,17 +3,35 @@ package agent
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"openmanus-go/pkg/config"
 	"openmanus-go/pkg/mcp/transport"
+	"openmanus-go/pkg/tool"
 )
 
+// mcpPingTTL 同一 MCP 服务器健康检查结果的复用时间，避免逐个工具重复请求 tools/list
+const mcpPingTTL = 10 * time.Second
+
 // MCPExecutor 负责执行 MCP 工具调用
 type MCPExecutor struct {
 	config           *config.Config
 	discoveryService *MCPDiscoveryService
 	executionHistory map[string]*ExecutionStats
+
+	pingMu sync.Mutex
+	pings  map[string]*mcpPing // key: server_name
+}
+
+// mcpPing 一次 MCP 服务器健康检查，done 关闭后结果可用
+type mcpPing struct {
+	done    chan struct{}
+	at      time.Time
+	latency time.Duration
+	tools   map[string]bool
+	err     error
 }
 
 // ExecutionStats 记录工具执行统计信息
@@ -34,6 +52,7 @@ func NewMCPExecutor(cfg *config.Config, discoveryService *MCPDiscoveryService) *
 		config:           cfg,
 		discoveryService: discoveryService,
 		executionHistory: make(map[string]*ExecutionStats),
+		pings:            make(map[string]*mcpPing),
 	}
 }
 
@@ -72,10 +91,23 @@ func (e *MCPExecutor) callMCPTool(ctx context.Context, serverName string, server
 				} else {
 					result["content"] = content
 				}
+				// MCP 图片内容（base64）转为 data URL，作为观测图片转发给视觉模型
+				if images := mcpImageURLs(content); len(images) > 0 {
+					result[tool.OutputKeyImages] = images
+				}
 			} else {
 				// 直接使用整个结果
 				result = resultMap
 			}
+			// 工具级错误（isError）归一化为 success=false 和 error 字段
+			if isError, _ := resultMap["isError"].(bool); isError {
+				result["success"] = false
+				if text, ok := result["result"].(string); ok && text != "" {
+					result["error"] = text
+				} else {
+					result["error"] = "MCP tool reported an error"
+				}
+			}
 		} else {
 			// 非标准格式，直接包装
 			result["result"] = msg.Result
@@ -92,6 +124,32 @@ func (e *MCPExecutor) callMCPTool(ctx context.Context, serverName string, server
 	return result, nil
 }
 
+// mcpImageURLs 提取 MCP 内容数组中的图片项，返回 data URL 列表
+func mcpImageURLs(content interface{}) []string {
+	contentArray, ok := content.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	var images []string
+	for _, item := range contentArray {
+		contentItem, ok := item.(map[string]interface{})
+		if !ok || contentItem["type"] != "image" {
+			continue
+		}
+		data, _ := contentItem["data"].(string)
+		mimeType, _ := contentItem["mimeType"].(string)
+		if data == "" {
+			continue
+		}
+		if mimeType == "" {
+			mimeType = "image/png"
+		}
+		images = append(images, "data:"+mimeType+";base64,"+data)
+	}
+	return images
+}
+
 // updateExecutionStats 更新执行统计信息
 func (e *MCPExecutor) updateExecutionStats(serverName, toolName string, success bool, latency time.Duration, err error) {
 	statsKey := fmt.Sprintf("%s.%s", serverName, toolName)
@@ -164,3 +222,61 @@ func (e *MCPExecutor) ExecuteMCPTool(ctx context.Context, serverName, toolName s
 
 	return result, nil
 }
+
+// PingMCPTool 实现 tool.MCPHealthChecker 接口：通过 tools/list 检查服务器可用且仍提供该工具，
+// 同一服务器的检查结果在 mcpPingTTL 内复用
+func (e *MCPExecutor) PingMCPTool(ctx context.Context, serverName, toolName string) (tool.HealthStatus, error) {
+	serverConfig, exists := e.config.MCP.Servers[serverName]
+	if !exists {
+		return tool.HealthStatus{}, fmt.Errorf("MCP server '%s' not found in configuration", serverName)
+	}
+
+	e.pingMu.Lock()
+	ping := e.pings[serverName]
+	if ping == nil || (isClosed(ping.done) && time.Since(ping.at) > mcpPingTTL) {
+		ping = &mcpPing{done: make(chan struct{})}
+		e.pings[serverName] = ping
+		e.pingMu.Unlock()
+
+		start := time.Now()
+		tools, err := e.discoveryService.discoverToolsFromServer(ctx, serverName, serverConfig)
+		ping.latency = time.Since(start)
+		ping.at = time.Now()
+		ping.err = err
+		ping.tools = make(map[string]bool, len(tools))
+		for _, t := range tools {
+			ping.tools[t.Name] = true
+		}
+		close(ping.done)
+	} else {
+		e.pingMu.Unlock()
+	}
+
+	select {
+	case <-ping.done:
+	case <-ctx.Done():
+		return tool.HealthStatus{}, ctx.Err()
+	}
+
+	if ping.err != nil {
+		return tool.HealthStatus{}, ping.err
+	}
+	status := tool.HealthStatus{
+		Latency: ping.latency,
+		Detail:  fmt.Sprintf("server %s lists %d tools", serverName, len(ping.tools)),
+	}
+	if !ping.tools[toolName] && !ping.tools[strings.TrimPrefix(toolName, serverName+".")] {
+		return status, fmt.Errorf("tool '%s' is no longer listed by server '%s'", toolName, serverName)
+	}
+	return status, nil
+}
+
+// isClosed 判断通道是否已关闭
+func isClosed(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,166 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"openmanus-go/pkg/llm"
+	"openmanus-go/pkg/logger"
+	"openmanus-go/pkg/state"
+	"openmanus-go/pkg/tool"
+)
+
+// 部分结果检查的默认值
+const (
+	// defaultPartialCheckInterval 流式工具运行期间检查部分结果的间隔
+	defaultPartialCheckInterval = 20 * time.Second
+
+	// partialCheckMaxChars 提交给模型判断的部分输出最大字符数
+	partialCheckMaxChars = 6000
+)
+
+// partialVerdict 部分结果检查的判断
+type partialVerdict struct {
+	Sufficient bool   `json:"sufficient"`
+	Reason     string `json:"reason"`
+}
+
+// partialVerdictSchema 部分结果检查的 JSON Schema（用于 json_schema 结构化输出）
+func partialVerdictSchema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"sufficient": map[string]any{"type": "boolean"},
+			"reason":     map[string]any{"type": "string"},
+		},
+		"required":             []string{"sufficient", "reason"},
+		"additionalProperties": false,
+	}
+}
+
+// checkPartial 判断流式工具目前的部分输出是否已足以回答目标，返回判断和本次调用信息
+func (r *Reflector) checkPartial(ctx context.Context, goal string, event tool.ProgressEvent) (*partialVerdict, *state.LLMCall, error) {
+	partial, err := json.Marshal(event.Progress.Partial)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal partial output: %w", err)
+	}
+
+	progress := event.Progress.Message
+	if event.Progress.Total > 0 {
+		progress = fmt.Sprintf("%d/%d %s", event.Progress.Current, event.Progress.Total, progress)
+	}
+	req := &llm.ChatRequest{
+		Messages: []llm.Message{
+			llm.CreateSystemMessage("You monitor a long-running tool call for an autonomous agent. " +
+				"Decide whether the partial output collected so far already contains everything needed to answer the goal, " +
+				"so the tool can be stopped early. Only answer true when the remaining work cannot change the answer. " +
+				`Respond with a JSON object: {"sufficient": bool, "reason": "one short sentence"}.`),
+			llm.CreateUserMessage(fmt.Sprintf("Goal: %s\n\nTool: %s (running for %s, progress: %s)\n\nPartial output (may be truncated):\n%s",
+				goal, event.Tool, event.Elapsed.Round(time.Second), progress, preview(string(partial), partialCheckMaxChars))),
+		},
+		Temperature:    0.1,
+		ResponseFormat: llm.NewResponseFormat(r.structuredOutput, "partial_check", partialVerdictSchema()),
+	}
+
+	start := time.Now()
+	resp, err := r.llmClient.Chat(ctx, req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("partial check LLM request failed: %w", err)
+	}
+	call := newLLMCall(req, resp, time.Since(start), r.pricing)
+	if len(resp.Choices) == 0 {
+		return nil, call, fmt.Errorf("no response choices from partial check")
+	}
+
+	var verdict partialVerdict
+	if err := llm.ParseJSONObject(resp.Choices[0].Message.Content, &verdict); err != nil {
+		return nil, call, fmt.Errorf("failed to parse partial check response: %w", err)
+	}
+	return &verdict, call, nil
+}
+
+// partialMonitor 在一次工具调用期间转发进度事件，并定期让模型判断部分结果是否已足够
+type partialMonitor struct {
+	agent    *BaseAgent
+	ctx      context.Context
+	cancel   context.CancelFunc
+	goal     string
+	step     int
+	interval time.Duration
+
+	mu        sync.Mutex
+	lastCheck time.Time
+	checking  bool
+	calls     []*state.LLMCall
+	wg        sync.WaitGroup
+}
+
+// newPartialMonitor 创建部分结果监视器，interval 为 0 时只转发事件
+func newPartialMonitor(ctx context.Context, a *BaseAgent, goal string, step int) *partialMonitor {
+	ctx, cancel := context.WithCancel(ctx)
+	return &partialMonitor{
+		agent:     a,
+		ctx:       ctx,
+		cancel:    cancel,
+		goal:      goal,
+		step:      step,
+		interval:  a.config.PartialCheckInterval,
+		lastCheck: time.Now(),
+	}
+}
+
+// handle 实现 tool.ProgressHandler
+func (m *partialMonitor) handle(event tool.ProgressEvent, stop tool.StopFunc) {
+	m.agent.emit(Event{Type: EventToolProgress, Step: m.step, Tool: event.Tool, Progress: &event})
+
+	if m.interval <= 0 || event.Progress.Partial == nil {
+		return
+	}
+	m.mu.Lock()
+	if m.checking || time.Since(m.lastCheck) < m.interval {
+		m.mu.Unlock()
+		return
+	}
+	m.checking = true
+	m.lastCheck = time.Now()
+	m.mu.Unlock()
+
+	// 判断在后台进行，不阻塞工具
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		verdict, call, err := m.agent.reflector.checkPartial(m.ctx, m.goal, event)
+
+		m.mu.Lock()
+		m.checking = false
+		if call != nil {
+			m.calls = append(m.calls, call)
+		}
+		m.mu.Unlock()
+
+		if err != nil {
+			if m.ctx.Err() == nil {
+				logger.Warnw("agent.partial_check.failed", "tool", event.Tool, "error", err)
+			}
+			return
+		}
+		logger.Debugw("agent.partial_check", "tool", event.Tool, "seq", event.Seq, "sufficient", verdict.Sufficient, "reason", verdict.Reason)
+		if verdict.Sufficient {
+			logger.Infof("✋ [TOOL_STOP] Partial results of %s already answer the goal: %s", event.Tool, verdict.Reason)
+			stop(verdict.Reason)
+		}
+	}()
+}
+
+// finish 取消进行中的检查并等待结束，返回检查产生的 LLM 调用
+func (m *partialMonitor) finish() []*state.LLMCall {
+	m.cancel()
+	m.wg.Wait()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.calls
+}
@@ -2,21 +2,44 @@ package agent
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"openmanus-go/pkg/llm"
 	"openmanus-go/pkg/logger"
+	"openmanus-go/pkg/prompts"
 	"openmanus-go/pkg/state"
 	"openmanus-go/pkg/tool"
 )
 
+// planningInstruction 附加在规划提示末尾的指令
+const planningInstruction = "\n\nPlease decide the next action:"
+
 // Planner 规划器（统一工具选择策略）
 type Planner struct {
-	llmClient    llm.Client
-	toolRegistry *tool.Registry
-	memory       *Memory // 添加内存引用
+	llmClient     llm.Client
+	toolRegistry  *tool.Registry
+	memory        *Memory        // 添加内存引用
+	lastCall      *state.LLMCall // 最近一次规划请求的调用信息
+	lastReasoning string         // 最近一次规划响应中的推理内容
+
+	structuredOutput string // 决策的结构化输出模式
+	vision           bool   // 模型是否支持图片输入
+
+	pricing llm.PricingTable // 模型价格表，用于计算调用费用
+
+	prompts *prompts.Loader // 系统提示模板
+	locale  string          // 回答语言
+
+	toolProtocol string // 工具调用协议：auto, native, react
+	reactMode    bool   // auto 模式下检测到模型不支持函数调用后切换为 ReAct
+
+	maxArgRepairs int // 工具参数无效时请求模型修正的最大次数
+
+	toolsVersion uint64               // 上次规划时的注册表版本
+	toolsSeen    bool                 // 是否已记录过注册表版本
+	toolChanges  []tool.RegistryEvent // 自上次规划以来的工具变更，提示模型工具列表已更新
 }
 
 // NewPlanner 创建规划器
@@ -25,6 +48,10 @@ func NewPlanner(llmClient llm.Client, toolRegistry *tool.Registry, memory *Memor
 		llmClient:    llmClient,
 		toolRegistry: toolRegistry,
 		memory:       memory,
+		prompts:      prompts.DefaultLoader,
+		toolProtocol: ToolProtocolAuto,
+
+		maxArgRepairs: defaultMaxArgRepairs,
 	}
 }
 
@@ -35,180 +62,359 @@ func (p *Planner) Plan(ctx context.Context, goal string, trace *state.Trace) (st
 	return p.standardPlan(ctx, goal, trace)
 }
 
-// standardPlan 标准规划流程（原有逻辑）
-func (p *Planner) standardPlan(ctx context.Context, goal string, trace *state.Trace) (state.Action, error) {
+// BuildRequest 构建规划请求（不发送），用于预览最终提示
+func (p *Planner) BuildRequest(goal string, trace *state.Trace) *llm.ChatRequest {
+	req, _, _ := p.buildRequest(context.Background(), goal, trace)
+	return req
+}
+
+// buildRequest 构建规划请求，同时返回系统提示和上下文提示
+func (p *Planner) buildRequest(ctx context.Context, goal string, trace *state.Trace) (*llm.ChatRequest, string, string) {
+	// 工具在运行中变化（如 MCP 工具热更新）时刷新工具列表，并在上下文中提示模型
+	p.refreshTools()
+
+	// 挑选本步发送给模型的工具
+	manifest := p.selectTools(ctx, goal, trace)
+
 	// 构建系统提示
-	systemPrompt := p.buildSystemPrompt()
+	systemPrompt := p.buildSystemPrompt(goal, manifest)
+
+	// 构建工具清单和工具定义；ReAct 协议下工具以文本形式说明，不发送函数定义
+	var toolsPrompt string
+	var tools []llm.Tool
+	useReAct := p.useReAct()
+	if useReAct {
+		toolsPrompt = buildReActPrompt(manifest)
+	} else {
+		toolsPrompt = p.buildToolsPrompt(manifest)
+		tools = p.buildLLMTools(manifest)
+	}
 
-	// 构建上下文
-	contextPrompt := p.buildContextPrompt(goal, trace)
+	// 上一步工具附加的图片（仅视觉模型）
+	fixedMessages := []llm.Message{
+		llm.CreateSystemMessage(systemPrompt),
+		llm.CreateUserMessage(toolsPrompt + planningInstruction),
+	}
+	imageMessage, hasImages := p.buildImageMessage(trace)
+	if hasImages {
+		fixedMessages = append(fixedMessages, imageMessage)
+	}
 
-	// 构建工具清单
-	toolsPrompt := p.buildToolsPrompt()
+	// 构建上下文（扣除系统提示、工具清单和工具定义后按模型窗口分配预算）
+	fixedTokens := llm.EstimateMessagesTokens(fixedMessages) + llm.EstimateToolsTokens(tools)
+	contextPrompt := p.buildContextPrompt(goal, trace, promptBudget(p.llmClient.GetModel(), fixedTokens))
 
 	// 准备消息
 	messages := []llm.Message{
 		llm.CreateSystemMessage(systemPrompt),
-		llm.CreateUserMessage(contextPrompt + "\n\n" + toolsPrompt + "\n\nPlease decide the next action:"),
+		llm.CreateUserMessage(contextPrompt + "\n\n" + toolsPrompt + planningInstruction),
+	}
+	if hasImages {
+		messages = append(messages, imageMessage)
 	}
-
-	// 准备工具定义
-	tools := p.buildLLMTools()
 
 	// 创建请求
 	req := &llm.ChatRequest{
 		Messages:    messages,
-		Tools:       tools,
-		ToolChoice:  "auto",
 		Temperature: 0.1,
 	}
+	if useReAct {
+		// ReAct 为纯文本协议，不能与 JSON 输出约束同时使用
+		return req, systemPrompt, contextPrompt
+	}
+	req.Tools = tools
+	req.ToolChoice = "auto"
+	req.ResponseFormat = llm.NewResponseFormat(p.structuredOutput, "decision", decisionSchema())
+
+	return req, systemPrompt, contextPrompt
+}
+
+// standardPlan 标准规划流程（原有逻辑）
+func (p *Planner) standardPlan(ctx context.Context, goal string, trace *state.Trace) (state.Action, error) {
+	req, systemPrompt, contextPrompt := p.buildRequest(ctx, goal, trace)
+	tools := req.Tools
 
 	// 打印完整的思考过程提示
 	logger.Infof("🧠 [THINKING] Sending planning request to LLM...")
 	logger.Infof("📋 [CONTEXT] System prompt length: %d chars", len(systemPrompt))
 	logger.Infof("📋 [CONTEXT] Context prompt length: %d chars", len(contextPrompt))
 	logger.Infof("🔧 [TOOLS] Available tools: %d", len(tools))
+	logger.Infof("📏 [CONTEXT] Estimated prompt tokens: ~%d / %d window", llm.EstimateRequestTokens(req), llm.ContextWindow(p.llmClient.GetModel()))
+
+	// 发送请求；工具参数无效时追加纠错轮次重新请求，最多 maxArgRepairs 次
+	p.lastCall = nil
+	p.lastReasoning = ""
+	for attempt := 0; ; attempt++ {
+		start := time.Now()
+		resp, err := p.llmClient.Chat(ctx, req)
+		if err != nil {
+			if attempt == 0 && p.toolProtocol == ToolProtocolAuto && !p.reactMode && isToolsUnsupportedError(err) {
+				logger.Warnf("🔁 [REACT] Model rejected function calling (%v), switching to ReAct text protocol", err)
+				p.reactMode = true
+				action, err := p.standardPlan(ctx, goal, trace)
+				if err != nil {
+					// ReAct 请求同样失败，说明错误与函数调用无关，后续步骤恢复原生协议
+					logger.Warnf("🔁 [REACT] ReAct request also failed, keeping function calling for later steps")
+					p.reactMode = false
+				}
+				return action, err
+			}
+			return state.Action{}, fmt.Errorf("LLM request failed: %w", err)
+		}
+		p.lastCall = mergeLLMCall(p.lastCall, newLLMCall(req, resp, time.Since(start), p.pricing))
+		if resp.CacheHit {
+			logger.Infof("💾 [LLM_CACHE] Planning response served from cache")
+		}
 
-	// 发送请求
-	resp, err := p.llmClient.Chat(ctx, req)
-	if err != nil {
-		return state.Action{}, fmt.Errorf("LLM request failed: %w", err)
-	}
+		if len(resp.Choices) == 0 {
+			return state.Action{}, fmt.Errorf("no response choices")
+		}
 
-	if len(resp.Choices) == 0 {
-		return state.Action{}, fmt.Errorf("no response choices")
-	}
+		choice := resp.Choices[0]
 
-	choice := resp.Choices[0]
+		// 推理模型的思考过程单独保存，不会进入后续提示
+		p.lastReasoning = choice.Message.ReasoningContent
+		if p.lastReasoning != "" {
+			logger.Infof("🧩 [REASONING] Model reasoning captured: %d chars", len(p.lastReasoning))
+			logger.Debugw("agent.plan.reasoning", "preview", preview(p.lastReasoning, 300))
+		}
 
-	// 打印LLM的完整思考内容
-	logger.Infof("🧠 [LLM_THINKING] ═══════════════════════════════════════")
-	if choice.Message.Content != "" {
-		logger.Infof("💭 [LLM_REASONING] %s", choice.Message.Content)
-	}
+		// 打印LLM的完整思考内容
+		logger.Infof("🧠 [LLM_THINKING] ═══════════════════════════════════════")
+		if choice.Message.Content != "" {
+			logger.Infof("💭 [LLM_REASONING] %s", choice.Message.Content)
+		}
 
-	// 详细的LLM响应日志
-	if len(choice.Message.ToolCalls) > 0 {
-		logger.Infof("🛠️  [LLM_DECISION] LLM decided to use a tool")
-		toolCall := choice.Message.ToolCalls[0]
+		// 详细的LLM响应日志
+		if len(choice.Message.ToolCalls) > 0 {
+			logger.Infof("🛠️  [LLM_DECISION] LLM decided to use a tool")
+			toolCall := choice.Message.ToolCalls[0]
+
+			// 获取工具信息以判断类型
+			toolInfo := p.getToolInfo(toolCall.Function.Name)
+			toolTypeSymbol := "🔧" // 默认内置工具
+			toolTypeText := "Built-in"
+			if toolInfo != nil && toolInfo.Type == tool.ToolTypeMCP {
+				toolTypeSymbol = "🌐"
+				toolTypeText = "MCP"
+			}
 
-		// 获取工具信息以判断类型
-		toolInfo := p.getToolInfo(toolCall.Function.Name)
-		toolTypeSymbol := "🔧" // 默认内置工具
-		toolTypeText := "Built-in"
-		if toolInfo != nil && toolInfo.Type == tool.ToolTypeMCP {
-			toolTypeSymbol = "🌐"
-			toolTypeText = "MCP"
-		}
+			logger.Infof("🎯 [TOOL_SELECTED] %s %s (%s tool)", toolTypeSymbol, toolCall.Function.Name, toolTypeText)
+			if toolInfo != nil && toolInfo.ServerName != "" {
+				logger.Infof("📡 [MCP_SERVER] From MCP server: %s", toolInfo.ServerName)
+			}
 
-		logger.Infof("🎯 [TOOL_SELECTED] %s %s (%s tool)", toolTypeSymbol, toolCall.Function.Name, toolTypeText)
-		if toolInfo != nil && toolInfo.ServerName != "" {
-			logger.Infof("📡 [MCP_SERVER] From MCP server: %s", toolInfo.ServerName)
-		}
+			// 显示工具描述
+			if toolInfo != nil {
+				logger.Infof("📝 [TOOL_DESC] %s", toolInfo.Description)
+			}
 
-		// 显示工具描述
-		if toolInfo != nil {
-			logger.Infof("📝 [TOOL_DESC] %s", toolInfo.Description)
-		}
+			// 宽松解析并按 InputSchema 修正参数
+			args, err := repairToolArguments(toolCall.Function.Arguments, toolInfo)
+			if err != nil {
+				if attempt >= p.maxArgRepairs {
+					return state.Action{}, fmt.Errorf("invalid arguments for tool %s after %d repair attempt(s): %w", toolCall.Function.Name, attempt, err)
+				}
+				logger.Warnf("🩹 [ARG_REPAIR] Invalid arguments for %s (attempt %d/%d): %v", toolCall.Function.Name, attempt+1, p.maxArgRepairs, err)
+				req.Messages = append(req.Messages, argRepairMessages(choice.Message, toolCall, err)...)
+				continue
+			}
 
-		args, err := llm.ParseToolCallArguments(toolCall.Function.Arguments)
-		if err != nil {
-			return state.Action{}, fmt.Errorf("failed to parse tool arguments: %w", err)
+			// 详细显示工具参数和计划
+			logger.Infof("⚙️  [TOOL_ARGS] Tool arguments:")
+			for key, value := range args {
+				if valueStr := fmt.Sprintf("%v", value); len(valueStr) > 100 {
+					logger.Infof("    %s: <%s, %d chars>", key, getValueType(value), len(valueStr))
+				} else {
+					logger.Infof("    %s: %v", key, value)
+				}
+			}
+
+			logger.Infof("🎯 [ACTION_PLAN] Will execute: %s with %d parameters", toolCall.Function.Name, len(args))
+			logger.Infof("🧠 [LLM_THINKING] ═══════════════════════════════════════")
+
+			return state.Action{
+				Name: toolCall.Function.Name,
+				Args: args,
+			}, nil
+		} else {
+			logger.Infof("💭 [LLM_DECISION] LLM decided not to use any tools")
+			if choice.Message.Content != "" {
+				logger.Infof("📝 [LLM_RESPONSE] LLM response: %s", truncateString(choice.Message.Content, 150))
+			}
+			logger.Infof("🧠 [LLM_THINKING] ═══════════════════════════════════════")
 		}
 
-		// 详细显示工具参数和计划
-		logger.Infof("⚙️  [TOOL_ARGS] Tool arguments:")
-		for key, value := range args {
-			if valueStr := fmt.Sprintf("%v", value); len(valueStr) > 100 {
-				logger.Infof("    %s: <%s, %d chars>", key, getValueType(value), len(valueStr))
-			} else {
-				logger.Infof("    %s: %v", key, value)
+		// 处理直接回答
+		if choice.Message.Content != "" {
+			// 尝试解析为 ReAct 文本协议（Thought / Action / Action Input）
+			if p.toolProtocol != ToolProtocolNative {
+				if step, ok := parseReAct(choice.Message.Content); ok {
+					return p.convertReActToAction(step), nil
+				}
+			}
+
+			// 尝试解析为 JSON 决策（容忍代码块、前后缀文本和尾逗号）
+			var decision state.Decision
+			if err := llm.ParseJSONObject(choice.Message.Content, &decision); err == nil && isKnownDecision(decision.Type) {
+				return p.convertDecisionToAction(decision), nil
 			}
+
+			// 否则作为直接回答处理
+			return state.Action{
+				Name: "direct_answer",
+				Args: map[string]any{
+					"answer": choice.Message.Content,
+				},
+			}, nil
 		}
 
-		logger.Infof("🎯 [ACTION_PLAN] Will execute: %s with %d parameters", toolCall.Function.Name, len(args))
-		logger.Infof("🧠 [LLM_THINKING] ═══════════════════════════════════════")
+		return state.Action{}, fmt.Errorf("no valid response from LLM")
+	}
+}
+
+// useReAct 判断当前是否使用 ReAct 文本协议
+func (p *Planner) useReAct() bool {
+	return p.toolProtocol == ToolProtocolReAct || (p.toolProtocol == ToolProtocolAuto && p.reactMode)
+}
 
+// convertReActToAction 将 ReAct 解析结果转换为动作
+func (p *Planner) convertReActToAction(step *reactStep) state.Action {
+	if step.HasFinal {
+		logger.Infof("🏁 [REACT] Final answer parsed from text protocol")
 		return state.Action{
-			Name: toolCall.Function.Name,
-			Args: args,
-		}, nil
-	} else {
-		logger.Infof("💭 [LLM_DECISION] LLM decided not to use any tools")
-		if choice.Message.Content != "" {
-			logger.Infof("📝 [LLM_RESPONSE] LLM response: %s", truncateString(choice.Message.Content, 150))
+			Name:   "direct_answer",
+			Args:   map[string]any{"answer": step.FinalAnswer},
+			Reason: step.Thought,
 		}
-		logger.Infof("🧠 [LLM_THINKING] ═══════════════════════════════════════")
 	}
 
-	// 处理直接回答
-	if choice.Message.Content != "" {
-		// 尝试解析为 JSON 决策
-		var decision state.Decision
-		if err := json.Unmarshal([]byte(choice.Message.Content), &decision); err == nil {
-			return p.convertDecisionToAction(decision), nil
-		}
+	toolInfo := p.getToolInfo(step.Action)
+	if toolInfo == nil {
+		logger.Warnf("⚠️  [REACT] Model selected unknown tool: %s", step.Action)
+	}
+	args := resolveReActInput(step.Input, toolInfo)
 
-		// 否则作为直接回答处理
-		return state.Action{
-			Name: "direct_answer",
-			Args: map[string]any{
-				"answer": choice.Message.Content,
-			},
-		}, nil
+	logger.Infof("🎯 [REACT] Action parsed from text protocol: %s %s", step.Action, truncateString(formatReActInput(args), 200))
+	return state.Action{
+		Name:   step.Action,
+		Args:   args,
+		Reason: step.Thought,
 	}
+}
 
-	return state.Action{}, fmt.Errorf("no valid response from LLM")
+// LastLLMCall 返回最近一次规划请求的调用信息
+func (p *Planner) LastLLMCall() *state.LLMCall {
+	return p.lastCall
 }
 
-// buildSystemPrompt 构建系统提示（统一工具选择策略）
-func (p *Planner) buildSystemPrompt() string {
-	return `You are OpenManus-Go, a generalist agent that helps users accomplish their goals.
+// LastReasoning 返回最近一次规划响应中的推理内容
+func (p *Planner) LastReasoning() string {
+	return p.lastReasoning
+}
 
-Your task is to maintain a loop of: Plan -> (Direct Answer | Tool Use) -> Observe -> Reflect -> Decide Next.
+// mergeLLMCall 合并同一步骤内多次请求（如参数纠错轮次）的调用信息
+func mergeLLMCall(total, call *state.LLMCall) *state.LLMCall {
+	if total == nil {
+		return call
+	}
+	merged := *call
+	merged.PromptTokens += total.PromptTokens
+	merged.CompletionTokens += total.CompletionTokens
+	merged.Cost += total.Cost
+	merged.Latency += total.Latency
+	merged.CacheHit = total.CacheHit && call.CacheHit
+	return &merged
+}
 
-CRITICAL PRIORITY: If you have data from previous tool calls, FIRST analyze whether this data is sufficient to answer the user's question. If it is sufficient, immediately use direct_answer to provide the answer based on the available data.
+// newLLMCall 根据请求和响应构建 LLM 调用信息
+func newLLMCall(req *llm.ChatRequest, resp *llm.ChatResponse, latency time.Duration, pricing llm.PricingTable) *state.LLMCall {
+	model := resp.Model
+	if model == "" {
+		model = req.Model
+	}
+	call := &state.LLMCall{
+		Model:            model,
+		PromptTokens:     resp.Usage.PromptTokens,
+		CompletionTokens: resp.Usage.CompletionTokens,
+		CacheHit:         resp.CacheHit,
+		Latency:          latency.Milliseconds(),
+	}
 
-Guidelines:
-1. **HIGHEST PRIORITY**: When you have data from previous tool calls, analyze it first to see if it answers the user's question
-2. If the data is sufficient, provide a direct_answer immediately - don't call more tools
-3. Only call additional tools if the existing data is insufficient or incomplete
-4. Choose the most appropriate tool from all available tools (both built-in and external tools)
-5. All tools are treated equally - select based on functionality, not tool type
-6. Always follow the tool registry strictly and return valid JSON arguments
-7. Stop when the user goal is satisfied or no more useful action can be taken
+	// 缓存命中不产生费用；价格表优先按请求模型匹配，再按响应中的模型名匹配
+	if !resp.CacheHit {
+		if cost, ok := pricing.Cost(req.Model, call.PromptTokens, call.CompletionTokens); ok {
+			call.Cost = cost
+		} else if cost, ok := pricing.Cost(model, call.PromptTokens, call.CompletionTokens); ok {
+			call.Cost = cost
+		}
+	}
+	return call
+}
 
-Available Tool Types:
-- Built-in tools: For local operations (file system, calculations, etc.)
-- External tools: For remote data/services (APIs, databases, web services, etc.)
+// buildSystemPrompt 构建系统提示（统一工具选择策略），内容来自 planner_system 模板
+func (p *Planner) buildSystemPrompt(goal string, manifest []tool.ToolInfo) string {
+	data := prompts.Data{
+		Goal:   goal,
+		Tools:  manifest,
+		Locale: p.locale,
+	}
+	if p.memory != nil {
+		data.Memory = p.memory.GetSummary()
+	}
+	return renderSystemPrompt(p.prompts, prompts.PlannerSystem, data)
+}
 
-Decision Types:
-- DIRECT_ANSWER: Provide a direct response to the user (USE THIS when you have sufficient data)
-- USE_TOOL: Call a tool with appropriate arguments (only if more data is needed)
-- ASK_CLARIFICATION: Ask for more information from the user
-- STOP: Stop execution with a reason
+// renderSystemPrompt 渲染系统提示模板，自定义模板出错时回退到内置模板
+func renderSystemPrompt(loader *prompts.Loader, name string, data prompts.Data) string {
+	if loader == nil {
+		loader = prompts.DefaultLoader
+	}
+
+	prompt, err := loader.Render(name, data)
+	if err == nil {
+		return prompt
+	}
 
-Always respond with either a tool call or a JSON decision in the format:
-{"type": "DECISION_TYPE", "content": "response", "reason": "explanation"}`
+	logger.Warnf("⚠️  [PROMPT] Failed to render %s, falling back to built-in template: %v", name, err)
+	prompt, err = prompts.DefaultLoader.Render(name, data)
+	if err != nil {
+		logger.Errorw("agent.prompt.render_failed", "template", name, "error", err)
+	}
+	return prompt
 }
 
 // buildContextPrompt 构建上下文提示（增强版，使用 Memory 分析）
-func (p *Planner) buildContextPrompt(goal string, trace *state.Trace) string {
-	var context strings.Builder
-
-	context.WriteString(fmt.Sprintf("GOAL: %s\n\n", goal))
+//
+// 提示按段落组装，总长度超过 budget（token）时优先压缩低优先级段落。
+func (p *Planner) buildContextPrompt(goal string, trace *state.Trace, budget int) string {
+	sections := []promptSection{{
+		Name:     "goal",
+		Content:  fmt.Sprintf("GOAL: %s\n\n", goal),
+		Priority: 100,
+		Fixed:    true,
+	}}
 
 	// 检查是否有成功的工具调用数据
 	hasSuccessfulToolData := false
 	var latestToolData string
 
 	if len(trace.Steps) == 0 {
-		context.WriteString("CONTEXT: This is the first step. No previous actions have been taken.\n")
+		sections = append(sections, promptSection{
+			Name:     "first_step",
+			Content:  "CONTEXT: This is the first step. No previous actions have been taken.\n",
+			Priority: 90,
+			Fixed:    true,
+		})
 
 		// 检查长期记忆中是否有相关经验
 		if p.memory != nil {
 			if similarGoalContext, exists := p.memory.GetContextualInfo("similar_goal_patterns"); exists {
-				context.WriteString(fmt.Sprintf("💡 EXPERIENCE: Previous experience with similar goals: %v\n", similarGoalContext))
+				sections = append(sections, promptSection{
+					Name:     "experience",
+					Content:  fmt.Sprintf("💡 EXPERIENCE: Previous experience with similar goals: %v\n", similarGoalContext),
+					Priority: 10,
+				})
 			}
 		}
 	} else {
@@ -219,21 +425,25 @@ func (p *Planner) buildContextPrompt(goal string, trace *state.Trace) string {
 			successfulSteps := p.memory.GetSuccessfulSteps()
 
 			if len(failedSteps) > 0 {
-				context.WriteString("⚠️  FAILURE ANALYSIS:\n")
+				var failure strings.Builder
+				failure.WriteString("⚠️  FAILURE ANALYSIS:\n")
 				failurePatterns := p.analyzeFailurePatterns(failedSteps)
 				for _, pattern := range failurePatterns {
-					context.WriteString(fmt.Sprintf("- %s\n", pattern))
+					failure.WriteString(fmt.Sprintf("- %s\n", pattern))
 				}
-				context.WriteString("\n")
+				failure.WriteString("\n")
+				sections = append(sections, promptSection{Name: "failure_analysis", Content: failure.String(), Priority: 30})
 			}
 
 			if len(successfulSteps) > 0 {
-				context.WriteString("✅ SUCCESS PATTERNS:\n")
+				var success strings.Builder
+				success.WriteString("✅ SUCCESS PATTERNS:\n")
 				successPatterns := p.analyzeSuccessPatterns(successfulSteps)
 				for _, pattern := range successPatterns {
-					context.WriteString(fmt.Sprintf("- %s\n", pattern))
+					success.WriteString(fmt.Sprintf("- %s\n", pattern))
 				}
-				context.WriteString("\n")
+				success.WriteString("\n")
+				sections = append(sections, promptSection{Name: "success_patterns", Content: success.String(), Priority: 20})
 			}
 		}
 
@@ -250,80 +460,112 @@ func (p *Planner) buildContextPrompt(goal string, trace *state.Trace) string {
 			}
 		}
 
-		context.WriteString("RECENT STEPS:\n")
+		// compact 为仅包含步骤摘要的紧凑版本，超出预算时替换完整版本
+		var steps, compact strings.Builder
+		steps.WriteString("RECENT STEPS:\n")
+		compact.WriteString("RECENT STEPS:\n")
 		for i, step := range recentSteps {
 			stepNum := len(trace.Steps) - len(recentSteps) + i + 1
-			context.WriteString(fmt.Sprintf("Step %d: %s", stepNum, step.Action.Name))
+			steps.WriteString(fmt.Sprintf("Step %d: %s", stepNum, step.Action.Name))
 			if step.Action.Reason != "" {
-				context.WriteString(fmt.Sprintf(" (%s)", step.Action.Reason))
+				steps.WriteString(fmt.Sprintf(" (%s)", step.Action.Reason))
+			}
+			steps.WriteString("\n")
+
+			if step.Summary != "" {
+				compact.WriteString(fmt.Sprintf("Step %d: %s\n", stepNum, step.Summary))
+			} else {
+				compact.WriteString(fmt.Sprintf("Step %d: %s\n", stepNum, step.Action.Name))
 			}
-			context.WriteString("\n")
 
 			if step.Observation != nil {
 				if step.Observation.ErrMsg != "" {
-					context.WriteString(fmt.Sprintf("  Result: ERROR - %s\n", step.Observation.ErrMsg))
+					steps.WriteString(fmt.Sprintf("  Result: ERROR - %s\n", step.Observation.ErrMsg))
 				} else {
 					// 检查是否是成功的工具调用
 					if len(step.Observation.Output) > 0 {
 						hasSuccessfulToolData = true
 						// 保存最新的成功工具数据
-						if rawOutput, ok := step.Observation.Output["content"]; ok {
-							latestToolData = fmt.Sprintf("%v", rawOutput)
-						} else if rawOutput, ok := step.Observation.Output["result"]; ok {
-							latestToolData = fmt.Sprintf("%v", rawOutput)
-						} else if jsonBytes, err := json.Marshal(step.Observation.Output); err == nil {
-							latestToolData = string(jsonBytes)
-						}
+						latestToolData = p.toolData(step.Action.Name, step.Observation)
 					}
 
 					// 截断长输出
-					output := p.summarizeOutput(step.Observation.Output)
-					context.WriteString(fmt.Sprintf("  Result: %s\n", output))
+					output := p.summarizeOutput(step.Action.Name, step.Observation)
+					steps.WriteString(fmt.Sprintf("  Result: %s\n", output))
+				}
+				if step.Observation.Stopped != "" {
+					steps.WriteString(fmt.Sprintf("  Note: tool stopped early (%s); the result above is partial\n", step.Observation.Stopped))
+				} else if step.Observation.Partial {
+					steps.WriteString(fmt.Sprintf("  Partial output before the error: %s\n", p.summarizeOutput(step.Action.Name, step.Observation)))
 				}
 			}
 		}
-		context.WriteString("\n")
+		steps.WriteString("\n")
+		compact.WriteString("\n")
+		sections = append(sections, promptSection{
+			Name:     "recent_steps",
+			Content:  steps.String(),
+			Compact:  compact.String(),
+			Priority: 50,
+		})
 	}
 
 	// 如果有成功的工具数据，添加分析指导
 	if hasSuccessfulToolData {
-		context.WriteString("🎯 IMPORTANT - DATA ANALYSIS PRIORITY:\n")
-		context.WriteString("You have successfully obtained data from previous tool calls. Your FIRST task is to analyze this data and determine if it's sufficient to answer the user's question.\n")
-		context.WriteString("If the data answers the user's question, immediately provide a direct_answer based on this data.\n")
-		context.WriteString("Only call additional tools if the existing data is insufficient.\n\n")
+		sections = append(sections, promptSection{
+			Name: "data_priority",
+			Content: "🎯 IMPORTANT - DATA ANALYSIS PRIORITY:\n" +
+				"You have successfully obtained data from previous tool calls. Your FIRST task is to analyze this data and determine if it's sufficient to answer the user's question.\n" +
+				"If the data answers the user's question, immediately provide a direct_answer based on this data.\n" +
+				"Only call additional tools if the existing data is insufficient.\n\n",
+			Priority: 80,
+			Fixed:    true,
+		})
 
 		if latestToolData != "" {
-			context.WriteString("LATEST TOOL DATA TO ANALYZE:\n")
-			context.WriteString(latestToolData)
-			context.WriteString("\n\n")
+			sections = append(sections, promptSection{
+				Name:     "latest_tool_data",
+				Content:  "LATEST TOOL DATA TO ANALYZE:\n" + latestToolData + "\n\n",
+				Priority: 40,
+			})
 		}
 	}
 
 	// 添加最新反思信息
 	latestReflection := trace.GetLatestReflection()
 	if latestReflection != nil {
-		context.WriteString("🤖 LATEST REFLECTION:\n")
-		context.WriteString(fmt.Sprintf("- Reason: %s\n", latestReflection.Result.Reason))
+		var reflection strings.Builder
+		reflection.WriteString("🤖 LATEST REFLECTION:\n")
+		reflection.WriteString(fmt.Sprintf("- Reason: %s\n", latestReflection.Result.Reason))
 		if latestReflection.Result.RevisePlan {
-			context.WriteString("- ⚠️ Plan revision suggested\n")
+			reflection.WriteString("- ⚠️ Plan revision suggested\n")
 		}
 		if latestReflection.Result.NextActionHint != "" {
-			context.WriteString(fmt.Sprintf("- 💡 Next action hint: %s\n", latestReflection.Result.NextActionHint))
+			reflection.WriteString(fmt.Sprintf("- 💡 Next action hint: %s\n", latestReflection.Result.NextActionHint))
 		}
-		context.WriteString(fmt.Sprintf("- Confidence: %.2f\n", latestReflection.Result.Confidence))
-		context.WriteString("\n")
+		reflection.WriteString(fmt.Sprintf("- Confidence: %.2f\n", latestReflection.Result.Confidence))
+		reflection.WriteString("\n")
+		sections = append(sections, promptSection{Name: "reflection", Content: reflection.String(), Priority: 60})
 	}
 
-	// 添加预算信息
-	context.WriteString(fmt.Sprintf("BUDGET: %d/%d steps used\n",
-		trace.Budget.UsedSteps, trace.Budget.MaxSteps))
+	// 添加工具变更信息
+	if notice := toolChangesNotice(p.toolChanges); notice != "" {
+		sections = append(sections, promptSection{Name: "tool_changes", Content: notice, Priority: 80})
+	}
 
-	return context.String()
+	// 添加预算信息
+	sections = append(sections, promptSection{
+		Name:     "budget",
+		Content:  fmt.Sprintf("BUDGET: %d/%d steps used\n", trace.Budget.UsedSteps, trace.Budget.MaxSteps),
+		Priority: 100,
+		Fixed:    true,
+	})
+
+	return fitSections(sections, budget)
 }
 
 // buildToolsPrompt 构建工具提示
-func (p *Planner) buildToolsPrompt() string {
-	tools := p.toolRegistry.GetToolsManifest()
+func (p *Planner) buildToolsPrompt(tools []tool.ToolInfo) string {
 	if len(tools) == 0 {
 		return "TOOLS: No tools available."
 	}
@@ -334,16 +576,18 @@ func (p *Planner) buildToolsPrompt() string {
 	for _, tool := range tools {
 		prompt.WriteString(fmt.Sprintf("- %s: %s\n", tool.Name, tool.Description))
 	}
+	if total := len(p.toolRegistry.ListNames()); total > len(tools) {
+		prompt.WriteString(fmt.Sprintf("(%d more tools are available; call search_tools to find them)\n", total-len(tools)))
+	}
 
 	return prompt.String()
 }
 
 // buildLLMTools 构建 LLM 工具定义
-func (p *Planner) buildLLMTools() []llm.Tool {
-	toolsManifest := p.toolRegistry.GetToolsManifest()
+func (p *Planner) buildLLMTools(toolsManifest []tool.ToolInfo) []llm.Tool {
 	llmTools := make([]llm.Tool, 0, len(toolsManifest))
 
-	// 添加所有注册的工具（包括 direct_answer 和 stop）
+	// 添加本步选中的工具（始终包括 direct_answer 和 stop）
 	for _, toolInfo := range toolsManifest {
 		llmTools = append(llmTools, llm.CreateToolFromToolInfo(
 			toolInfo.Name,
@@ -355,27 +599,214 @@ func (p *Planner) buildLLMTools() []llm.Tool {
 	return llmTools
 }
 
-// summarizeOutput 总结输出内容
-func (p *Planner) summarizeOutput(output map[string]any) string {
-	if output == nil {
-		return "No output"
+// refreshTools 读取自上次规划以来的注册表变更
+func (p *Planner) refreshTools() {
+	p.toolChanges = nil
+	version := p.toolRegistry.Version()
+	if !p.toolsSeen {
+		p.toolsSeen = true
+		p.toolsVersion = version
+		return
+	}
+	if version == p.toolsVersion {
+		return
 	}
 
-	// 转换为 JSON 字符串
-	jsonBytes, err := json.Marshal(output)
-	if err != nil {
-		return "Invalid output"
+	p.toolChanges = p.toolRegistry.Changes(p.toolsVersion)
+	added, removed, replaced := summarizeToolChanges(p.toolChanges)
+	logger.Infof("🔄 [TOOLS] Tool list changed (v%d → v%d): %d added, %d removed, %d updated",
+		p.toolsVersion, version, len(added), len(removed), len(replaced))
+	p.toolsVersion = version
+}
+
+// summarizeToolChanges 合并同一工具的多次变更，返回新增、移除和更新的工具名称
+func summarizeToolChanges(events []tool.RegistryEvent) (added, removed, replaced []string) {
+	first := make(map[string]tool.RegistryEventType)
+	last := make(map[string]tool.RegistryEventType)
+	var names []string
+	for _, event := range events {
+		if _, ok := first[event.Tool]; !ok {
+			first[event.Tool] = event.Type
+			names = append(names, event.Tool)
+		}
+		last[event.Tool] = event.Type
 	}
 
-	jsonStr := string(jsonBytes)
+	for _, name := range names {
+		switch {
+		case last[name] == tool.RegistryEventRemoved:
+			if first[name] != tool.RegistryEventAdded {
+				removed = append(removed, name)
+			}
+		case first[name] == tool.RegistryEventAdded:
+			added = append(added, name)
+		default:
+			replaced = append(replaced, name)
+		}
+	}
+	return added, removed, replaced
+}
 
-	// 如果太长，进行截断
-	maxLen := 200
-	if len(jsonStr) > maxLen {
-		return jsonStr[:maxLen] + "..."
+// toolChangesNotice 工具变更的上下文提示
+func toolChangesNotice(events []tool.RegistryEvent) string {
+	added, removed, replaced := summarizeToolChanges(events)
+	if len(added)+len(removed)+len(replaced) == 0 {
+		return ""
 	}
 
-	return jsonStr
+	var b strings.Builder
+	b.WriteString("🔄 TOOLS UPDATED since the last step:\n")
+	if len(added) > 0 {
+		b.WriteString("- New tools available: " + strings.Join(added, ", ") + "\n")
+	}
+	if len(removed) > 0 {
+		b.WriteString("- Tools no longer available (do not call them): " + strings.Join(removed, ", ") + "\n")
+	}
+	if len(replaced) > 0 {
+		b.WriteString("- Tools with updated definitions (re-check their parameters): " + strings.Join(replaced, ", ") + "\n")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// selectionRecentSteps 构建工具检索查询时参考的最近步骤数
+const selectionRecentSteps = 3
+
+// selectTools 挑选本步发送给模型的工具：未设置检索器时为全部工具，
+// 否则按目标和最近步骤检索，并保留执行过的工具
+func (p *Planner) selectTools(ctx context.Context, goal string, trace *state.Trace) []tool.ToolInfo {
+	retriever := p.toolRegistry.Retriever()
+	if retriever == nil {
+		return p.toolRegistry.GetToolsManifest()
+	}
+
+	query := []string{goal}
+	var used []string
+	if trace != nil {
+		for i, step := range trace.Steps {
+			used = append(used, step.Action.Name)
+			if i >= len(trace.Steps)-selectionRecentSteps {
+				query = append(query, step.Action.Name, step.Action.Reason)
+			}
+		}
+	}
+
+	return retriever.Select(ctx, strings.Join(query, "\n"), used)
+}
+
+// artifactToolData 转存为工件的输出：优先使用摘要，其次使用预览，并提示如何读取完整内容
+func artifactToolData(output map[string]any) string {
+	var b strings.Builder
+	if summary, ok := output[tool.OutputKeySummary].(string); ok && summary != "" {
+		b.WriteString("SUMMARY:\n" + summary + "\n\n")
+	}
+	if preview, ok := output[tool.OutputKeyPreview].(string); ok && preview != "" {
+		b.WriteString("PREVIEW:\n" + preview + "\n\n")
+	}
+	if note, ok := output["note"].(string); ok {
+		b.WriteString(note)
+	}
+	return b.String()
+}
+
+// toolData 供直接回答分析的完整工具数据：转存的输出使用摘要和预览，其他输出按工具的 OutputSchema 格式化
+func (p *Planner) toolData(toolName string, obs *state.Observation) string {
+	if _, ok := obs.Output[tool.OutputKeyArtifactID]; ok {
+		return artifactToolData(obs.Output)
+	}
+	var schema map[string]any
+	if toolInfo := p.getToolInfo(toolName); toolInfo != nil {
+		schema = toolInfo.OutputSchema
+	}
+	return tool.FormatOutputData(obs.Output, schema)
+}
+
+// plannerSummaryChars 规划提示中每步输出摘要的最大字符数
+const plannerSummaryChars = 200
+
+// summarizeOutput 总结步骤输出：使用执行器按 OutputSchema 生成的摘要，没有时按工具当前的 Schema 生成
+func (p *Planner) summarizeOutput(toolName string, obs *state.Observation) string {
+	if obs.Summary != "" {
+		return obs.Summary
+	}
+	var schema map[string]any
+	if toolInfo := p.getToolInfo(toolName); toolInfo != nil && len(obs.Artifacts) == 0 {
+		schema = toolInfo.OutputSchema
+	}
+	return tool.SummarizeOutput(obs.Output, schema, plannerSummaryChars)
+}
+
+// maxObservationImages 每轮规划最多转发的图片数量
+const maxObservationImages = 4
+
+// buildImageMessage 将上一步观测附加的图片构建为多模态消息，仅在启用视觉输入时生效
+func (p *Planner) buildImageMessage(trace *state.Trace) (llm.Message, bool) {
+	if !p.vision || len(trace.Steps) == 0 {
+		return llm.Message{}, false
+	}
+
+	lastStep := trace.Steps[len(trace.Steps)-1]
+	if lastStep.Observation == nil || len(lastStep.Observation.Images) == 0 {
+		return llm.Message{}, false
+	}
+
+	var parts []llm.ContentPart
+	for _, ref := range lastStep.Observation.Images {
+		if len(parts) >= maxObservationImages {
+			break
+		}
+		part, err := llm.ImagePart(ref, llm.ImageDetailAuto)
+		if err != nil {
+			logger.Warnf("⚠️  [VISION] Skipping image %s: %v", truncateString(ref, 80), err)
+			continue
+		}
+		parts = append(parts, part)
+	}
+	if len(parts) == 0 {
+		return llm.Message{}, false
+	}
+
+	logger.Infof("🖼️  [VISION] Attaching %d image(s) from step %d (%s)", len(parts), lastStep.Index, lastStep.Action.Name)
+	text := fmt.Sprintf("Images returned by tool '%s' in step %d:", lastStep.Action.Name, lastStep.Index)
+	return llm.CreateMultimodalUserMessage(text, parts...), true
+}
+
+// decisionSchema 决策的 JSON Schema（用于 json_schema 结构化输出）
+func decisionSchema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"type": map[string]any{
+				"type": "string",
+				"enum": []string{
+					string(state.DecisionDirectAnswer),
+					string(state.DecisionUseTool),
+					string(state.DecisionAskClarification),
+					string(state.DecisionStop),
+				},
+			},
+			"content": map[string]any{"type": "string"},
+			"reason":  map[string]any{"type": "string"},
+			"action": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"name": map[string]any{"type": "string"},
+					"args": map[string]any{"type": "object"},
+				},
+			},
+		},
+		"required": []string{"type", "content", "reason"},
+	}
+}
+
+// isKnownDecision 判断决策类型是否有效，避免把普通回答中的 JSON 片段误当作决策
+func isKnownDecision(decisionType state.DecisionType) bool {
+	switch decisionType {
+	case state.DecisionDirectAnswer, state.DecisionUseTool, state.DecisionAskClarification, state.DecisionStop:
+		return true
+	default:
+		return false
+	}
 }
 
 // convertDecisionToAction 将决策转换为动作
@@ -428,13 +859,11 @@ func (p *Planner) convertDecisionToAction(decision state.Decision) state.Action
 
 // getToolInfo 获取工具信息
 func (p *Planner) getToolInfo(toolName string) *tool.ToolInfo {
-	manifest := p.toolRegistry.GetToolsManifest()
-	for _, toolInfo := range manifest {
-		if toolInfo.Name == toolName {
-			return &toolInfo
-		}
+	toolInfo, ok := p.toolRegistry.ToolInfo(toolName)
+	if !ok {
+		return nil
 	}
-	return nil
+	return &toolInfo
 }
 
 // truncateString 截断字符串
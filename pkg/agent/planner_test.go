@@ -0,0 +1,93 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"openmanus-go/pkg/llm"
+	"openmanus-go/pkg/state"
+	"openmanus-go/pkg/tool"
+)
+
+// plannerCassette 录制的规划交互：目标为 "Say hello"，模型调用 echo 工具
+const plannerCassette = "testdata/planner_echo.cassette.json"
+
+// echoTool 测试用工具，原样返回 text 参数
+type echoTool struct {
+	*tool.BaseTool
+}
+
+func newEchoTool() *echoTool {
+	return &echoTool{
+		BaseTool: tool.NewBaseTool("echo", "Echo the given text",
+			tool.CreateJSONSchema("object", map[string]any{
+				"text": tool.StringProperty("Text to echo"),
+			}, []string{"text"}),
+			tool.CreateJSONSchema("object", map[string]any{
+				"text": tool.StringProperty("Echoed text"),
+			}, []string{"text"}),
+		),
+	}
+}
+
+func (t *echoTool) Invoke(ctx context.Context, args map[string]any) (map[string]any, error) {
+	return map[string]any{"text": args["text"]}, nil
+}
+
+// newTestPlanner 创建使用回放客户端的规划器
+func newTestPlanner(t *testing.T) (*Planner, *llm.ReplayClient) {
+	t.Helper()
+	client, err := llm.NewReplayClientFromFile(plannerCassette, llm.MatchFuzzy)
+	if err != nil {
+		t.Fatalf("failed to load cassette: %v", err)
+	}
+	registry := tool.NewRegistry()
+	if err := registry.Register(newEchoTool()); err != nil {
+		t.Fatalf("failed to register echo tool: %v", err)
+	}
+	return NewPlanner(client, registry, NewMemory()), client
+}
+
+// newTestTrace 创建空的执行轨迹
+func newTestTrace(goal string) *state.Trace {
+	now := time.Now()
+	return &state.Trace{
+		Goal:      goal,
+		Budget:    state.Budget{MaxSteps: 5},
+		Status:    state.TraceStatusRunning,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func TestPlannerReplaysToolCall(t *testing.T) {
+	planner, client := newTestPlanner(t)
+
+	action, err := planner.Plan(context.Background(), "Say hello", newTestTrace("Say hello"))
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if action.Name != "echo" {
+		t.Fatalf("action.Name = %q, want echo", action.Name)
+	}
+	if text, _ := action.Args["text"].(string); text != "hello" {
+		t.Errorf("action.Args[text] = %v, want hello", action.Args["text"])
+	}
+	if call := planner.LastLLMCall(); call == nil || call.PromptTokens == 0 {
+		t.Errorf("LastLLMCall() = %+v, want recorded usage", call)
+	}
+	if remaining := client.Remaining(); remaining != 0 {
+		t.Errorf("Remaining() = %d, want 0", remaining)
+	}
+}
+
+func TestPlannerReplayRejectsChangedPrompt(t *testing.T) {
+	planner, _ := newTestPlanner(t)
+
+	_, err := planner.Plan(context.Background(), "Say goodbye", newTestTrace("Say goodbye"))
+	if err == nil || !strings.Contains(err.Error(), "no recorded interaction") {
+		t.Fatalf("Plan() error = %v, want no recorded interaction", err)
+	}
+}
@@ -0,0 +1,105 @@
+package agent
+
+import (
+	"sort"
+	"strings"
+
+	"openmanus-go/pkg/llm"
+	"openmanus-go/pkg/logger"
+)
+
+const (
+	// completionReserveTokens 为模型回复预留的 token 数
+	completionReserveTokens = 4000
+	// minPromptBudget 提示预算下限，避免窗口过小时预算为负
+	minPromptBudget = 512
+	// minSectionTokens 截断后低于该值的段落直接丢弃
+	minSectionTokens = 32
+)
+
+// promptSection 表示提示中的一个段落
+//
+// 段落按添加顺序输出；超出预算时按 Priority 从低到高依次压缩：
+// 先将各段替换为 Compact（如果更短），仍超出时再截断或整体丢弃。Fixed 段落不会被压缩。
+type promptSection struct {
+	Name     string
+	Content  string
+	Compact  string
+	Priority int
+	Fixed    bool
+}
+
+// promptBudget 计算上下文提示可用的 token 预算
+func promptBudget(model string, fixedTokens int) int {
+	budget := llm.ContextWindow(model) - completionReserveTokens - fixedTokens
+	if budget < minPromptBudget {
+		budget = minPromptBudget
+	}
+	return budget
+}
+
+// fitSections 将段落组装为不超过预算的提示文本
+func fitSections(sections []promptSection, budget int) string {
+	tokens := make([]int, len(sections))
+	total := 0
+	for i, section := range sections {
+		tokens[i] = llm.EstimateTokens(section.Content)
+		total += tokens[i]
+	}
+	original := total
+
+	if budget > 0 && total > budget {
+		order := make([]int, 0, len(sections))
+		for i, section := range sections {
+			if !section.Fixed {
+				order = append(order, i)
+			}
+		}
+		sort.SliceStable(order, func(a, b int) bool {
+			return sections[order[a]].Priority < sections[order[b]].Priority
+		})
+
+		// 1. 优先使用更紧凑的替代内容（例如步骤摘要）
+		for _, i := range order {
+			if total <= budget {
+				break
+			}
+			section := &sections[i]
+			if section.Compact == "" {
+				continue
+			}
+			if compactTokens := llm.EstimateTokens(section.Compact); compactTokens < tokens[i] {
+				section.Content = section.Compact
+				total -= tokens[i] - compactTokens
+				tokens[i] = compactTokens
+			}
+		}
+
+		// 2. 截断到剩余预算，过短则整体丢弃
+		for _, i := range order {
+			if total <= budget {
+				break
+			}
+			section := &sections[i]
+			target := tokens[i] - (total - budget)
+			if target < minSectionTokens {
+				section.Content = ""
+				total -= tokens[i]
+				tokens[i] = 0
+				continue
+			}
+			section.Content = llm.TruncateToTokens(section.Content, target)
+			newTokens := llm.EstimateTokens(section.Content)
+			total -= tokens[i] - newTokens
+			tokens[i] = newTokens
+		}
+
+		logger.Infof("✂️  [CONTEXT] Prompt trimmed to fit context window: ~%d -> ~%d tokens (budget %d)", original, total, budget)
+	}
+
+	var prompt strings.Builder
+	for _, section := range sections {
+		prompt.WriteString(section.Content)
+	}
+	return prompt.String()
+}
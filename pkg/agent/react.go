@@ -0,0 +1,249 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"openmanus-go/pkg/llm"
+	"openmanus-go/pkg/tool"
+)
+
+// 工具调用协议
+const (
+	// ToolProtocolAuto 默认使用原生函数调用，模型不支持时自动切换到 ReAct 文本协议
+	ToolProtocolAuto = "auto"
+	// ToolProtocolNative 只使用原生函数调用（tools / tool_calls）
+	ToolProtocolNative = "native"
+	// ToolProtocolReAct 使用 Thought / Action / Action Input 文本协议
+	ToolProtocolReAct = "react"
+)
+
+// reactLabelPattern 匹配 ReAct 协议的字段标签（允许 Markdown 加粗、大小写差异和中英文冒号）
+var reactLabelPattern = regexp.MustCompile(`(?im)^[ \t>*_#-]*(thought|action input|action|final answer|observation)[ \t*_]*[:：][ \t*_]*`)
+
+// unsupportedToolsPattern 匹配提供方明确表示不支持函数调用的错误信息，
+// 如 "model does not support tools"、"function calling is not supported"、
+// "Unrecognized request argument supplied: tools"；泛化的 400 错误（含 tool_call_id 等字样）不匹配
+var unsupportedToolsPattern = regexp.MustCompile(`(?i)(does not|doesn't|do not) support (tools|tool use|tool calls|tool calling|function calls|function calling|functions)\b|\b(tools|tool[_ ]choice|tool calling|function calling|functions)[\x60'"]? (is |are )?(not supported|unsupported)|unrecognized request argument supplied: (tools|tool_choice|functions)|tool choice requires --enable-auto-tool-choice`)
+
+// reactStep 表示从模型文本输出中解析出的一步 ReAct 决策
+type reactStep struct {
+	Thought     string
+	Action      string
+	Input       map[string]any
+	FinalAnswer string
+	HasFinal    bool
+}
+
+// isToolsUnsupportedError 判断错误是否表示模型或提供方不支持函数调用
+func isToolsUnsupportedError(err error) bool {
+	return err != nil && unsupportedToolsPattern.MatchString(err.Error())
+}
+
+// buildReActPrompt 构建 ReAct 文本协议说明和工具清单
+func buildReActPrompt(tools []tool.ToolInfo) string {
+	var prompt strings.Builder
+	prompt.WriteString("AVAILABLE TOOLS:\n")
+	if len(tools) == 0 {
+		prompt.WriteString("(no tools available)\n")
+	}
+	for _, toolInfo := range tools {
+		prompt.WriteString(fmt.Sprintf("- %s: %s\n", toolInfo.Name, toolInfo.Description))
+		if params := compactSchema(toolInfo.InputSchema); params != "" {
+			prompt.WriteString(fmt.Sprintf("  Arguments: %s\n", params))
+		}
+	}
+
+	prompt.WriteString(`
+RESPONSE FORMAT:
+To use a tool, respond with exactly these lines and nothing after them:
+Thought: <your reasoning about what to do next>
+Action: <one tool name from the list above>
+Action Input: <a single-line JSON object with the tool arguments>
+
+When you can answer the goal, respond with:
+Thought: <your reasoning>
+Final Answer: <the complete answer for the user>
+
+Do not write an Observation line; the tool result will be provided in the next turn.`)
+
+	return prompt.String()
+}
+
+// compactSchema 将输入 Schema 压缩为 "name (type, required): description" 形式的单行说明
+func compactSchema(schema map[string]any) string {
+	properties, _ := schema["properties"].(map[string]any)
+	if len(properties) == 0 {
+		return ""
+	}
+
+	required := make(map[string]bool)
+	switch values := schema["required"].(type) {
+	case []string:
+		for _, name := range values {
+			required[name] = true
+		}
+	case []any:
+		for _, name := range values {
+			if s, ok := name.(string); ok {
+				required[s] = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		prop, _ := properties[name].(map[string]any)
+		typ, _ := prop["type"].(string)
+		if typ == "" {
+			typ = "any"
+		}
+		if required[name] {
+			typ += ", required"
+		}
+		part := fmt.Sprintf("%s (%s)", name, typ)
+		if desc, _ := prop["description"].(string); desc != "" {
+			part += ": " + desc
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// parseReAct 解析 ReAct 文本输出
+//
+// 只取第一个 Action；模型自行编造的 Observation 及其后内容会被忽略。
+// Action Input 允许使用代码块、包含前后缀文本或尾逗号；无法解析为 JSON 时保留原文。
+func parseReAct(content string) (*reactStep, bool) {
+	matches := reactLabelPattern.FindAllStringSubmatchIndex(content, -1)
+	if len(matches) == 0 {
+		return nil, false
+	}
+
+	step := &reactStep{}
+	var rawInput string
+	hasInput := false
+
+	for i, match := range matches {
+		label := strings.ToLower(content[match[2]:match[3]])
+		end := len(content)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		value := strings.TrimSpace(content[match[1]:end])
+
+		switch label {
+		case "thought":
+			if step.Thought == "" {
+				step.Thought = value
+			}
+		case "action":
+			if step.Action != "" {
+				// 只执行第一个动作
+				return finishReAct(step, rawInput, hasInput)
+			}
+			step.Action = cleanActionName(value)
+		case "action input":
+			if step.Action != "" && !hasInput {
+				rawInput = value
+				hasInput = true
+			}
+		case "final answer":
+			if step.Action == "" {
+				step.FinalAnswer = value
+				step.HasFinal = true
+				return step, true
+			}
+			return finishReAct(step, rawInput, hasInput)
+		case "observation":
+			// 模型编造的观测结果：停止解析
+			return finishReAct(step, rawInput, hasInput)
+		}
+	}
+
+	return finishReAct(step, rawInput, hasInput)
+}
+
+// finishReAct 完成动作输入的解析
+func finishReAct(step *reactStep, rawInput string, hasInput bool) (*reactStep, bool) {
+	if step.Action == "" {
+		return nil, false
+	}
+
+	step.Input = map[string]any{}
+	if hasInput && rawInput != "" {
+		if err := llm.ParseJSONObject(rawInput, &step.Input); err != nil {
+			// 非 JSON 输入：保留原文，由调用方根据工具 Schema 映射
+			step.Input = map[string]any{reactRawInputKey: strings.Trim(rawInput, "`\"' \n")}
+		}
+	}
+	return step, true
+}
+
+// reactRawInputKey 非 JSON 的 Action Input 在参数中的临时键
+const reactRawInputKey = "_raw_input"
+
+// cleanActionName 清理动作名称中的修饰（代码标记、引号、括号、多余文本）
+func cleanActionName(value string) string {
+	if idx := strings.IndexAny(value, "\r\n"); idx >= 0 {
+		value = value[:idx]
+	}
+	value = strings.Trim(strings.TrimSpace(value), "`\"'*[] ")
+	if idx := strings.IndexAny(value, "( \t"); idx >= 0 {
+		value = value[:idx]
+	}
+	return value
+}
+
+// resolveReActInput 将非 JSON 的原始输入映射到工具唯一的必填参数（或唯一参数）
+func resolveReActInput(args map[string]any, toolInfo *tool.ToolInfo) map[string]any {
+	raw, ok := args[reactRawInputKey]
+	if !ok {
+		return args
+	}
+	if toolInfo == nil {
+		return map[string]any{"input": raw}
+	}
+
+	var candidates []string
+	switch values := toolInfo.InputSchema["required"].(type) {
+	case []string:
+		candidates = append(candidates, values...)
+	case []any:
+		for _, v := range values {
+			if s, ok := v.(string); ok {
+				candidates = append(candidates, s)
+			}
+		}
+	}
+	if len(candidates) != 1 {
+		if properties, ok := toolInfo.InputSchema["properties"].(map[string]any); ok && len(properties) == 1 {
+			candidates = nil
+			for name := range properties {
+				candidates = append(candidates, name)
+			}
+		}
+	}
+	if len(candidates) == 1 {
+		return map[string]any{candidates[0]: raw}
+	}
+	return map[string]any{"input": raw}
+}
+
+// formatReActInput 将参数格式化为单行 JSON，用于日志
+func formatReActInput(args map[string]any) string {
+	data, err := json.Marshal(args)
+	if err != nil {
+		return fmt.Sprintf("%v", args)
+	}
+	return string(data)
+}
@@ -2,19 +2,27 @@ package agent
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"openmanus-go/pkg/llm"
 	"openmanus-go/pkg/logger"
+	"openmanus-go/pkg/prompts"
 	"openmanus-go/pkg/state"
 )
 
 // Reflector 反思器
 type Reflector struct {
-	llmClient llm.Client
-	memory    *Memory // 添加内存引用
+	llmClient        llm.Client
+	memory           *Memory // 添加内存引用
+	structuredOutput string  // 结构化输出模式
+
+	pricing  llm.PricingTable // 模型价格表，用于计算调用费用
+	lastCall *state.LLMCall   // 最近一次反思请求的调用信息
+
+	prompts *prompts.Loader // 系统提示模板
+	locale  string          // 回答语言
 }
 
 // NewReflector 创建反思器
@@ -22,33 +30,40 @@ func NewReflector(llmClient llm.Client, memory *Memory) *Reflector {
 	return &Reflector{
 		llmClient: llmClient,
 		memory:    memory,
+		prompts:   prompts.DefaultLoader,
 	}
 }
 
 // Reflect 进行反思分析
 func (r *Reflector) Reflect(ctx context.Context, trace *state.Trace) (*state.ReflectionResult, error) {
-	// 构建反思提示
-	prompt := r.buildReflectionPrompt(trace)
+	// 构建反思提示（扣除系统提示后按模型窗口分配预算）
+	systemPrompt := r.getSystemPrompt(trace.Goal)
+	fixedTokens := llm.EstimateMessagesTokens([]llm.Message{llm.CreateSystemMessage(systemPrompt)})
+	prompt := r.buildReflectionPrompt(trace, promptBudget(r.llmClient.GetModel(), fixedTokens))
 	logger.Debugw("agent.reflect.request", "steps", len(trace.Steps), "status", trace.Status)
 
 	// 准备消息
 	messages := []llm.Message{
-		llm.CreateSystemMessage(r.getSystemPrompt()),
+		llm.CreateSystemMessage(systemPrompt),
 		llm.CreateUserMessage(prompt),
 	}
 
 	// 创建请求
 	req := &llm.ChatRequest{
-		Messages:    messages,
-		Temperature: 0.1, // 低温度确保一致性
+		Messages:       messages,
+		Temperature:    0.1, // 低温度确保一致性
+		ResponseFormat: llm.NewResponseFormat(r.structuredOutput, "reflection_result", reflectionResultSchema()),
 	}
 
 	// 发送请求
+	r.lastCall = nil
+	start := time.Now()
 	resp, err := r.llmClient.Chat(ctx, req)
 	if err != nil {
 		logger.Errorw("agent.reflect.llm_error", "error", err)
 		return nil, fmt.Errorf("reflection LLM request failed: %w", err)
 	}
+	r.lastCall = newLLMCall(req, resp, time.Since(start), r.pricing)
 
 	if len(resp.Choices) == 0 {
 		return nil, fmt.Errorf("no response choices from reflection")
@@ -58,7 +73,7 @@ func (r *Reflector) Reflect(ctx context.Context, trace *state.Trace) (*state.Ref
 	content := resp.Choices[0].Message.Content
 	var result state.ReflectionResult
 
-	if err := json.Unmarshal([]byte(content), &result); err != nil {
+	if err := llm.ParseJSONObject(content, &result); err != nil {
 		// 如果解析失败，创建默认结果
 		logger.Warnw("agent.reflect.parse_failed", "content_preview", preview(content, 200))
 		return &state.ReflectionResult{
@@ -75,39 +90,51 @@ func (r *Reflector) Reflect(ctx context.Context, trace *state.Trace) (*state.Ref
 	return &result, nil
 }
 
-// getSystemPrompt 获取系统提示
-func (r *Reflector) getSystemPrompt() string {
-	return `You are a reflection module for an AI agent. Your job is to analyze the agent's execution trace and provide insights about progress, potential issues, and next steps.
-
-Analyze the given execution trace and respond with a JSON object containing:
-{
-  "revise_plan": boolean,     // Whether the current plan should be revised
-  "next_action_hint": string, // Suggestion for the next action
-  "should_stop": boolean,     // Whether execution should stop
-  "reason": string,          // Explanation for the recommendation
-  "confidence": number       // Confidence level (0.0 to 1.0)
+// LastLLMCall 返回最近一次反思请求的调用信息
+func (r *Reflector) LastLLMCall() *state.LLMCall {
+	return r.lastCall
 }
 
-Consider:
-1. Are we making progress toward the goal?
-2. Are there repeated failures or loops?
-3. Is there missing information or context?
-4. Are we using the right tools and approach?
-5. Should we try a different strategy?
+// reflectionResultSchema 反思结果的 JSON Schema（用于 json_schema 结构化输出）
+func reflectionResultSchema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"revise_plan":      map[string]any{"type": "boolean"},
+			"next_action_hint": map[string]any{"type": "string"},
+			"should_stop":      map[string]any{"type": "boolean"},
+			"reason":           map[string]any{"type": "string"},
+			"confidence":       map[string]any{"type": "number", "minimum": 0, "maximum": 1},
+		},
+		"required":             []string{"revise_plan", "next_action_hint", "should_stop", "reason", "confidence"},
+		"additionalProperties": false,
+	}
+}
 
-Be concise but thorough in your analysis.`
+// getSystemPrompt 获取系统提示，内容来自 reflector_system 模板
+func (r *Reflector) getSystemPrompt(goal string) string {
+	data := prompts.Data{
+		Goal:   goal,
+		Locale: r.locale,
+	}
+	if r.memory != nil {
+		data.Memory = r.memory.GetSummary()
+	}
+	return renderSystemPrompt(r.prompts, prompts.ReflectorSystem, data)
 }
 
 // buildReflectionPrompt 构建反思提示（增强版，使用 Memory 分析）
-func (r *Reflector) buildReflectionPrompt(trace *state.Trace) string {
-	var prompt strings.Builder
+//
+// 总长度超过 budget（token）时优先压缩低优先级段落。
+func (r *Reflector) buildReflectionPrompt(trace *state.Trace, budget int) string {
+	var summary strings.Builder
 
-	prompt.WriteString(fmt.Sprintf("GOAL: %s\n\n", trace.Goal))
+	summary.WriteString(fmt.Sprintf("GOAL: %s\n\n", trace.Goal))
 
 	// 添加执行统计
-	prompt.WriteString("EXECUTION SUMMARY:\n")
-	prompt.WriteString(fmt.Sprintf("- Total steps: %d/%d\n", len(trace.Steps), trace.Budget.MaxSteps))
-	prompt.WriteString(fmt.Sprintf("- Status: %s\n", trace.Status))
+	summary.WriteString("EXECUTION SUMMARY:\n")
+	summary.WriteString(fmt.Sprintf("- Total steps: %d/%d\n", len(trace.Steps), trace.Budget.MaxSteps))
+	summary.WriteString(fmt.Sprintf("- Status: %s\n", trace.Status))
 
 	// 使用 Memory 获取更精确的分析
 	var successCount, failureCount int
@@ -125,15 +152,18 @@ func (r *Reflector) buildReflectionPrompt(trace *state.Trace) string {
 	totalSteps := len(trace.Steps)
 	if totalSteps > 0 {
 		successRate := float64(successCount) / float64(totalSteps) * 100
-		prompt.WriteString(fmt.Sprintf("- Success rate: %.1f%% (%d/%d successful, %d failed)\n", successRate, successCount, totalSteps, failureCount))
+		summary.WriteString(fmt.Sprintf("- Success rate: %.1f%% (%d/%d successful, %d failed)\n", successRate, successCount, totalSteps, failureCount))
 	}
 
+	sections := []promptSection{{Name: "summary", Content: summary.String(), Priority: 100, Fixed: true}}
+
 	// 添加 Memory 提供的智能分析
 	if r.memory != nil {
+		var history strings.Builder
 		memorySummary := r.memory.GetSummary()
 		if metrics, ok := memorySummary["metrics"].(map[string]any); ok {
 			if updatedAt, ok := metrics["updated_at"]; ok {
-				prompt.WriteString(fmt.Sprintf("- Last metrics update: %v\n", updatedAt))
+				history.WriteString(fmt.Sprintf("- Last metrics update: %v\n", updatedAt))
 			}
 		}
 
@@ -141,59 +171,79 @@ func (r *Reflector) buildReflectionPrompt(trace *state.Trace) string {
 		if trace.Scratch != nil {
 			if compressedHistory, ok := trace.Scratch["compressed_history"].(map[string]any); ok {
 				if keyOutcomes, ok := compressedHistory["key_outcomes"].([]string); ok && len(keyOutcomes) > 0 {
-					prompt.WriteString("- Key outcomes from compressed history:\n")
+					history.WriteString("- Key outcomes from compressed history:\n")
 					for _, outcome := range keyOutcomes {
-						prompt.WriteString(fmt.Sprintf("  • %s\n", outcome))
+						history.WriteString(fmt.Sprintf("  • %s\n", outcome))
 					}
 				}
 			}
 		}
+		sections = append(sections, promptSection{Name: "history", Content: history.String(), Priority: 20})
 	}
 
-	prompt.WriteString("\n")
+	sections = append(sections, promptSection{Name: "separator", Content: "\n", Fixed: true})
 
 	// 添加最近的步骤
 	recentSteps := r.getRecentSteps(trace.Steps, 5)
 	if len(recentSteps) > 0 {
-		prompt.WriteString("RECENT STEPS:\n")
+		var steps, compact strings.Builder
+		steps.WriteString("RECENT STEPS:\n")
+		compact.WriteString("RECENT STEPS:\n")
 		for i, step := range recentSteps {
-			prompt.WriteString(fmt.Sprintf("%d. Action: %s", len(trace.Steps)-len(recentSteps)+i+1, step.Action.Name))
+			stepNum := len(trace.Steps) - len(recentSteps) + i + 1
+			steps.WriteString(fmt.Sprintf("%d. Action: %s", stepNum, step.Action.Name))
 
 			if step.Action.Reason != "" {
-				prompt.WriteString(fmt.Sprintf(" (Reason: %s)", step.Action.Reason))
+				steps.WriteString(fmt.Sprintf(" (Reason: %s)", step.Action.Reason))
+			}
+			steps.WriteString("\n")
+
+			if step.Summary != "" {
+				compact.WriteString(fmt.Sprintf("%d. %s\n", stepNum, step.Summary))
+			} else {
+				compact.WriteString(fmt.Sprintf("%d. Action: %s\n", stepNum, step.Action.Name))
 			}
-			prompt.WriteString("\n")
 
 			if step.Observation != nil {
 				if step.Observation.ErrMsg != "" {
-					prompt.WriteString(fmt.Sprintf("   Result: FAILED - %s\n", step.Observation.ErrMsg))
+					steps.WriteString(fmt.Sprintf("   Result: FAILED - %s\n", step.Observation.ErrMsg))
 				} else {
-					summary := r.summarizeObservation(step.Observation)
-					prompt.WriteString(fmt.Sprintf("   Result: SUCCESS - %s\n", summary))
+					summary := summarizeObservation(step.Observation, observationSummaryChars)
+					steps.WriteString(fmt.Sprintf("   Result: SUCCESS - %s\n", summary))
 				}
 			}
 		}
-		prompt.WriteString("\n")
+		steps.WriteString("\n")
+		compact.WriteString("\n")
+		sections = append(sections, promptSection{
+			Name:     "recent_steps",
+			Content:  steps.String(),
+			Compact:  compact.String(),
+			Priority: 50,
+		})
 	}
 
 	// 分析模式和问题
 	patterns := r.analyzePatterns(trace.Steps)
 	if len(patterns) > 0 {
-		prompt.WriteString("PATTERNS DETECTED:\n")
+		var detected strings.Builder
+		detected.WriteString("PATTERNS DETECTED:\n")
 		for _, pattern := range patterns {
-			prompt.WriteString(fmt.Sprintf("- %s\n", pattern))
+			detected.WriteString(fmt.Sprintf("- %s\n", pattern))
 		}
-		prompt.WriteString("\n")
+		detected.WriteString("\n")
+		sections = append(sections, promptSection{Name: "patterns", Content: detected.String(), Priority: 60})
 	}
 
 	// 添加预算状态
+	var closing strings.Builder
 	if trace.IsExceededBudget() {
-		prompt.WriteString("WARNING: Budget limits have been exceeded!\n\n")
+		closing.WriteString("WARNING: Budget limits have been exceeded!\n\n")
 	}
+	closing.WriteString("Please analyze this execution trace and provide your reflection.")
+	sections = append(sections, promptSection{Name: "closing", Content: closing.String(), Priority: 100, Fixed: true})
 
-	prompt.WriteString("Please analyze this execution trace and provide your reflection.")
-
-	return prompt.String()
+	return fitSections(sections, budget)
 }
 
 func preview(s string, max int) string {
@@ -225,35 +275,6 @@ func (r *Reflector) getRecentSteps(steps []state.Step, count int) []state.Step {
 	return steps[len(steps)-count:]
 }
 
-// summarizeObservation 总结观测结果
-func (r *Reflector) summarizeObservation(obs *state.Observation) string {
-	if obs.Output == nil {
-		return "No output"
-	}
-
-	// 尝试提取关键信息
-	if result, ok := obs.Output["result"].(string); ok {
-		if len(result) > 100 {
-			return result[:100] + "..."
-		}
-		return result
-	}
-
-	if success, ok := obs.Output["success"].(bool); ok {
-		if success {
-			return "Operation completed successfully"
-		} else {
-			if errMsg, ok := obs.Output["error"].(string); ok {
-				return fmt.Sprintf("Operation failed: %s", errMsg)
-			}
-			return "Operation failed"
-		}
-	}
-
-	// 默认总结
-	return "Operation completed"
-}
-
 // analyzePatterns 分析执行模式
 func (r *Reflector) analyzePatterns(steps []state.Step) []string {
 	var patterns []string
@@ -0,0 +1,42 @@
+package agent
+
+import (
+	"sync"
+
+	"openmanus-go/pkg/llm"
+	"openmanus-go/pkg/state"
+)
+
+// llmUsageCollector 收集规划和工具执行期间的附带 LLM 调用（工件摘要、工具检索向量化等），
+// 由循环在同步点计入轨迹；记录可能来自其他 goroutine
+type llmUsageCollector struct {
+	pricing llm.PricingTable
+
+	mu    sync.Mutex
+	calls []*state.LLMCall
+}
+
+// record 实现 state.LLMCallRecorder，调用方未计算费用时按价格表计算
+func (c *llmUsageCollector) record(call *state.LLMCall) {
+	if call.Cost == 0 && !call.CacheHit {
+		if cost, ok := c.pricing.Cost(call.Model, call.PromptTokens, call.CompletionTokens); ok {
+			call.Cost = cost
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls = append(c.calls, call)
+}
+
+// flush 将收集到的调用计入轨迹的用量和费用
+func (c *llmUsageCollector) flush(trace *state.Trace) {
+	c.mu.Lock()
+	calls := c.calls
+	c.calls = nil
+	c.mu.Unlock()
+
+	for _, call := range calls {
+		trace.RecordLLMUsage(call)
+	}
+}
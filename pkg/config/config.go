@@ -29,6 +29,49 @@ type LLMConfig struct {
 	Temperature float64 `mapstructure:"temperature"`
 	MaxTokens   int     `mapstructure:"max_tokens"`
 	Timeout     int     `mapstructure:"timeout"`
+
+	// ContextWindow 覆盖模型上下文窗口（token），0 表示按模型名自动识别
+	ContextWindow int `mapstructure:"context_window"`
+
+	// StructuredOutput 结构化输出模式：none | json_object | json_schema
+	StructuredOutput string `mapstructure:"structured_output"`
+
+	// Vision 模型是否支持图片输入（多模态消息）
+	Vision bool `mapstructure:"vision"`
+
+	// ToolProtocol 工具调用协议：auto | native | react（不支持函数调用的模型使用 react）
+	ToolProtocol string `mapstructure:"tool_protocol"`
+
+	Cache     LLMCacheConfig     `mapstructure:"cache"`
+	Embedding LLMEmbeddingConfig `mapstructure:"embedding"`
+
+	// Pricing 模型价格表，用于计算每次调用的费用
+	Pricing []ModelPricingConfig `mapstructure:"pricing"`
+}
+
+// ModelPricingConfig 单个模型的价格（每 1K token）
+type ModelPricingConfig struct {
+	Model  string  `mapstructure:"model"`  // 模型名或模型名前缀
+	Input  float64 `mapstructure:"input"`  // 输入（prompt）单价
+	Output float64 `mapstructure:"output"` // 输出（completion）单价
+}
+
+// LLMCacheConfig LLM 响应磁盘缓存配置
+type LLMCacheConfig struct {
+	Enabled   bool   `mapstructure:"enabled"`
+	Dir       string `mapstructure:"dir"`         // 缓存目录
+	TTL       string `mapstructure:"ttl"`         // 缓存有效期，如 "24h"，空表示永不过期
+	MaxSizeMB int    `mapstructure:"max_size_mb"` // 缓存目录大小上限（MB），0 表示不限制
+}
+
+// LLMEmbeddingConfig 向量化（/embeddings）配置
+type LLMEmbeddingConfig struct {
+	Provider   string `mapstructure:"provider"` // openai | hash
+	Model      string `mapstructure:"model"`
+	BaseURL    string `mapstructure:"base_url"` // 空表示使用 llm.base_url
+	APIKey     string `mapstructure:"api_key"`  // 空表示使用 llm.api_key
+	Dimensions int    `mapstructure:"dimensions"`
+	BatchSize  int    `mapstructure:"batch_size"`
 }
 
 // AgentConfig Agent 配置
@@ -40,6 +83,18 @@ type AgentConfig struct {
 	MaxRetries      int    `mapstructure:"max_retries"`
 	RetryBackoff    string `mapstructure:"retry_backoff"`
 	MemoryPath      string `mapstructure:"memory_path"`
+
+	// MaxCost 单次运行的费用上限，0 表示不限制
+	MaxCost float64 `mapstructure:"max_cost"`
+
+	// PromptsDir 自定义提示模板目录（planner_system.tmpl 等），空表示使用内置模板
+	PromptsDir string `mapstructure:"prompts_dir"`
+	// Locale 回答使用的语言，如 "zh-CN"，空表示不限制
+	Locale string `mapstructure:"locale"`
+	// MaxArgRepairs 工具参数无效时请求模型修正的最大次数，0 表示不重试
+	MaxArgRepairs int `mapstructure:"max_arg_repairs"`
+	// PartialCheckInterval 流式工具（如 crawler）运行期间检查部分结果是否已足以回答目标的间隔，"0" 表示不检查
+	PartialCheckInterval string `mapstructure:"partial_check_interval"`
 }
 
 // RunFlowConfig 流程配置
@@ -84,6 +139,8 @@ type ToolsConfig struct {
 		BlockedPaths []string `mapstructure:"blocked_paths"`
 	} `mapstructure:"filesystem"`
 
+	Shell ToolShellConfig `mapstructure:"shell"`
+
 	Browser struct {
 		Headless  bool   `mapstructure:"headless"`
 		Timeout   int    `mapstructure:"timeout"`
@@ -107,6 +164,161 @@ type ToolsConfig struct {
 			Password  string   `mapstructure:"password"`
 		} `mapstructure:"elasticsearch"`
 	} `mapstructure:"database"`
+
+	Middleware ToolMiddlewareConfig `mapstructure:"middleware"`
+	Cache      ToolCacheConfig      `mapstructure:"cache"`
+	Policy     ToolPolicyConfig     `mapstructure:"policy"`
+	Limits     ToolLimitsConfig     `mapstructure:"limits"`
+	Artifacts  ToolArtifactsConfig  `mapstructure:"artifacts"`
+	Selection  ToolSelectionConfig  `mapstructure:"selection"`
+	Output     ToolOutputConfig     `mapstructure:"output"`
+	Plugins    []PluginConfig       `mapstructure:"plugins"`
+	OpenAPI    []OpenAPIConfig      `mapstructure:"openapi"`
+}
+
+// OpenAPIConfig 从 OpenAPI 3 规范生成工具的配置，规范中的每个操作生成一个工具
+type OpenAPIConfig struct {
+	Name       string            `mapstructure:"name"`       // 服务名称，作为工具名前缀，也用于 tools.limits.servers
+	Spec       string            `mapstructure:"spec"`       // 规范文件路径（JSON 或 YAML）
+	BaseURL    string            `mapstructure:"base_url"`   // 覆盖规范中的 servers
+	Operations []string          `mapstructure:"operations"` // 只加载这些 operationId，空表示全部
+	Headers    map[string]string `mapstructure:"headers"`    // 附加请求头，值支持 ${ENV} 展开
+	Timeout    string            `mapstructure:"timeout"`    // 请求超时，默认 30s
+	Auth       OpenAPIAuthConfig `mapstructure:"auth"`
+}
+
+// OpenAPIAuthConfig OpenAPI 服务认证配置，凭据支持 ${ENV} 展开
+type OpenAPIAuthConfig struct {
+	Type     string `mapstructure:"type"`     // bearer | basic | header | query，空表示无认证
+	Token    string `mapstructure:"token"`    // bearer
+	Username string `mapstructure:"username"` // basic
+	Password string `mapstructure:"password"` // basic
+	Name     string `mapstructure:"name"`     // header / query 的参数名
+	Value    string `mapstructure:"value"`    // header / query 的参数值
+}
+
+// PluginConfig 外部可执行插件配置，插件通过 stdin/stdout 上的 JSON 行协议提供工具
+type PluginConfig struct {
+	Name         string   `mapstructure:"name"`          // 插件名称，用于日志和 tools.limits.servers
+	Command      string   `mapstructure:"command"`       // 可执行文件
+	Args         []string `mapstructure:"args"`          // 命令行参数
+	Env          []string `mapstructure:"env"`           // 额外环境变量：NAME 从当前进程透传，NAME=VALUE 直接设置（默认只传递 PATH、HOME 等基础变量）
+	Dir          string   `mapstructure:"dir"`           // 工作目录，默认当前目录
+	Timeout      string   `mapstructure:"timeout"`       // 单次调用超时，默认 30s，超时的插件会被重启
+	StartTimeout string   `mapstructure:"start_timeout"` // 启动并响应 describe 的超时，默认 10s
+	MaxRestarts  int      `mapstructure:"max_restarts"`  // 连续重启上限，0 使用默认值 3，负数表示不重启
+}
+
+// ToolSelectionConfig 工具选择配置：工具很多时每步只向模型发送与目标和最近步骤相关的工具，
+// 模型可通过 search_tools 检索并加载其余工具
+type ToolSelectionConfig struct {
+	Enabled       bool     `mapstructure:"enabled"`
+	TopK          int      `mapstructure:"top_k"`          // 每步按相关性选出的工具数（不含必选工具）
+	MinTools      int      `mapstructure:"min_tools"`      // 工具总数不超过该值时发送全部工具
+	AlwaysInclude []string `mapstructure:"always_include"` // 始终发送的工具（direct_answer、stop、search_tools 默认包含）
+	Embeddings    bool     `mapstructure:"embeddings"`     // 是否结合向量相似度（使用 llm.embedding 配置）
+}
+
+// ToolShellConfig shell 工具配置：命令在工作区内运行，按允许/禁止列表检查命令名称和路径参数，
+// 并限制运行时间、CPU、内存和输出大小，环境变量只传递白名单中的变量。
+// 默认关闭；允许解释器（python3、node、go 等）后命令可执行任意代码，不再受工作区和 tools.fs.blocked_paths 限制
+type ToolShellConfig struct {
+	Enabled         bool     `mapstructure:"enabled"`
+	Workspace       string   `mapstructure:"workspace"`        // 工作区目录，命令的 cwd 和路径参数只能位于其中
+	AllowedCommands []string `mapstructure:"allowed_commands"` // 允许的命令名称，空表示除 denied_commands 外全部允许
+	DeniedCommands  []string `mapstructure:"denied_commands"`  // 禁止的命令名称，优先于 allowed_commands
+	Timeout         string   `mapstructure:"timeout"`          // 单条命令的最长运行时间，默认 30s
+	MaxOutputBytes  int      `mapstructure:"max_output_bytes"` // stdout、stderr 各自保留的最大字节数
+	CPUSeconds      int      `mapstructure:"cpu_seconds"`      // CPU 时间上限，0 表示不限制（Windows 不支持）
+	MemoryMB        int      `mapstructure:"memory_mb"`        // 虚拟内存上限，0 表示不限制（Windows 不支持）
+	Env             []string `mapstructure:"env"`              // 额外传递的环境变量：NAME 从当前进程透传，NAME=VALUE 直接设置
+}
+
+// ToolOutputConfig 工具输出配置
+type ToolOutputConfig struct {
+	// Validation 按工具 OutputSchema 校验输出：off 不校验，warn 只记录警告，strict 将不符合的输出作为错误
+	Validation string `mapstructure:"validation"`
+}
+
+// ToolArtifactsConfig 大输出工件配置：超过阈值的工具输出保存到运行目录，观测结果只保留引用和预览
+type ToolArtifactsConfig struct {
+	Enabled        bool   `mapstructure:"enabled"`
+	Dir            string `mapstructure:"dir"`             // 工件根目录，每次运行一个子目录
+	ThresholdBytes int    `mapstructure:"threshold_bytes"` // 输出 JSON 超过该字节数时转存
+	PreviewChars   int    `mapstructure:"preview_chars"`   // 观测结果中保留的预览字符数
+	Summarize      bool   `mapstructure:"summarize"`       // 是否调用 LLM 为转存的输出生成摘要
+}
+
+// ToolLimitsConfig 工具并发和频率限制配置
+type ToolLimitsConfig struct {
+	// Default 未在 Tools 中单独配置的工具使用的限制（每个工具独立计数），零值表示不限制
+	Default ToolLimitConfig `mapstructure:"default"`
+	// Tools 工具级限制，键为工具名称
+	Tools map[string]ToolLimitConfig `mapstructure:"tools"`
+	// Servers MCP 服务器级限制（该服务器所有工具共享），键为服务器名称
+	Servers map[string]ToolLimitConfig `mapstructure:"servers"`
+}
+
+// ToolLimitConfig 单个工具或服务器的限制
+type ToolLimitConfig struct {
+	MaxConcurrent int     `mapstructure:"max_concurrent"` // 最大并发调用数，0 表示不限制
+	Rate          float64 `mapstructure:"rate"`           // 每秒调用次数（令牌桶），0 表示不限制
+	Burst         int     `mapstructure:"burst"`          // 令牌桶容量，默认 1
+}
+
+// ToolPolicyConfig 工具权限策略配置
+type ToolPolicyConfig struct {
+	// Default 未被 allow / deny 命中的工具的默认策略：allow | deny
+	Default string `mapstructure:"default"`
+	// Allow 允许的工具名称（支持 * 通配），Default 为 deny 时使用
+	Allow []string `mapstructure:"allow"`
+	// Deny 禁止的工具名称（支持 * 通配），优先于 Allow
+	Deny []string `mapstructure:"deny"`
+	// Tools 工具级规则，键为工具名称
+	Tools map[string]ToolRuleConfig `mapstructure:"tools"`
+}
+
+// ToolRuleConfig 单个工具的操作和参数规则
+type ToolRuleConfig struct {
+	// OperationArg 表示操作类型的参数名，默认 "operation"
+	OperationArg string `mapstructure:"operation_arg"`
+	// Operations 允许的操作，空表示不限制
+	Operations []string `mapstructure:"operations"`
+	// DenyOperations 禁止的操作
+	DenyOperations []string `mapstructure:"deny_operations"`
+	// Args 参数规则
+	Args []ArgRuleConfig `mapstructure:"args"`
+}
+
+// ArgRuleConfig 参数规则：参数值命中 deny 或未命中任何 allow 时拒绝调用
+type ArgRuleConfig struct {
+	Arg   string   `mapstructure:"arg"`   // 参数名，嵌套参数使用点号，如 "options.url"
+	Match string   `mapstructure:"match"` // regex（默认）| glob | sql_verb
+	Allow []string `mapstructure:"allow"`
+	Deny  []string `mapstructure:"deny"`
+}
+
+// ToolCacheConfig 工具结果缓存配置，只缓存工具声明为幂等的操作（如 HTTP GET、SELECT 查询）
+type ToolCacheConfig struct {
+	Enabled    bool   `mapstructure:"enabled"`
+	Backend    string `mapstructure:"backend"`     // memory | disk（disk 可跨运行复用）
+	Dir        string `mapstructure:"dir"`         // disk 后端的缓存目录
+	TTL        string `mapstructure:"ttl"`         // 默认有效期，工具可按操作声明更短的 TTL
+	MaxEntries int    `mapstructure:"max_entries"` // memory 后端最大条目数，0 表示不限制
+}
+
+// ToolMiddlewareConfig 工具中间件配置
+type ToolMiddlewareConfig struct {
+	// RedactKeys 日志中额外隐藏的参数名（password、token 等常见敏感字段默认隐藏）
+	RedactKeys []string `mapstructure:"redact_keys"`
+	// Overrides 工具级缓存（叠加在注册表级中间件之后），键为工具名称；
+	// cache_ttl 对该工具的所有调用开启缓存，不论工具是否声明为可缓存
+	Overrides map[string]ToolMiddlewareOverride `mapstructure:"overrides"`
+}
+
+// ToolMiddlewareOverride 单个工具的中间件配置
+type ToolMiddlewareOverride struct {
+	CacheTTL string `mapstructure:"cache_ttl"`
 }
 
 // LoggingConfig 日志配置
@@ -136,6 +348,20 @@ func DefaultConfig() *Config {
 			Temperature: 0.1,
 			MaxTokens:   4000,
 			Timeout:     30,
+
+			StructuredOutput: "none",
+			ToolProtocol:     "auto",
+			Cache: LLMCacheConfig{
+				Enabled:   false,
+				Dir:       "./data/llm_cache",
+				TTL:       "24h",
+				MaxSizeMB: 100,
+			},
+			Embedding: LLMEmbeddingConfig{
+				Provider:  "openai",
+				Model:     "text-embedding-3-small",
+				BatchSize: 64,
+			},
 		},
 		Agent: AgentConfig{
 			MaxSteps:        10,
@@ -145,6 +371,9 @@ func DefaultConfig() *Config {
 			MaxRetries:      2,
 			RetryBackoff:    "1s",
 			MemoryPath:      "./data/memory/long_term.json",
+			MaxArgRepairs:   2,
+
+			PartialCheckInterval: "20s",
 		},
 		RunFlow: RunFlowConfig{
 			UseDataAnalysisAgent: false,
@@ -184,6 +413,47 @@ func DefaultConfig() *Config {
 				Timeout:   30,
 				UserAgent: "OpenManus-Go/1.0",
 			},
+			Policy: ToolPolicyConfig{
+				Default: "allow",
+			},
+			Cache: ToolCacheConfig{
+				Enabled:    false,
+				Backend:    "memory",
+				Dir:        "./data/tool_cache",
+				TTL:        "10m",
+				MaxEntries: 1000,
+			},
+			Artifacts: ToolArtifactsConfig{
+				Enabled:        true,
+				Dir:            "./data/artifacts",
+				ThresholdBytes: 16384,
+				PreviewChars:   2000,
+				Summarize:      false,
+			},
+			Selection: ToolSelectionConfig{
+				Enabled:    true,
+				TopK:       15,
+				MinTools:   30,
+				Embeddings: false,
+			},
+			Output: ToolOutputConfig{
+				Validation: "warn",
+			},
+			Shell: ToolShellConfig{
+				Enabled:   false,
+				Workspace: "./workspace",
+				AllowedCommands: []string{
+					"ls", "cat", "head", "tail", "wc", "grep", "diff", "sort", "echo", "pwd", "mkdir", "touch",
+				},
+				DeniedCommands: []string{
+					"rm", "sudo", "su", "sh", "bash", "zsh", "curl", "wget", "ssh", "scp",
+					"dd", "mkfs", "chmod", "chown", "kill", "shutdown", "reboot",
+				},
+				Timeout:        "30s",
+				MaxOutputBytes: 65536,
+				CPUSeconds:     60,
+				MemoryMB:       2048,
+			},
 		},
 		Logging: LoggingConfig{
 			Level:    "info",
@@ -249,6 +519,98 @@ func (c *Config) Validate() error {
 	if c.LLM.APIKey == "" {
 		return fmt.Errorf("llm.api_key is required")
 	}
+	if c.Agent.MaxCost < 0 {
+		return fmt.Errorf("agent.max_cost must be non-negative")
+	}
+	switch c.Tools.Policy.Default {
+	case "", "allow", "deny":
+	default:
+		return fmt.Errorf("tools.policy.default must be one of allow, deny")
+	}
+	switch c.Tools.Cache.Backend {
+	case "", "memory", "disk":
+	default:
+		return fmt.Errorf("tools.cache.backend must be one of memory, disk")
+	}
+	if c.Tools.Artifacts.ThresholdBytes < 0 || c.Tools.Artifacts.PreviewChars < 0 {
+		return fmt.Errorf("tools.artifacts.threshold_bytes and preview_chars must be non-negative")
+	}
+	if c.Tools.Selection.TopK < 0 || c.Tools.Selection.MinTools < 0 {
+		return fmt.Errorf("tools.selection.top_k and min_tools must be non-negative")
+	}
+	switch c.Tools.Output.Validation {
+	case "", "off", "warn", "strict":
+	default:
+		return fmt.Errorf("tools.output.validation must be one of off, warn, strict")
+	}
+	if c.Tools.Shell.Timeout != "" {
+		if timeout, err := time.ParseDuration(c.Tools.Shell.Timeout); err != nil || timeout <= 0 {
+			return fmt.Errorf("invalid tools.shell.timeout: %s", c.Tools.Shell.Timeout)
+		}
+	}
+	if c.Tools.Shell.MaxOutputBytes < 0 || c.Tools.Shell.CPUSeconds < 0 || c.Tools.Shell.MemoryMB < 0 {
+		return fmt.Errorf("tools.shell.max_output_bytes, cpu_seconds and memory_mb must be non-negative")
+	}
+	pluginNames := make(map[string]bool, len(c.Tools.Plugins))
+	for _, plugin := range c.Tools.Plugins {
+		if plugin.Name == "" || plugin.Command == "" {
+			return fmt.Errorf("tools.plugins entries require a name and a command")
+		}
+		if pluginNames[plugin.Name] {
+			return fmt.Errorf("duplicate tools.plugins name: %s", plugin.Name)
+		}
+		pluginNames[plugin.Name] = true
+		for _, value := range []string{plugin.Timeout, plugin.StartTimeout} {
+			if value == "" {
+				continue
+			}
+			if _, err := time.ParseDuration(value); err != nil {
+				return fmt.Errorf("invalid timeout for plugin %s: %w", plugin.Name, err)
+			}
+		}
+	}
+	for _, service := range c.Tools.OpenAPI {
+		if service.Name == "" || service.Spec == "" {
+			return fmt.Errorf("tools.openapi entries require a name and a spec")
+		}
+		switch service.Auth.Type {
+		case "", "bearer", "basic":
+		case "header", "query":
+			if service.Auth.Name == "" {
+				return fmt.Errorf("tools.openapi %s: auth.name is required for %s auth", service.Name, service.Auth.Type)
+			}
+		default:
+			return fmt.Errorf("tools.openapi %s: auth.type must be one of bearer, basic, header, query", service.Name)
+		}
+	}
+	if c.Agent.MaxArgRepairs < 0 {
+		return fmt.Errorf("agent.max_arg_repairs must be non-negative")
+	}
+	if c.Agent.PartialCheckInterval != "" {
+		if interval, err := time.ParseDuration(c.Agent.PartialCheckInterval); err != nil || interval < 0 {
+			return fmt.Errorf("agent.partial_check_interval must be a non-negative duration")
+		}
+	}
+	for _, price := range c.LLM.Pricing {
+		if price.Model == "" || price.Input < 0 || price.Output < 0 {
+			return fmt.Errorf("llm.pricing entries require a model and non-negative prices")
+		}
+	}
+	switch c.LLM.Embedding.Provider {
+	case "", llm.EmbeddingProviderOpenAI, llm.EmbeddingProviderHash:
+	default:
+		return fmt.Errorf("llm.embedding.provider must be one of openai, hash")
+	}
+	switch c.LLM.ToolProtocol {
+	case "", "auto", "native", "react":
+	default:
+		return fmt.Errorf("llm.tool_protocol must be one of auto, native, react")
+	}
+	switch c.LLM.StructuredOutput {
+	case "", "none", llm.ResponseFormatJSONObject, llm.ResponseFormatJSONSchema:
+	default:
+		return fmt.Errorf("llm.structured_output must be one of none, json_object, json_schema")
+	}
 
 	// 验证 Agent 配置
 	if c.Agent.MaxSteps <= 0 {
@@ -272,9 +634,73 @@ func (c *Config) ToLLMConfig() *llm.Config {
 		Temperature: c.LLM.Temperature,
 		MaxTokens:   c.LLM.MaxTokens,
 		Timeout:     c.LLM.Timeout,
+
+		ContextWindow: c.LLM.ContextWindow,
 	}
 }
 
+// ToLLMCacheConfig 转换为 LLM 缓存配置
+func (c *Config) ToLLMCacheConfig() (*llm.CacheConfig, error) {
+	cacheConfig := llm.DefaultCacheConfig()
+	if c.LLM.Cache.Dir != "" {
+		cacheConfig.Dir = c.LLM.Cache.Dir
+	}
+	cacheConfig.TTL = 0
+	if c.LLM.Cache.TTL != "" {
+		ttl, err := time.ParseDuration(c.LLM.Cache.TTL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid llm.cache.ttl: %w", err)
+		}
+		cacheConfig.TTL = ttl
+	}
+	if c.LLM.Cache.MaxSizeMB > 0 {
+		cacheConfig.MaxBytes = int64(c.LLM.Cache.MaxSizeMB) * 1024 * 1024
+	}
+	return cacheConfig, nil
+}
+
+// ToPricingTable 转换为模型价格表
+func (c *Config) ToPricingTable() llm.PricingTable {
+	if len(c.LLM.Pricing) == 0 {
+		return nil
+	}
+	table := make(llm.PricingTable, len(c.LLM.Pricing))
+	for _, price := range c.LLM.Pricing {
+		if price.Model == "" {
+			continue
+		}
+		table[price.Model] = llm.ModelPrice{Input: price.Input, Output: price.Output}
+	}
+	return table
+}
+
+// ToEmbeddingConfig 转换为向量化配置，未设置的地址和密钥沿用 LLM 配置
+func (c *Config) ToEmbeddingConfig() *llm.EmbeddingConfig {
+	embeddingConfig := llm.DefaultEmbeddingConfig()
+	if c.LLM.Embedding.Provider != "" {
+		embeddingConfig.Provider = c.LLM.Embedding.Provider
+	}
+	if c.LLM.Embedding.Model != "" {
+		embeddingConfig.Model = c.LLM.Embedding.Model
+	}
+	embeddingConfig.BaseURL = c.LLM.BaseURL
+	if c.LLM.Embedding.BaseURL != "" {
+		embeddingConfig.BaseURL = c.LLM.Embedding.BaseURL
+	}
+	embeddingConfig.APIKey = c.LLM.APIKey
+	if c.LLM.Embedding.APIKey != "" {
+		embeddingConfig.APIKey = c.LLM.Embedding.APIKey
+	}
+	embeddingConfig.Dimensions = c.LLM.Embedding.Dimensions
+	if c.LLM.Embedding.BatchSize > 0 {
+		embeddingConfig.BatchSize = c.LLM.Embedding.BatchSize
+	}
+	if c.LLM.Timeout > 0 {
+		embeddingConfig.Timeout = c.LLM.Timeout
+	}
+	return embeddingConfig
+}
+
 // GetMaxDuration 获取最大持续时间
 func (c *Config) GetMaxDuration() (time.Duration, error) {
 	return time.ParseDuration(c.Agent.MaxDuration)
@@ -312,6 +738,41 @@ api_key = "your-api-key-here"
 temperature = 0.1
 max_tokens = 4000
 timeout = 30
+# context_window 覆盖模型上下文窗口（token），0 表示按模型名自动识别
+context_window = 0
+# structured_output: none | json_object | json_schema（需要提供方支持 response_format）
+structured_output = "none"
+# vision 为 true 时，浏览器截图等工具图片会作为多模态消息发送给模型（需视觉模型）
+vision = false
+# tool_protocol: auto（原生函数调用，模型不支持时自动切换）| native | react（Thought/Action 文本协议，适用于小型本地模型）
+tool_protocol = "auto"
+
+[llm.cache]
+# 磁盘缓存相同请求的 LLM 响应，也可通过 run --llm-cache 开启
+enabled = false
+dir = "./data/llm_cache"
+ttl = "24h"
+max_size_mb = 100
+
+[llm.embedding]
+# 向量化服务：openai（OpenAI 兼容 /embeddings）或 hash（本地哈希向量，无需网络）
+provider = "openai"
+model = "text-embedding-3-small"
+# base_url / api_key 留空时沿用 [llm] 配置
+dimensions = 0
+batch_size = 64
+
+# 模型价格表（每 1K token），用于计算调用费用和 agent.max_cost 预算
+# model 支持前缀匹配，例如 "gpt-4o" 同时匹配 "gpt-4o-2024-08-06"
+[[llm.pricing]]
+model = "gpt-3.5-turbo"
+input = 0.0005
+output = 0.0015
+
+[[llm.pricing]]
+model = "gpt-4o"
+input = 0.0025
+output = 0.01
 
 [agent]
 max_steps = 10
@@ -320,6 +781,17 @@ max_duration = "5m"
 reflection_steps = 3
 max_retries = 2
 retry_backoff = "1s"
+# max_cost 单次运行费用上限（按 llm.pricing 计算），0 表示不限制
+max_cost = 0
+# prompts_dir 自定义提示模板目录（planner_system.tmpl / reflector_system.tmpl），空表示使用内置模板
+prompts_dir = ""
+# locale 回答使用的语言，如 "zh-CN"，空表示不限制
+locale = ""
+# max_arg_repairs 工具参数无效且无法自动修正时请求模型重新生成的次数，0 表示不重试
+max_arg_repairs = 2
+# partial_check_interval 流式工具（crawler crawl、elasticsearch bulk）运行期间，
+# 每隔多久让模型判断已有的部分结果是否足以回答目标，足够时提前停止工具；"0" 表示不检查
+partial_check_interval = "20s"
 
 [runflow]
 use_data_analysis_agent = false
@@ -371,6 +843,131 @@ addresses = ["http://localhost:9200"]
 username = ""
 password = ""
 
+[tools.middleware]
+# redact_keys 日志中额外隐藏的参数名（password、token、api_key 等默认隐藏）
+redact_keys = []
+
+# 工具级缓存（cache_ttl 对该工具的所有调用开启缓存）；频率限制见 [tools.limits]
+# [tools.middleware.overrides.http]
+# cache_ttl = "5m"
+
+[tools.cache]
+# 工具结果缓存：只缓存工具声明为幂等的操作（HTTP GET、SELECT 查询、爬虫抓取等）
+enabled = false
+# backend: memory | disk（disk 可跨运行复用）
+backend = "memory"
+dir = "./data/tool_cache"
+ttl = "10m"
+max_entries = 1000
+
+[tools.artifacts]
+# 大输出工件：超过阈值的工具输出保存到 dir 下的运行目录，观测结果只保留引用和预览，
+# Agent 可通过 artifact 工具分页读取或搜索完整内容
+enabled = true
+dir = "./data/artifacts"
+threshold_bytes = 16384
+preview_chars = 2000
+# 是否调用 LLM 为转存的输出生成摘要
+summarize = false
+
+[tools.selection]
+# 工具选择：工具总数超过 min_tools 时，每步只发送与目标和最近步骤最相关的 top_k 个工具，
+# 以及 direct_answer、stop、search_tools、最近使用过和通过 search_tools 加载的工具
+enabled = true
+top_k = 15
+min_tools = 30
+always_include = []
+# 是否结合向量相似度（使用 [llm.embedding] 配置），否则只按关键词匹配
+embeddings = false
+
+[tools.output]
+# 按工具声明的 OutputSchema 校验输出：off 不校验，warn 只记录警告，strict 将不符合的输出作为错误交给规划器
+validation = "warn"
+
+[tools.shell]
+# shell 工具（默认关闭）：在 workspace 内运行命令（如运行 Agent 写入的脚本和测试），命令不经过 shell 解释，
+# 不支持管道、重定向和变量展开；cwd 和路径参数必须位于 workspace 内（拒绝绝对路径和 ..）。
+# denied_commands 优先于 allowed_commands，allowed_commands 为空表示不限制。
+# 注意：加入解释器（python3、node、go、pytest 等）后命令可执行任意代码，不再受 workspace 和 tools.fs.blocked_paths 限制，
+# 可读取配置文件中的 API Key，只应在容器等隔离环境中开启
+enabled = false
+workspace = "./workspace"
+allowed_commands = ["ls", "cat", "head", "tail", "wc", "grep", "diff", "sort", "echo", "pwd", "mkdir", "touch"]
+denied_commands = ["rm", "sudo", "su", "sh", "bash", "zsh", "curl", "wget", "ssh", "scp", "dd", "mkfs", "chmod", "chown", "kill", "shutdown", "reboot"]
+timeout = "30s"
+# stdout、stderr 各自保留的最大字节数
+max_output_bytes = 65536
+# CPU 时间（秒）和虚拟内存（MB）上限，0 表示不限制（Windows 不支持）
+cpu_seconds = 60
+memory_mb = 2048
+# 额外传递的环境变量（默认只传递 PATH、HOME、USER、LANG、LC_ALL、TZ、TMPDIR）：NAME 透传，NAME=VALUE 直接设置
+env = []
+
+# 外部插件工具：插件是一个可执行文件，通过 stdin/stdout 的 JSON 行协议
+# 响应 describe（返回工具名称、描述和 Schema）和 invoke 请求，作为常驻子进程运行，
+# 崩溃或超时后自动重启
+# 插件默认只继承 PATH、HOME、USER、LANG、LC_ALL、TZ、TMPDIR，env 中 NAME 从当前进程透传、NAME=VALUE 直接设置，
+# API Key 等其余变量需显式列出
+# [[tools.plugins]]
+# name = "text_stats"
+# command = "./bin/text-stats-plugin"
+# args = []
+# env = ["PLUGIN_MODE=prod"]
+# timeout = "30s"
+# start_timeout = "10s"
+# max_restarts = 3
+
+# OpenAPI 工具：规范（JSON 或 YAML）中的每个操作生成一个工具，名称为 <name>_<operationId>，
+# 参数来自 path/query/header 参数和请求体，JSON 响应解析后放在 data 字段
+# [[tools.openapi]]
+# name = "recommend"
+# spec = "./configs/openapi/recommend.yaml"
+# base_url = "http://localhost:8000"   # 覆盖规范中的 servers
+# operations = []                      # 只加载指定的 operationId，空表示全部
+# timeout = "30s"
+# [tools.openapi.auth]
+# type = "bearer"                      # bearer | basic | header | query
+# token = "${RECOMMEND_API_TOKEN}"
+
+[tools.policy]
+# 工具权限策略。default: allow | deny（未被 allow / deny 命中的工具）
+default = "allow"
+# allow / deny 按工具名称匹配，支持 * 通配；deny 优先
+allow = []
+deny = []
+
+# 工具级规则示例：mysql 只允许查询
+# [tools.policy.tools.mysql]
+# operations = ["query", "describe", "show_tables"]
+# [[tools.policy.tools.mysql.args]]
+# arg = "sql"
+# match = "sql_verb"          # regex | glob | sql_verb
+# allow = ["SELECT", "SHOW", "DESCRIBE", "EXPLAIN"]
+#
+# [tools.policy.tools.redis]
+# deny_operations = ["del", "hdel", "srem"]
+#
+# [[tools.policy.tools.http.args]]
+# arg = "url"
+# match = "glob"              # * 不跨越 /，** 匹配任意字符
+# allow = ["https://**"]
+
+# 工具并发和频率限制：超出限制的调用排队等待，等待时间记录在观测结果的 wait_ms 中（计入工具执行超时）；
+# 被权限策略拒绝的调用和缓存命中不占用配额
+# [tools.limits.default]
+# rate = 10         # 未单独配置的工具，每个工具每秒调用次数
+# burst = 10
+#
+# [tools.limits.tools.mysql]
+# max_concurrent = 2
+# rate = 5          # 每秒调用次数
+# burst = 5
+#
+# MCP 服务器级限制（该服务器的所有工具共享）
+# [tools.limits.servers.mcp-stock-helper]
+# max_concurrent = 1
+# rate = 1
+
 [logging]
 # level: debug | info | warn | error
 level = "info"
@@ -0,0 +1,275 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"openmanus-go/pkg/logger"
+)
+
+// CacheConfig 表示 LLM 响应缓存配置
+type CacheConfig struct {
+	Dir      string        // 缓存目录
+	TTL      time.Duration // 缓存有效期，0 表示永不过期
+	MaxBytes int64         // 缓存目录最大字节数，0 表示不限制
+}
+
+// DefaultCacheConfig 返回默认缓存配置
+func DefaultCacheConfig() *CacheConfig {
+	return &CacheConfig{
+		Dir:      "./data/llm_cache",
+		TTL:      24 * time.Hour,
+		MaxBytes: 100 * 1024 * 1024,
+	}
+}
+
+// cacheEntry 表示磁盘上的一条缓存记录
+type cacheEntry struct {
+	Key       string        `json:"key"`
+	Model     string        `json:"model"`
+	CreatedAt time.Time     `json:"created_at"`
+	Response  *ChatResponse `json:"response"`
+}
+
+// CachingClient 带磁盘缓存的 LLM 客户端包装器
+type CachingClient struct {
+	client Client
+	config *CacheConfig
+	mu     sync.Mutex
+}
+
+// NewCachingClient 创建带磁盘缓存的 LLM 客户端
+func NewCachingClient(client Client, config *CacheConfig) (*CachingClient, error) {
+	if config == nil {
+		config = DefaultCacheConfig()
+	}
+	if config.Dir == "" {
+		config.Dir = DefaultCacheConfig().Dir
+	}
+
+	if err := os.MkdirAll(config.Dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	return &CachingClient{
+		client: client,
+		config: config,
+	}, nil
+}
+
+// Chat 发送聊天请求，命中缓存时直接返回缓存的响应
+func (c *CachingClient) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	// 与底层客户端保持一致：未指定模型时使用当前模型
+	if req.Model == "" {
+		req.Model = c.client.GetModel()
+	}
+
+	key, err := CacheKey(req)
+	if err != nil {
+		logger.Warnw("llm.cache.key_failed", "error", err)
+		return c.client.Chat(ctx, req)
+	}
+
+	if resp, ok := c.load(key); ok {
+		logger.Debugw("llm.cache.hit", "key", key[:12], "model", req.Model)
+		resp.CacheHit = true
+		return resp, nil
+	}
+
+	logger.Debugw("llm.cache.miss", "key", key[:12], "model", req.Model)
+	resp, err := c.client.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.store(key, req.Model, resp); err != nil {
+		logger.Warnw("llm.cache.store_failed", "key", key[:12], "error", err)
+	}
+
+	return resp, nil
+}
+
+// ChatStream 流式请求不做缓存，直接透传
+func (c *CachingClient) ChatStream(ctx context.Context, req *ChatRequest) (<-chan *ChatResponse, error) {
+	return c.client.ChatStream(ctx, req)
+}
+
+// GetModel 获取当前使用的模型
+func (c *CachingClient) GetModel() string {
+	return c.client.GetModel()
+}
+
+// SetModel 设置使用的模型
+func (c *CachingClient) SetModel(model string) {
+	c.client.SetModel(model)
+}
+
+// Clear 清空缓存目录中的所有缓存记录
+func (c *CachingClient) Clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	files, err := filepath.Glob(filepath.Join(c.config.Dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove cache file: %w", err)
+		}
+	}
+	return nil
+}
+
+// CacheKey 基于请求的规范化内容（模型、消息、工具、温度、输出格式）计算缓存键
+func CacheKey(req *ChatRequest) (string, error) {
+	canonical := struct {
+		Model       string    `json:"model"`
+		Messages    []Message `json:"messages"`
+		Tools       []Tool    `json:"tools,omitempty"`
+		ToolChoice  any       `json:"tool_choice,omitempty"`
+		Temperature float64   `json:"temperature"`
+
+		ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+	}{
+		Model:       req.Model,
+		Messages:    req.Messages,
+		Tools:       req.Tools,
+		ToolChoice:  req.ToolChoice,
+		Temperature: req.Temperature,
+
+		ResponseFormat: req.ResponseFormat,
+	}
+
+	// encoding/json 对 map 键排序输出，Schema 中的 map 因此具有稳定的序列化结果
+	data, err := json.Marshal(canonical)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal cache key: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// load 读取缓存记录，过期或损坏的记录会被删除
+func (c *CachingClient) load(key string) (*ChatResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	path := c.entryPath(key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Response == nil {
+		_ = os.Remove(path)
+		return nil, false
+	}
+
+	if c.config.TTL > 0 && time.Since(entry.CreatedAt) > c.config.TTL {
+		_ = os.Remove(path)
+		return nil, false
+	}
+
+	return entry.Response, true
+}
+
+// store 写入缓存记录并按大小限制清理旧记录
+func (c *CachingClient) store(key, model string, resp *ChatResponse) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, err := json.Marshal(cacheEntry{
+		Key:       key,
+		Model:     model,
+		CreatedAt: time.Now(),
+		Response:  resp,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache entry: %w", err)
+	}
+
+	// 先写临时文件再重命名，避免并发读取到半写入的文件
+	path := c.entryPath(key)
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to rename cache file: %w", err)
+	}
+
+	return c.prune()
+}
+
+// prune 删除过期记录，并在超过大小限制时从最旧的记录开始删除
+func (c *CachingClient) prune() error {
+	entries, err := os.ReadDir(c.config.Dir)
+	if err != nil {
+		return fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	type cacheFile struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+
+	var files []cacheFile
+	var total int64
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		path := filepath.Join(c.config.Dir, entry.Name())
+		if c.config.TTL > 0 && time.Since(info.ModTime()) > c.config.TTL {
+			_ = os.Remove(path)
+			continue
+		}
+
+		files = append(files, cacheFile{path: path, size: info.Size(), modTime: info.ModTime()})
+		total += info.Size()
+	}
+
+	if c.config.MaxBytes <= 0 || total <= c.config.MaxBytes {
+		return nil
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+
+	for _, file := range files {
+		if total <= c.config.MaxBytes {
+			break
+		}
+		if err := os.Remove(file.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to evict cache file: %w", err)
+		}
+		total -= file.size
+		logger.Debugw("llm.cache.evict", "file", filepath.Base(file.path), "size", file.size)
+	}
+
+	return nil
+}
+
+// entryPath 返回缓存键对应的文件路径
+func (c *CachingClient) entryPath(key string) string {
+	return filepath.Join(c.config.Dir, key+".json")
+}
@@ -0,0 +1,297 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"openmanus-go/pkg/logger"
+)
+
+// MatchMode 表示回放时请求的匹配模式
+type MatchMode string
+
+const (
+	// MatchStrict 严格匹配：请求的规范化哈希必须与录制时完全一致
+	MatchStrict MatchMode = "strict"
+	// MatchFuzzy 模糊匹配：忽略提示中的数字等易变内容；提示有其他变化时仍视为未命中
+	MatchFuzzy MatchMode = "fuzzy"
+)
+
+// cassetteVersion 当前磁带文件格式版本
+const cassetteVersion = 1
+
+// Cassette 表示一盘录制的请求/响应磁带
+type Cassette struct {
+	Version      int           `json:"version"`
+	RecordedAt   time.Time     `json:"recorded_at"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction 表示一次录制的 LLM 交互
+type Interaction struct {
+	Key      string        `json:"key"`       // 严格匹配使用的请求哈希
+	FuzzyKey string        `json:"fuzzy_key"` // 模糊匹配使用的请求哈希
+	Request  *ChatRequest  `json:"request"`
+	Response *ChatResponse `json:"response"`
+}
+
+// LoadCassette 从文件加载磁带
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal cassette: %w", err)
+	}
+
+	if cassette.Version != cassetteVersion {
+		return nil, fmt.Errorf("unsupported cassette version: %d", cassette.Version)
+	}
+
+	return &cassette, nil
+}
+
+// Save 保存磁带到文件
+func (c *Cassette) Save(path string) error {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create cassette directory: %w", err)
+		}
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal cassette: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+
+	return nil
+}
+
+// RecordingClient 将每次请求/响应录制到磁带文件的 LLM 客户端包装器
+type RecordingClient struct {
+	client   Client
+	path     string
+	cassette *Cassette
+	mu       sync.Mutex
+}
+
+// NewRecordingClient 创建录制客户端，每次请求后都会写回磁带文件
+func NewRecordingClient(client Client, path string) *RecordingClient {
+	return &RecordingClient{
+		client: client,
+		path:   path,
+		cassette: &Cassette{
+			Version:    cassetteVersion,
+			RecordedAt: time.Now(),
+		},
+	}
+}
+
+// Chat 发送聊天请求并录制交互
+func (c *RecordingClient) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	if req.Model == "" {
+		req.Model = c.client.GetModel()
+	}
+
+	// 在调用前计算键，避免底层客户端填充默认参数后与回放时不一致
+	interaction, err := newInteraction(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	interaction.Response = resp
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cassette.Interactions = append(c.cassette.Interactions, *interaction)
+	if err := c.cassette.Save(c.path); err != nil {
+		logger.Warnw("llm.cassette.save_failed", "path", c.path, "error", err)
+	}
+
+	return resp, nil
+}
+
+// ChatStream 流式请求不录制，直接透传
+func (c *RecordingClient) ChatStream(ctx context.Context, req *ChatRequest) (<-chan *ChatResponse, error) {
+	return c.client.ChatStream(ctx, req)
+}
+
+// GetModel 获取当前使用的模型
+func (c *RecordingClient) GetModel() string {
+	return c.client.GetModel()
+}
+
+// SetModel 设置使用的模型
+func (c *RecordingClient) SetModel(model string) {
+	c.client.SetModel(model)
+}
+
+// Cassette 返回当前录制的磁带
+func (c *RecordingClient) Cassette() *Cassette {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cassette
+}
+
+// ReplayClient 从磁带回放响应的 LLM 客户端，不发起任何网络请求
+type ReplayClient struct {
+	cassette *Cassette
+	mode     MatchMode
+	model    string
+	used     []bool
+	mu       sync.Mutex
+}
+
+// NewReplayClient 创建回放客户端
+func NewReplayClient(cassette *Cassette, mode MatchMode) *ReplayClient {
+	if mode == "" {
+		mode = MatchStrict
+	}
+
+	model := ""
+	if len(cassette.Interactions) > 0 && cassette.Interactions[0].Request != nil {
+		model = cassette.Interactions[0].Request.Model
+	}
+
+	return &ReplayClient{
+		cassette: cassette,
+		mode:     mode,
+		model:    model,
+		used:     make([]bool, len(cassette.Interactions)),
+	}
+}
+
+// NewReplayClientFromFile 从磁带文件创建回放客户端
+func NewReplayClientFromFile(path string, mode MatchMode) (*ReplayClient, error) {
+	cassette, err := LoadCassette(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewReplayClient(cassette, mode), nil
+}
+
+// Chat 返回与请求匹配的录制响应
+func (c *ReplayClient) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	if req.Model == "" {
+		req.Model = c.model
+	}
+
+	probe, err := newInteraction(req)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	index := c.find(func(i Interaction) bool { return i.Key == probe.Key })
+	if index < 0 && c.mode == MatchFuzzy {
+		index = c.find(func(i Interaction) bool { return i.FuzzyKey == probe.FuzzyKey })
+	}
+
+	if index < 0 {
+		return nil, fmt.Errorf("no recorded interaction matches request (mode: %s, key: %s)", c.mode, probe.Key[:12])
+	}
+
+	c.used[index] = true
+	logger.Debugw("llm.cassette.replay", "index", index, "mode", c.mode)
+
+	// 返回副本，避免调用方修改磁带内容
+	resp := *c.cassette.Interactions[index].Response
+	return &resp, nil
+}
+
+// ChatStream 以单个分片的形式回放响应
+func (c *ReplayClient) ChatStream(ctx context.Context, req *ChatRequest) (<-chan *ChatResponse, error) {
+	resp, err := c.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	respChan := make(chan *ChatResponse, 1)
+	respChan <- resp
+	close(respChan)
+	return respChan, nil
+}
+
+// GetModel 获取当前使用的模型
+func (c *ReplayClient) GetModel() string {
+	return c.model
+}
+
+// SetModel 设置使用的模型
+func (c *ReplayClient) SetModel(model string) {
+	c.model = model
+}
+
+// Remaining 返回尚未被回放的交互数量
+func (c *ReplayClient) Remaining() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	remaining := 0
+	for _, used := range c.used {
+		if !used {
+			remaining++
+		}
+	}
+	return remaining
+}
+
+// find 按录制顺序查找第一条满足条件且未使用的交互
+func (c *ReplayClient) find(match func(Interaction) bool) int {
+	for i, interaction := range c.cassette.Interactions {
+		if !c.used[i] && match(interaction) {
+			return i
+		}
+	}
+	return -1
+}
+
+// volatilePattern 匹配提示中容易变化的数字内容（步数、耗时、时间戳等）
+var volatilePattern = regexp.MustCompile(`\d+(\.\d+)?`)
+
+// newInteraction 为请求计算严格键和模糊键
+func newInteraction(req *ChatRequest) (*Interaction, error) {
+	key, err := CacheKey(req)
+	if err != nil {
+		return nil, err
+	}
+
+	normalized := *req
+	normalized.Messages = make([]Message, len(req.Messages))
+	for i, msg := range req.Messages {
+		msg.Content = volatilePattern.ReplaceAllString(msg.Content, "#")
+		normalized.Messages[i] = msg
+	}
+
+	fuzzyKey, err := CacheKey(&normalized)
+	if err != nil {
+		return nil, err
+	}
+
+	requestCopy := *req
+	return &Interaction{
+		Key:      key,
+		FuzzyKey: fuzzyKey,
+		Request:  &requestCopy,
+	}, nil
+}
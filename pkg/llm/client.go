@@ -3,6 +3,7 @@ package llm
 import (
 	"context"
 	"encoding/json"
+	"strings"
 )
 
 // Message 表示对话消息
@@ -11,6 +12,16 @@ type Message struct {
 	Content   string     `json:"content"`              // 消息内容
 	Name      string     `json:"name,omitempty"`       // 工具名称（仅用于 tool 消息）
 	ToolCalls []ToolCall `json:"tool_calls,omitempty"` // 工具调用（用于 assistant 消息）
+
+	// ToolCallID 对应的工具调用 ID（仅用于 tool 消息）
+	ToolCallID string `json:"tool_call_id,omitempty"`
+
+	// ReasoningContent 推理模型（如 deepseek-reasoner）返回的思考过程，仅出现在响应中；
+	// 提供方要求后续请求不得回传该字段，OpenAIClient 发送前会将其清除
+	ReasoningContent string `json:"reasoning_content,omitempty"`
+
+	// Parts 追加在 Content 之后的多模态片段（如图片），非空时 content 序列化为片段数组
+	Parts []ContentPart `json:"-"`
 }
 
 // ToolCall 表示工具调用
@@ -48,6 +59,54 @@ type ChatRequest struct {
 	Temperature float64   `json:"temperature,omitempty"`
 	MaxTokens   int       `json:"max_tokens,omitempty"`
 	Stream      bool      `json:"stream,omitempty"`
+
+	// ResponseFormat 约束输出格式（json_object 或 json_schema），nil 表示自由文本
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+}
+
+// 结构化输出模式
+const (
+	ResponseFormatText       = "text"
+	ResponseFormatJSONObject = "json_object"
+	ResponseFormatJSONSchema = "json_schema"
+)
+
+// ResponseFormat 表示响应格式约束
+type ResponseFormat struct {
+	Type       string      `json:"type"` // text, json_object, json_schema
+	JSONSchema *JSONSchema `json:"json_schema,omitempty"`
+}
+
+// JSONSchema 表示 json_schema 模式下的输出 Schema
+type JSONSchema struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Schema      map[string]any `json:"schema"`
+	Strict      bool           `json:"strict,omitempty"`
+}
+
+// NewResponseFormat 根据结构化输出模式创建响应格式
+//
+// mode 为 json_schema 时使用给定的 Schema；为 json_object 时只要求输出合法 JSON；
+// 其他取值（包括空字符串）返回 nil，表示不约束格式。
+func NewResponseFormat(mode, name string, schema map[string]any) *ResponseFormat {
+	switch mode {
+	case ResponseFormatJSONSchema:
+		if schema == nil {
+			return &ResponseFormat{Type: ResponseFormatJSONObject}
+		}
+		return &ResponseFormat{
+			Type: ResponseFormatJSONSchema,
+			JSONSchema: &JSONSchema{
+				Name:   name,
+				Schema: schema,
+			},
+		}
+	case ResponseFormatJSONObject:
+		return &ResponseFormat{Type: ResponseFormatJSONObject}
+	default:
+		return nil
+	}
 }
 
 // ChatResponse 表示聊天响应
@@ -58,6 +117,9 @@ type ChatResponse struct {
 	Model   string   `json:"model"`
 	Choices []Choice `json:"choices"`
 	Usage   Usage    `json:"usage"`
+
+	// CacheHit 标记响应是否来自本地缓存（不参与序列化）
+	CacheHit bool `json:"-"`
 }
 
 // Choice 表示响应选择
@@ -98,6 +160,9 @@ type Config struct {
 	Temperature float64 `json:"temperature" mapstructure:"temperature"`
 	MaxTokens   int     `json:"max_tokens" mapstructure:"max_tokens"`
 	Timeout     int     `json:"timeout" mapstructure:"timeout"` // 秒
+
+	// ContextWindow 覆盖模型的上下文窗口大小（token），0 表示使用内置表
+	ContextWindow int `json:"context_window,omitempty" mapstructure:"context_window"`
 }
 
 // DefaultConfig 返回默认配置
@@ -120,6 +185,32 @@ func ParseToolCallArguments(arguments string) (map[string]any, error) {
 	return args, nil
 }
 
+// ParseToolCallArgumentsLenient 宽松解析工具调用参数
+//
+// 在 ParseToolCallArguments 的基础上容忍：空参数、代码块包裹、前后缀文本、尾逗号，
+// 以及被二次编码为 JSON 字符串的参数对象。
+func ParseToolCallArgumentsLenient(arguments string) (map[string]any, error) {
+	trimmed := strings.TrimSpace(arguments)
+	if trimmed == "" || trimmed == "null" {
+		return map[string]any{}, nil
+	}
+
+	// 二次编码："{\"query\": \"x\"}"
+	var encoded string
+	if err := json.Unmarshal([]byte(trimmed), &encoded); err == nil {
+		trimmed = strings.TrimSpace(encoded)
+	}
+
+	var args map[string]any
+	if err := ParseJSONObject(trimmed, &args); err != nil {
+		return nil, err
+	}
+	if args == nil {
+		args = map[string]any{}
+	}
+	return args, nil
+}
+
 // CreateToolFromToolInfo 从工具信息创建 LLM Tool
 func CreateToolFromToolInfo(name, description string, parameters map[string]any) Tool {
 	return Tool{
@@ -0,0 +1,374 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"openmanus-go/pkg/logger"
+)
+
+// 向量化提供方
+const (
+	EmbeddingProviderOpenAI = "openai"
+	EmbeddingProviderHash   = "hash"
+)
+
+const (
+	// defaultEmbeddingBatchSize 单次 /embeddings 请求的默认输入条数
+	defaultEmbeddingBatchSize = 64
+	// defaultHashDimensions 本地哈希向量的默认维度
+	defaultHashDimensions = 256
+)
+
+// Embedder 定义文本向量化接口
+type Embedder interface {
+	// Embed 将文本批量转换为向量，返回顺序与输入一致
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+
+	// Dimensions 返回向量维度，未知时返回 0
+	Dimensions() int
+
+	// GetModel 获取当前使用的向量模型
+	GetModel() string
+}
+
+// UsageEmbedder 可同时返回 token 用量的向量化客户端
+type UsageEmbedder interface {
+	Embedder
+
+	// EmbedWithUsage 与 Embed 相同，并返回所有批次累计的用量
+	EmbedWithUsage(ctx context.Context, texts []string) ([][]float32, Usage, error)
+}
+
+// EmbeddingConfig 表示向量化客户端配置
+type EmbeddingConfig struct {
+	Provider   string `json:"provider" mapstructure:"provider"` // openai, hash
+	Model      string `json:"model" mapstructure:"model"`
+	BaseURL    string `json:"base_url" mapstructure:"base_url"`
+	APIKey     string `json:"api_key" mapstructure:"api_key"`
+	Dimensions int    `json:"dimensions" mapstructure:"dimensions"` // 0 表示使用模型默认维度
+	BatchSize  int    `json:"batch_size" mapstructure:"batch_size"`
+	Timeout    int    `json:"timeout" mapstructure:"timeout"` // 秒
+}
+
+// DefaultEmbeddingConfig 返回默认向量化配置
+func DefaultEmbeddingConfig() *EmbeddingConfig {
+	return &EmbeddingConfig{
+		Provider:  EmbeddingProviderOpenAI,
+		Model:     "text-embedding-3-small",
+		BaseURL:   "https://api.openai.com/v1",
+		BatchSize: defaultEmbeddingBatchSize,
+		Timeout:   30,
+	}
+}
+
+// NewEmbedder 根据配置创建向量化客户端
+func NewEmbedder(config *EmbeddingConfig) (Embedder, error) {
+	if config == nil {
+		config = DefaultEmbeddingConfig()
+	}
+
+	switch config.Provider {
+	case "", EmbeddingProviderOpenAI:
+		return NewOpenAIEmbedder(config), nil
+	case EmbeddingProviderHash:
+		return NewHashEmbedder(config.Dimensions), nil
+	default:
+		return nil, fmt.Errorf("unsupported embedding provider: %s", config.Provider)
+	}
+}
+
+// OpenAIEmbedder OpenAI 兼容的 /embeddings 客户端
+type OpenAIEmbedder struct {
+	config     *EmbeddingConfig
+	httpClient *http.Client
+}
+
+// NewOpenAIEmbedder 创建 OpenAI 兼容的向量化客户端
+func NewOpenAIEmbedder(config *EmbeddingConfig) *OpenAIEmbedder {
+	if config == nil {
+		config = DefaultEmbeddingConfig()
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultEmbeddingBatchSize
+	}
+
+	timeout := time.Duration(config.Timeout) * time.Second
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+
+	return &OpenAIEmbedder{
+		config: config,
+		httpClient: &http.Client{
+			Timeout: timeout,
+		},
+	}
+}
+
+// embeddingRequest 表示 /embeddings 请求
+type embeddingRequest struct {
+	Model          string   `json:"model"`
+	Input          []string `json:"input"`
+	Dimensions     int      `json:"dimensions,omitempty"`
+	EncodingFormat string   `json:"encoding_format,omitempty"`
+}
+
+// embeddingResponse 表示 /embeddings 响应
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+	Model string `json:"model"`
+	Usage struct {
+		PromptTokens int `json:"prompt_tokens"`
+		TotalTokens  int `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+// Embed 按批次发送向量化请求
+func (e *OpenAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors, _, err := e.EmbedWithUsage(ctx, texts)
+	return vectors, err
+}
+
+// EmbedWithUsage 按批次发送向量化请求，返回累计用量
+func (e *OpenAIEmbedder) EmbedWithUsage(ctx context.Context, texts []string) ([][]float32, Usage, error) {
+	var usage Usage
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += e.config.BatchSize {
+		end := start + e.config.BatchSize
+		if end > len(texts) {
+			end = len(texts)
+		}
+
+		batch, batchUsage, err := e.embedBatch(ctx, texts[start:end])
+		usage.PromptTokens += batchUsage.PromptTokens
+		usage.TotalTokens += batchUsage.TotalTokens
+		if err != nil {
+			return nil, usage, fmt.Errorf("failed to embed batch %d-%d: %w", start, end, err)
+		}
+		vectors = append(vectors, batch...)
+	}
+	return vectors, usage, nil
+}
+
+// embedBatch 发送单个批次的向量化请求
+func (e *OpenAIEmbedder) embedBatch(ctx context.Context, texts []string) ([][]float32, Usage, error) {
+	// 部分提供方拒绝空字符串输入，用单个空格代替
+	input := make([]string, len(texts))
+	for i, text := range texts {
+		if strings.TrimSpace(text) == "" {
+			text = " "
+		}
+		input[i] = text
+	}
+
+	reqBody, err := json.Marshal(embeddingRequest{
+		Model:          e.config.Model,
+		Input:          input,
+		Dimensions:     e.config.Dimensions,
+		EncodingFormat: "float",
+	})
+	if err != nil {
+		return nil, Usage{}, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := strings.TrimSuffix(e.config.BaseURL, "/") + "/embeddings"
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, Usage{}, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+e.config.APIKey)
+	httpReq.Header.Set("User-Agent", "OpenManus-Go/1.0")
+
+	start := time.Now()
+	resp, err := e.httpClient.Do(httpReq)
+	if err != nil {
+		logger.Errorw("llm.embed.transport_error", "error", err)
+		return nil, Usage{}, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, Usage{}, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errorResp struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(respBody, &errorResp); err == nil && errorResp.Error.Message != "" {
+			logger.Errorw("llm.embed.api_error", "status", resp.StatusCode, "message", errorResp.Error.Message)
+			return nil, Usage{}, fmt.Errorf("API error (%d): %s", resp.StatusCode, errorResp.Error.Message)
+		}
+		logger.Errorw("llm.embed.api_error_raw", "status", resp.StatusCode, "body", string(respBody))
+		return nil, Usage{}, fmt.Errorf("API error (%d): %s", resp.StatusCode, string(respBody))
+	}
+
+	var embedResp embeddingResponse
+	if err := json.Unmarshal(respBody, &embedResp); err != nil {
+		return nil, Usage{}, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	usage := Usage{PromptTokens: embedResp.Usage.PromptTokens, TotalTokens: embedResp.Usage.TotalTokens}
+	if len(embedResp.Data) != len(texts) {
+		return nil, usage, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(embedResp.Data))
+	}
+
+	// 响应顺序不保证与输入一致，按 index 排序
+	sort.Slice(embedResp.Data, func(i, j int) bool {
+		return embedResp.Data[i].Index < embedResp.Data[j].Index
+	})
+
+	vectors := make([][]float32, len(embedResp.Data))
+	for i, item := range embedResp.Data {
+		vectors[i] = item.Embedding
+	}
+
+	logger.Debugw("llm.embed.response", "model", embedResp.Model, "inputs", len(texts), "tokens", embedResp.Usage.TotalTokens, "latency_ms", time.Since(start).Milliseconds())
+
+	return vectors, usage, nil
+}
+
+// Dimensions 返回配置的向量维度
+func (e *OpenAIEmbedder) Dimensions() int {
+	return e.config.Dimensions
+}
+
+// GetModel 获取当前向量模型
+func (e *OpenAIEmbedder) GetModel() string {
+	return e.config.Model
+}
+
+// HashEmbedder 基于特征哈希的本地向量化实现
+//
+// 将文本切分为小写单词和中日韩单字，哈希到固定维度并做 L2 归一化。
+// 结果确定、无需网络，适合测试和离线场景；语义能力仅限于词汇重叠。
+type HashEmbedder struct {
+	dimensions int
+}
+
+// NewHashEmbedder 创建本地哈希向量化器，dimensions <= 0 时使用默认维度
+func NewHashEmbedder(dimensions int) *HashEmbedder {
+	if dimensions <= 0 {
+		dimensions = defaultHashDimensions
+	}
+	return &HashEmbedder{dimensions: dimensions}
+}
+
+// Embed 将文本转换为哈希向量
+func (e *HashEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		vectors[i] = e.embed(text)
+	}
+	return vectors, nil
+}
+
+// embed 计算单条文本的哈希向量
+func (e *HashEmbedder) embed(text string) []float32 {
+	vector := make([]float32, e.dimensions)
+	for _, term := range hashTerms(text) {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(term))
+		sum := h.Sum64()
+
+		// 低位决定维度，高位决定符号，减少碰撞带来的偏差
+		index := int(sum % uint64(e.dimensions))
+		if sum>>63 == 1 {
+			vector[index]--
+		} else {
+			vector[index]++
+		}
+	}
+	return Normalize(vector)
+}
+
+// hashTerms 将文本切分为小写单词和中日韩单字
+func hashTerms(text string) []string {
+	var terms []string
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			terms = append(terms, word.String())
+			word.Reset()
+		}
+	}
+
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case isCJK(r):
+			flush()
+			terms = append(terms, string(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			word.WriteRune(r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return terms
+}
+
+// Dimensions 返回向量维度
+func (e *HashEmbedder) Dimensions() int {
+	return e.dimensions
+}
+
+// GetModel 返回本地哈希模型名称
+func (e *HashEmbedder) GetModel() string {
+	return fmt.Sprintf("hash-%d", e.dimensions)
+}
+
+// Normalize 对向量做 L2 归一化（原地修改并返回），零向量保持不变
+func Normalize(vector []float32) []float32 {
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v) * float64(v)
+	}
+	if norm == 0 {
+		return vector
+	}
+	norm = math.Sqrt(norm)
+	for i := range vector {
+		vector[i] = float32(float64(vector[i]) / norm)
+	}
+	return vector
+}
+
+// CosineSimilarity 计算两个向量的余弦相似度，维度不一致或存在零向量时返回 0
+func CosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,142 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// codeFencePattern 匹配 Markdown 代码块（可选语言标记）
+var codeFencePattern = regexp.MustCompile("(?s)```[a-zA-Z0-9_-]*\\s*\\n?(.*?)```")
+
+// ParseJSONObject 宽松地将模型输出解析为 JSON 对象
+//
+// 依次尝试：直接解析；去除代码块标记；提取第一个完整的 JSON 对象；修复多余的尾逗号。
+// 用于不支持 response_format 的提供方，或模型未严格遵守格式要求的情况。
+func ParseJSONObject(content string, v any) error {
+	trimmed := strings.TrimSpace(content)
+	if err := json.Unmarshal([]byte(trimmed), v); err == nil {
+		return nil
+	}
+
+	extracted, err := ExtractJSONObject(trimmed)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal([]byte(extracted), v); err != nil {
+		repaired := RepairJSON(extracted)
+		if err := json.Unmarshal([]byte(repaired), v); err != nil {
+			return fmt.Errorf("failed to parse JSON object: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ExtractJSONObject 从文本中提取第一个括号配平的 JSON 对象
+func ExtractJSONObject(content string) (string, error) {
+	// 优先在代码块内查找
+	candidates := make([]string, 0, 2)
+	for _, match := range codeFencePattern.FindAllStringSubmatch(content, -1) {
+		candidates = append(candidates, match[1])
+	}
+	candidates = append(candidates, content)
+
+	for _, candidate := range candidates {
+		if obj, ok := scanJSONObject(candidate); ok {
+			return obj, nil
+		}
+	}
+
+	return "", fmt.Errorf("no JSON object found in content")
+}
+
+// scanJSONObject 从第一个 '{' 开始扫描，返回配平的对象文本（忽略字符串中的括号）
+func scanJSONObject(text string) (string, bool) {
+	for start := strings.IndexByte(text, '{'); start >= 0; {
+		depth := 0
+		inString := false
+		escaped := false
+
+		for i := start; i < len(text); i++ {
+			c := text[i]
+			if inString {
+				switch {
+				case escaped:
+					escaped = false
+				case c == '\\':
+					escaped = true
+				case c == '"':
+					inString = false
+				}
+				continue
+			}
+
+			switch c {
+			case '"':
+				inString = true
+			case '{':
+				depth++
+			case '}':
+				depth--
+				if depth == 0 {
+					return text[start : i+1], true
+				}
+			}
+		}
+
+		// 当前起点未配平（通常是输出被截断），尝试下一个 '{'
+		next := strings.IndexByte(text[start+1:], '{')
+		if next < 0 {
+			break
+		}
+		start += next + 1
+	}
+
+	return "", false
+}
+
+// RepairJSON 修复常见的 JSON 格式问题：对象或数组结尾多余的逗号
+func RepairJSON(text string) string {
+	var out strings.Builder
+	out.Grow(len(text))
+
+	inString := false
+	escaped := false
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			out.WriteByte(c)
+			continue
+		}
+
+		if c == '"' {
+			inString = true
+		}
+
+		if c == ',' {
+			// 跳过空白后紧跟 } 或 ] 的逗号
+			j := i + 1
+			for j < len(text) && strings.ContainsRune(" \t\r\n", rune(text[j])) {
+				j++
+			}
+			if j < len(text) && (text[j] == '}' || text[j] == ']') {
+				continue
+			}
+		}
+
+		out.WriteByte(c)
+	}
+
+	return out.String()
+}
@@ -0,0 +1,191 @@
+package llm
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 内容片段类型
+const (
+	ContentPartText     = "text"
+	ContentPartImageURL = "image_url"
+)
+
+// 图片细节级别
+const (
+	ImageDetailAuto = "auto"
+	ImageDetailLow  = "low"
+	ImageDetailHigh = "high"
+)
+
+// imageTokens 单张图片的估算 token 数（按高细节级别的典型值保守估算）
+const imageTokens = 765
+
+// ContentPart 表示多模态消息中的一个内容片段
+type ContentPart struct {
+	Type     string    `json:"type"` // text, image_url
+	Text     string    `json:"text,omitempty"`
+	ImageURL *ImageURL `json:"image_url,omitempty"`
+}
+
+// ImageURL 表示图片引用，URL 可以是 http(s) 地址或 data URL（base64）
+type ImageURL struct {
+	URL    string `json:"url"`
+	Detail string `json:"detail,omitempty"` // auto, low, high
+}
+
+// TextPart 创建文本片段
+func TextPart(text string) ContentPart {
+	return ContentPart{Type: ContentPartText, Text: text}
+}
+
+// ImageURLPart 创建图片 URL 片段
+func ImageURLPart(url, detail string) ContentPart {
+	return ContentPart{
+		Type:     ContentPartImageURL,
+		ImageURL: &ImageURL{URL: url, Detail: detail},
+	}
+}
+
+// ImageDataPart 将图片数据编码为 base64 data URL 片段，mimeType 为空时自动识别
+func ImageDataPart(data []byte, mimeType, detail string) ContentPart {
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+	url := "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data)
+	return ImageURLPart(url, detail)
+}
+
+// ImageFilePart 读取本地图片文件并创建 base64 片段
+func ImageFilePart(path, detail string) (ContentPart, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ContentPart{}, fmt.Errorf("failed to read image file: %w", err)
+	}
+
+	mimeType := mime.TypeByExtension(strings.ToLower(filepath.Ext(path)))
+	if !strings.HasPrefix(mimeType, "image/") {
+		mimeType = http.DetectContentType(data)
+	}
+	if !strings.HasPrefix(mimeType, "image/") {
+		return ContentPart{}, fmt.Errorf("file %s is not an image (%s)", path, mimeType)
+	}
+
+	return ImageDataPart(data, mimeType, detail), nil
+}
+
+// ImagePart 根据引用创建图片片段：http(s) 与 data URL 直接引用，其他视为本地文件路径
+func ImagePart(ref, detail string) (ContentPart, error) {
+	if strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://") || strings.HasPrefix(ref, "data:") {
+		return ImageURLPart(ref, detail), nil
+	}
+	return ImageFilePart(ref, detail)
+}
+
+// CreateMultimodalUserMessage 创建包含文本和图片片段的用户消息
+func CreateMultimodalUserMessage(text string, parts ...ContentPart) Message {
+	return Message{
+		Role:    "user",
+		Content: text,
+		Parts:   parts,
+	}
+}
+
+// HasImages 判断消息是否包含图片片段
+func (m Message) HasImages() bool {
+	for _, part := range m.Parts {
+		if part.Type == ContentPartImageURL {
+			return true
+		}
+	}
+	return false
+}
+
+// ContentParts 返回消息的完整内容片段：Content 作为首个文本片段，其后为 Parts
+func (m Message) ContentParts() []ContentPart {
+	parts := make([]ContentPart, 0, len(m.Parts)+1)
+	if m.Content != "" {
+		parts = append(parts, TextPart(m.Content))
+	}
+	return append(parts, m.Parts...)
+}
+
+// messageJSON 用于序列化的消息结构，content 可以是字符串或片段数组
+type messageJSON struct {
+	Role             string          `json:"role"`
+	Content          json.RawMessage `json:"content"`
+	Name             string          `json:"name,omitempty"`
+	ToolCalls        []ToolCall      `json:"tool_calls,omitempty"`
+	ToolCallID       string          `json:"tool_call_id,omitempty"`
+	ReasoningContent string          `json:"reasoning_content,omitempty"`
+	Reasoning        string          `json:"reasoning,omitempty"` // 部分 OpenAI 兼容网关使用的字段名
+}
+
+// MarshalJSON 序列化消息；包含片段时 content 输出为 OpenAI 兼容的片段数组
+func (m Message) MarshalJSON() ([]byte, error) {
+	var content any = m.Content
+	if len(m.Parts) > 0 {
+		content = m.ContentParts()
+	}
+
+	data, err := json.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(messageJSON{
+		Role:             m.Role,
+		Content:          data,
+		Name:             m.Name,
+		ToolCalls:        m.ToolCalls,
+		ToolCallID:       m.ToolCallID,
+		ReasoningContent: m.ReasoningContent,
+	})
+}
+
+// UnmarshalJSON 反序列化消息，兼容字符串和片段数组两种 content 形式
+func (m *Message) UnmarshalJSON(data []byte) error {
+	var raw messageJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*m = Message{
+		Role:             raw.Role,
+		Name:             raw.Name,
+		ToolCalls:        raw.ToolCalls,
+		ToolCallID:       raw.ToolCallID,
+		ReasoningContent: raw.ReasoningContent,
+	}
+	if m.ReasoningContent == "" {
+		m.ReasoningContent = raw.Reasoning
+	}
+
+	content := strings.TrimSpace(string(raw.Content))
+	switch {
+	case content == "" || content == "null":
+		return nil
+	case strings.HasPrefix(content, "["):
+		var parts []ContentPart
+		if err := json.Unmarshal(raw.Content, &parts); err != nil {
+			return fmt.Errorf("failed to unmarshal content parts: %w", err)
+		}
+		// 首个文本片段还原为 Content，其余保留为片段
+		if len(parts) > 0 && parts[0].Type == ContentPartText {
+			m.Content = parts[0].Text
+			parts = parts[1:]
+		}
+		if len(parts) > 0 {
+			m.Parts = parts
+		}
+		return nil
+	default:
+		return json.Unmarshal(raw.Content, &m.Content)
+	}
+}
@@ -25,6 +25,10 @@ func NewOpenAIClient(config *Config) *OpenAIClient {
 		config = DefaultConfig()
 	}
 
+	if config.ContextWindow > 0 {
+		SetContextWindow(config.Model, config.ContextWindow)
+	}
+
 	timeout := time.Duration(config.Timeout) * time.Second
 	if timeout == 0 {
 		timeout = 30 * time.Second
@@ -51,8 +55,8 @@ func (c *OpenAIClient) Chat(ctx context.Context, req *ChatRequest) (*ChatRespons
 		req.MaxTokens = c.config.MaxTokens
 	}
 
-	// 序列化请求
-	reqBody, err := json.Marshal(req)
+	// 序列化请求（推理内容不得回传给提供方）
+	reqBody, err := json.Marshal(withoutReasoning(req))
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
@@ -131,8 +135,8 @@ func (c *OpenAIClient) ChatStream(ctx context.Context, req *ChatRequest) (<-chan
 		req.MaxTokens = c.config.MaxTokens
 	}
 
-	// 序列化请求
-	reqBody, err := json.Marshal(req)
+	// 序列化请求（推理内容不得回传给提供方）
+	reqBody, err := json.Marshal(withoutReasoning(req))
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
@@ -202,6 +206,28 @@ func (c *OpenAIClient) ChatStream(ctx context.Context, req *ChatRequest) (<-chan
 	return respChan, nil
 }
 
+// withoutReasoning 返回清除了历史消息推理内容的请求副本，不修改调用方的消息
+func withoutReasoning(req *ChatRequest) *ChatRequest {
+	hasReasoning := false
+	for _, msg := range req.Messages {
+		if msg.ReasoningContent != "" {
+			hasReasoning = true
+			break
+		}
+	}
+	if !hasReasoning {
+		return req
+	}
+
+	clean := *req
+	clean.Messages = make([]Message, len(req.Messages))
+	for i, msg := range req.Messages {
+		msg.ReasoningContent = ""
+		clean.Messages[i] = msg
+	}
+	return &clean
+}
+
 // GetModel 获取当前模型
 func (c *OpenAIClient) GetModel() string {
 	return c.config.Model
@@ -0,0 +1,51 @@
+package llm
+
+import (
+	"sort"
+	"strings"
+)
+
+// ModelPrice 表示模型的单价（每 1K token，货币单位由配置决定）
+type ModelPrice struct {
+	Input  float64 `json:"input" mapstructure:"input"`
+	Output float64 `json:"output" mapstructure:"output"`
+}
+
+// PricingTable 模型价格表，键为模型名或模型名前缀
+type PricingTable map[string]ModelPrice
+
+// Lookup 查找模型价格：先精确匹配，再按最长前缀匹配（兼容带日期后缀的模型名）
+func (t PricingTable) Lookup(model string) (ModelPrice, bool) {
+	if len(t) == 0 || model == "" {
+		return ModelPrice{}, false
+	}
+
+	model = strings.ToLower(model)
+	if price, ok := t[model]; ok {
+		return price, true
+	}
+
+	prefixes := make([]string, 0, len(t))
+	for prefix := range t {
+		if strings.HasPrefix(model, strings.ToLower(prefix)) {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	if len(prefixes) == 0 {
+		return ModelPrice{}, false
+	}
+
+	sort.Slice(prefixes, func(i, j int) bool {
+		return len(prefixes[i]) > len(prefixes[j])
+	})
+	return t[prefixes[0]], true
+}
+
+// Cost 计算一次调用的费用，模型不在价格表中时返回 false
+func (t PricingTable) Cost(model string, promptTokens, completionTokens int) (float64, bool) {
+	price, ok := t.Lookup(model)
+	if !ok {
+		return 0, false
+	}
+	return float64(promptTokens)/1000*price.Input + float64(completionTokens)/1000*price.Output, true
+}
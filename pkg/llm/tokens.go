@@ -0,0 +1,207 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// defaultContextWindow 未知模型的默认上下文窗口
+	defaultContextWindow = 8192
+	// messageOverheadTokens 每条消息的格式开销（角色、分隔符等）
+	messageOverheadTokens = 4
+	// replyPrimingTokens 每次请求为助手回复预留的开销
+	replyPrimingTokens = 3
+)
+
+// contextWindows 各模型的上下文窗口（按前缀匹配，最长前缀优先）
+var contextWindows = map[string]int{
+	"gpt-4o":            128000,
+	"gpt-4-turbo":       128000,
+	"gpt-4.1":           1047576,
+	"gpt-4-32k":         32768,
+	"gpt-4":             8192,
+	"gpt-3.5-turbo":     16385,
+	"o1":                200000,
+	"o3":                200000,
+	"deepseek-chat":     65536,
+	"deepseek-reasoner": 65536,
+	"deepseek-coder":    65536,
+	"claude-3":          200000,
+	"claude":            200000,
+	"qwen":              32768,
+	"glm-4":             128000,
+	"moonshot-v1-8k":    8192,
+	"moonshot-v1-32k":   32768,
+	"moonshot-v1-128k":  131072,
+	"llama3":            8192,
+}
+
+var contextWindowsMu sync.RWMutex
+
+// SetContextWindow 设置（或覆盖）模型的上下文窗口大小
+func SetContextWindow(model string, tokens int) {
+	if model == "" || tokens <= 0 {
+		return
+	}
+	contextWindowsMu.Lock()
+	defer contextWindowsMu.Unlock()
+	contextWindows[strings.ToLower(model)] = tokens
+}
+
+// ContextWindow 返回模型的上下文窗口大小，未知模型返回保守的默认值
+func ContextWindow(model string) int {
+	contextWindowsMu.RLock()
+	defer contextWindowsMu.RUnlock()
+
+	model = strings.ToLower(model)
+	if tokens, ok := contextWindows[model]; ok {
+		return tokens
+	}
+
+	prefixes := make([]string, 0, len(contextWindows))
+	for prefix := range contextWindows {
+		if strings.HasPrefix(model, prefix) {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	if len(prefixes) == 0 {
+		return defaultContextWindow
+	}
+
+	sort.Slice(prefixes, func(i, j int) bool {
+		return len(prefixes[i]) > len(prefixes[j])
+	})
+	return contextWindows[prefixes[0]]
+}
+
+// pretokenizePattern 近似 BPE 分词器的预切分规则（缩写、字母串、数字串、标点串、空白）
+var pretokenizePattern = regexp.MustCompile(`'(?:s|t|re|ve|m|ll|d)| ?\p{L}+| ?\p{N}+| ?[^\s\p{L}\p{N}]+|\s+`)
+
+// EstimateTokens 估算文本的 token 数量
+//
+// 估算基于预切分后的片段：拉丁字母约 4 个字符一个 token，数字约 3 位一个 token，
+// 中日韩字符约 1.5 个 token。结果略微偏高，用于预算控制而非计费。
+func EstimateTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+
+	tokens := 0
+	for _, piece := range pretokenizePattern.FindAllString(text, -1) {
+		tokens += estimatePieceTokens(piece)
+	}
+	return tokens
+}
+
+// estimatePieceTokens 估算单个预切分片段的 token 数量
+func estimatePieceTokens(piece string) int {
+	trimmed := strings.TrimLeft(piece, " ")
+	if trimmed == "" {
+		// 纯空白：连续空白通常会被合并
+		return ceilDiv(utf8.RuneCountInString(piece), 8)
+	}
+
+	var cjk, letters, digits, others int
+	for _, r := range trimmed {
+		switch {
+		case isCJK(r):
+			cjk++
+		case unicode.IsLetter(r):
+			letters++
+		case unicode.IsDigit(r):
+			digits++
+		case unicode.IsSpace(r):
+			// 换行等空白与相邻 token 合并
+		default:
+			others++
+		}
+	}
+
+	tokens := ceilDiv(cjk*3, 2) + ceilDiv(letters, 4) + ceilDiv(digits, 3) + ceilDiv(others, 2)
+	if tokens == 0 {
+		tokens = 1
+	}
+	return tokens
+}
+
+// isCJK 判断是否为中日韩字符
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) ||
+		unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) ||
+		unicode.Is(unicode.Hangul, r)
+}
+
+func ceilDiv(a, b int) int {
+	return (a + b - 1) / b
+}
+
+// EstimateMessagesTokens 估算消息列表的 token 数量（含格式开销）
+func EstimateMessagesTokens(messages []Message) int {
+	tokens := replyPrimingTokens
+	for _, msg := range messages {
+		tokens += messageOverheadTokens
+		tokens += EstimateTokens(msg.Content)
+		if msg.Name != "" {
+			tokens += EstimateTokens(msg.Name)
+		}
+		for _, part := range msg.Parts {
+			if part.Type == ContentPartImageURL {
+				tokens += imageTokens
+			} else {
+				tokens += EstimateTokens(part.Text)
+			}
+		}
+		for _, call := range msg.ToolCalls {
+			tokens += EstimateTokens(call.Function.Name) + EstimateTokens(call.Function.Arguments)
+		}
+	}
+	return tokens
+}
+
+// EstimateToolsTokens 估算工具定义的 token 数量
+func EstimateToolsTokens(tools []Tool) int {
+	if len(tools) == 0 {
+		return 0
+	}
+	data, err := json.Marshal(tools)
+	if err != nil {
+		return 0
+	}
+	return EstimateTokens(string(data))
+}
+
+// EstimateRequestTokens 估算整个请求的提示 token 数量
+func EstimateRequestTokens(req *ChatRequest) int {
+	return EstimateMessagesTokens(req.Messages) + EstimateToolsTokens(req.Tools)
+}
+
+// TruncateToTokens 将文本截断到大约 maxTokens 个 token，保留开头和结尾
+func TruncateToTokens(text string, maxTokens int) string {
+	total := EstimateTokens(text)
+	if total <= maxTokens {
+		return text
+	}
+	if maxTokens <= 0 {
+		return ""
+	}
+
+	runes := []rune(text)
+	marker := fmt.Sprintf("\n...[truncated ~%d tokens]...\n", total-maxTokens)
+	keep := len(runes) * (maxTokens - EstimateTokens(marker)) / total
+	if keep <= 0 {
+		return ""
+	}
+
+	// 保留 3/4 开头和 1/4 结尾，结尾往往包含结论或最新数据
+	head := keep * 3 / 4
+	tail := keep - head
+	return string(runes[:head]) + marker + string(runes[len(runes)-tail:])
+}
@@ -0,0 +1,135 @@
+package prompts
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+
+	"openmanus-go/pkg/tool"
+)
+
+// 内置提示模板名称
+const (
+	PlannerSystem   = "planner_system"
+	ReflectorSystem = "reflector_system"
+)
+
+// templateExt 模板文件扩展名
+const templateExt = ".tmpl"
+
+//go:embed templates/*.tmpl
+var embeddedTemplates embed.FS
+
+// Data 模板可用的变量
+type Data struct {
+	Goal   string          // 用户目标
+	Tools  []tool.ToolInfo // 可用工具清单
+	Memory map[string]any  // 记忆摘要
+	Locale string          // 回答使用的语言，空表示不限制
+}
+
+// funcs 模板辅助函数
+var funcs = template.FuncMap{
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"trim":  strings.TrimSpace,
+}
+
+// Loader 提示模板加载器
+//
+// 优先从 dir 目录加载同名 .tmpl 文件，不存在时使用内置模板。解析结果会被缓存。
+type Loader struct {
+	dir   string
+	mu    sync.Mutex
+	cache map[string]*template.Template
+}
+
+// DefaultLoader 仅使用内置模板的加载器
+var DefaultLoader = NewLoader("")
+
+// NewLoader 创建提示模板加载器，dir 为空时只使用内置模板
+func NewLoader(dir string) *Loader {
+	return &Loader{
+		dir:   dir,
+		cache: make(map[string]*template.Template),
+	}
+}
+
+// Names 返回所有内置模板名称
+func Names() []string {
+	entries, err := embeddedTemplates.ReadDir("templates")
+	if err != nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), templateExt))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Source 返回模板来源（覆盖文件路径或 "embedded"）和原始内容
+func (l *Loader) Source(name string) (string, string, error) {
+	if l.dir != "" {
+		path := filepath.Join(l.dir, name+templateExt)
+		data, err := os.ReadFile(path)
+		if err == nil {
+			return path, string(data), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", "", fmt.Errorf("failed to read prompt template %s: %w", path, err)
+		}
+	}
+
+	data, err := embeddedTemplates.ReadFile("templates/" + name + templateExt)
+	if err != nil {
+		return "", "", fmt.Errorf("unknown prompt template: %s", name)
+	}
+	return "embedded", string(data), nil
+}
+
+// Render 渲染指定模板，结果去除首尾空白
+func (l *Loader) Render(name string, data Data) (string, error) {
+	tmpl, err := l.load(name)
+	if err != nil {
+		return "", err
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt template %s: %w", name, err)
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+// load 加载并缓存模板
+func (l *Loader) load(name string) (*template.Template, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if tmpl, ok := l.cache[name]; ok {
+		return tmpl, nil
+	}
+
+	source, text, err := l.Source(name)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse prompt template %s (%s): %w", name, source, err)
+	}
+
+	l.cache[name] = tmpl
+	return tmpl, nil
+}
@@ -14,10 +14,88 @@ type Action struct {
 
 // Observation 表示工具执行的观测结果
 type Observation struct {
-	Tool    string         `json:"tool"`
-	Output  map[string]any `json:"output"`
-	ErrMsg  string         `json:"err_msg,omitempty"`
-	Latency int64          `json:"latency_ms"`
+	Tool    string            `json:"tool"`
+	Status  ObservationStatus `json:"status,omitempty"` // 归一化的结果状态，见 Envelope
+	Output  map[string]any    `json:"output"`
+	ErrMsg  string            `json:"err_msg,omitempty"`
+	Images  []string          `json:"images,omitempty"` // 工具附加的图片（本地路径、URL 或 data URL）
+	Latency int64             `json:"latency_ms"`
+	Cache   string            `json:"cache,omitempty"`   // 结果缓存状态：hit, miss；未经过缓存时为空
+	Wait    int64             `json:"wait_ms,omitempty"` // 在并发或频率限制上排队等待的时间
+
+	// Artifacts 输出过大而转存的工件 ID，Output 中只保留引用和预览
+	Artifacts []string `json:"artifacts,omitempty"`
+
+	// Partial Output 是流式工具在提前停止或出错前报告的部分输出
+	Partial bool `json:"partial,omitempty"`
+
+	// Stopped 工具被提前停止的原因（如部分结果已足以回答目标），未停止时为空
+	Stopped string `json:"stopped,omitempty"`
+
+	// Summary 按工具 OutputSchema 生成的一行输出摘要
+	Summary string `json:"summary,omitempty"`
+}
+
+// ObservationStatus 观测结果的归一化状态
+type ObservationStatus string
+
+const (
+	ObservationOK      ObservationStatus = "ok"
+	ObservationError   ObservationStatus = "error"
+	ObservationPartial ObservationStatus = "partial" // 工具被提前停止，Data 为部分输出
+)
+
+// 工具输出中的元数据字段，不属于归一化后的数据
+const (
+	OutputKeySuccess = "success"
+	OutputKeyError   = "error"
+	OutputKeyLatency = "latency_ms"
+	OutputKeyMeta    = "_meta" // MCP 调用的服务器、工具和时间戳
+)
+
+// ObservationEnvelope 归一化的观测结果，不同工具的 success/error/result 等约定统一为同一结构
+type ObservationEnvelope struct {
+	Status    ObservationStatus `json:"status"`
+	Data      map[string]any    `json:"data,omitempty"`
+	Error     string            `json:"error,omitempty"`
+	Artifacts []string          `json:"artifacts,omitempty"`
+}
+
+// Envelope 返回归一化的观测结果，旧轨迹中没有 Status 时根据错误和部分输出推断
+func (o *Observation) Envelope() ObservationEnvelope {
+	status := o.Status
+	if status == "" {
+		switch {
+		case o.ErrMsg != "":
+			status = ObservationError
+		case o.Partial:
+			status = ObservationPartial
+		default:
+			status = ObservationOK
+		}
+	}
+	return ObservationEnvelope{
+		Status:    status,
+		Data:      OutputData(o.Output),
+		Error:     o.ErrMsg,
+		Artifacts: o.Artifacts,
+	}
+}
+
+// OutputData 返回去除 success、error、latency_ms、_meta 等元数据字段后的输出
+func OutputData(output map[string]any) map[string]any {
+	if output == nil {
+		return nil
+	}
+	data := make(map[string]any, len(output))
+	for key, value := range output {
+		switch key {
+		case OutputKeySuccess, OutputKeyError, OutputKeyLatency, OutputKeyMeta:
+			continue
+		}
+		data[key] = value
+	}
+	return data
 }
 
 // Step 表示执行轨迹中的一个步骤
@@ -26,19 +104,40 @@ type Step struct {
 	Action      Action       `json:"action"`
 	Observation *Observation `json:"observation,omitempty"`
 	Summary     string       `json:"summary,omitempty"`
+	LLMCall     *LLMCall     `json:"llm_call,omitempty"`  // 产生该步骤的规划请求信息
+	Reasoning   string       `json:"reasoning,omitempty"` // 推理模型的思考过程，仅用于审计，不进入后续提示
 	Timestamp   time.Time    `json:"timestamp"`
 }
 
+// LLMCall 表示一次 LLM 调用的元数据
+type LLMCall struct {
+	Model            string  `json:"model"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	CacheHit         bool    `json:"cache_hit,omitempty"`
+	Cost             float64 `json:"cost,omitempty"` // 按价格表计算的费用，缓存命中为 0
+	Latency          int64   `json:"latency_ms"`
+}
+
+// ModelUsage 表示单个模型在一次运行中的累计用量
+type ModelUsage struct {
+	Calls            int     `json:"calls"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	Cost             float64 `json:"cost"`
+}
+
 // Trace 表示完整的执行轨迹
 type Trace struct {
-	Goal        string             `json:"goal"`
-	Steps       []Step             `json:"steps"`
-	Reflections []ReflectionRecord `json:"reflections,omitempty"` // 反思记录历史
-	Scratch     map[string]any     `json:"scratch,omitempty"`
-	Budget      Budget             `json:"budget"`
-	Status      TraceStatus        `json:"status"`
-	CreatedAt   time.Time          `json:"created_at"`
-	UpdatedAt   time.Time          `json:"updated_at"`
+	Goal        string                 `json:"goal"`
+	Steps       []Step                 `json:"steps"`
+	Reflections []ReflectionRecord     `json:"reflections,omitempty"` // 反思记录历史
+	Scratch     map[string]any         `json:"scratch,omitempty"`
+	Budget      Budget                 `json:"budget"`
+	Usage       map[string]*ModelUsage `json:"usage,omitempty"` // 按模型统计的 LLM 用量和费用
+	Status      TraceStatus            `json:"status"`
+	CreatedAt   time.Time              `json:"created_at"`
+	UpdatedAt   time.Time              `json:"updated_at"`
 }
 
 // Budget 表示执行预算限制
@@ -48,6 +147,8 @@ type Budget struct {
 	MaxDuration time.Duration `json:"max_duration,omitempty"`
 	UsedSteps   int           `json:"used_steps"`
 	UsedTokens  int           `json:"used_tokens"`
+	MaxCost     float64       `json:"max_cost,omitempty"`
+	UsedCost    float64       `json:"used_cost,omitempty"`
 	StartTime   time.Time     `json:"start_time"`
 }
 
@@ -129,6 +230,55 @@ func (t *Trace) UpdateSummary(summary string) {
 	}
 }
 
+// UpdateLLMCall 更新最后一个步骤的 LLM 调用信息，并计入运行用量
+func (t *Trace) UpdateLLMCall(call *LLMCall) {
+	if len(t.Steps) > 0 {
+		t.Steps[len(t.Steps)-1].LLMCall = call
+	}
+	t.RecordLLMUsage(call)
+}
+
+// RecordLLMUsage 累计一次 LLM 调用的用量和费用（包括不产生步骤的反思调用）
+func (t *Trace) RecordLLMUsage(call *LLMCall) {
+	if call == nil {
+		return
+	}
+	if t.Usage == nil {
+		t.Usage = make(map[string]*ModelUsage)
+	}
+
+	usage, ok := t.Usage[call.Model]
+	if !ok {
+		usage = &ModelUsage{}
+		t.Usage[call.Model] = usage
+	}
+	usage.Calls++
+	usage.PromptTokens += call.PromptTokens
+	usage.CompletionTokens += call.CompletionTokens
+	usage.Cost += call.Cost
+
+	t.Budget.UsedCost += call.Cost
+}
+
+// TotalUsage 汇总所有模型的用量
+func (t *Trace) TotalUsage() ModelUsage {
+	var total ModelUsage
+	for _, usage := range t.Usage {
+		total.Calls += usage.Calls
+		total.PromptTokens += usage.PromptTokens
+		total.CompletionTokens += usage.CompletionTokens
+		total.Cost += usage.Cost
+	}
+	return total
+}
+
+// UpdateReasoning 更新最后一个步骤的推理内容
+func (t *Trace) UpdateReasoning(reasoning string) {
+	if len(t.Steps) > 0 {
+		t.Steps[len(t.Steps)-1].Reasoning = reasoning
+	}
+}
+
 // AddReflection 添加反思记录
 func (t *Trace) AddReflection(result *ReflectionResult) {
 	reflection := ReflectionRecord{
@@ -159,5 +309,8 @@ func (t *Trace) IsExceededBudget() bool {
 	if t.Budget.MaxDuration > 0 && time.Since(t.Budget.StartTime) >= t.Budget.MaxDuration {
 		return true
 	}
+	if t.Budget.MaxCost > 0 && t.Budget.UsedCost >= t.Budget.MaxCost {
+		return true
+	}
 	return false
 }
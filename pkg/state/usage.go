@@ -0,0 +1,24 @@
+package state
+
+import "context"
+
+// LLMCallRecorder 接收不经过规划器、反思器的附带 LLM 调用（工件摘要、工具检索向量化等）
+type LLMCallRecorder func(call *LLMCall)
+
+// llmCallRecorderKey 上下文中调用记录器的键
+type llmCallRecorderKey struct{}
+
+// WithLLMCallRecorder 将调用记录器放入上下文，Agent 据此把附带调用计入轨迹的用量和费用
+func WithLLMCallRecorder(ctx context.Context, recorder LLMCallRecorder) context.Context {
+	return context.WithValue(ctx, llmCallRecorderKey{}, recorder)
+}
+
+// ReportLLMCall 将附带调用交给上下文中的记录器，没有记录器时忽略
+func ReportLLMCall(ctx context.Context, call *LLMCall) {
+	if call == nil {
+		return
+	}
+	if recorder, ok := ctx.Value(llmCallRecorderKey{}).(LLMCallRecorder); ok && recorder != nil {
+		recorder(call)
+	}
+}
@@ -0,0 +1,400 @@
+package tool
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"openmanus-go/pkg/config"
+	"openmanus-go/pkg/logger"
+	"openmanus-go/pkg/state"
+)
+
+// 大输出被替换为工件引用后，Observation.Output 中使用的字段
+const (
+	OutputKeyArtifactID = "artifact_id"
+	OutputKeyPreview    = "preview"
+	OutputKeySummary    = "summary"
+	OutputKeyTruncated  = "truncated"
+)
+
+// ArtifactToolName 读取工件的内置工具名称，其输出不会再被转存
+const ArtifactToolName = "artifact"
+
+// 默认阈值
+const (
+	defaultArtifactThreshold    = 16 * 1024
+	defaultArtifactPreviewChars = 2000
+	// maxSummaryInputChars 交给摘要器的最大字符数
+	maxSummaryInputChars = 24000
+	// maxInlineScalarLen 替换为引用时保留的顶层标量字段的最大长度
+	maxInlineScalarLen = 200
+)
+
+// ArtifactSummarizer 为大输出生成摘要（通常由 LLM 实现），content 已按 maxSummaryInputChars 截断；
+// 返回的 LLM 调用通过 state.ReportLLMCall 计入调用方的用量和费用
+type ArtifactSummarizer func(ctx context.Context, toolName, content string) (string, *state.LLMCall, error)
+
+// Artifact 存储在磁盘上的一份大工具输出
+type Artifact struct {
+	ID        string    `json:"id"`
+	Tool      string    `json:"tool"`
+	Path      string    `json:"path"`      // 文本视图，用于分页和搜索
+	DataPath  string    `json:"data_path"` // 原始 JSON 输出
+	Size      int       `json:"size"`      // 原始 JSON 字节数
+	Lines     int       `json:"lines"`     // 文本视图行数
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ArtifactOptions 工件存储选项
+type ArtifactOptions struct {
+	Dir            string // 工件根目录，每次运行使用其下的独立子目录
+	ThresholdBytes int    // 输出 JSON 超过该字节数时存为工件
+	PreviewChars   int    // 观测结果中保留的预览字符数
+}
+
+// ArtifactStore 将大工具输出保存到运行目录，观测结果只保留引用和预览
+type ArtifactStore struct {
+	opts   ArtifactOptions
+	runDir string
+
+	mu         sync.Mutex
+	order      []string // 工件 ID，按创建顺序
+	artifacts  map[string]*Artifact
+	summarizer ArtifactSummarizer
+}
+
+// NewArtifactStore 创建工件存储，运行目录在第一次保存时创建
+func NewArtifactStore(opts ArtifactOptions) *ArtifactStore {
+	if opts.Dir == "" {
+		opts.Dir = "./data/artifacts"
+	}
+	if opts.ThresholdBytes <= 0 {
+		opts.ThresholdBytes = defaultArtifactThreshold
+	}
+	if opts.PreviewChars <= 0 {
+		opts.PreviewChars = defaultArtifactPreviewChars
+	}
+	runID := fmt.Sprintf("run_%s_%d", time.Now().Format("20060102_150405"), os.Getpid())
+	return &ArtifactStore{
+		opts:      opts,
+		runDir:    filepath.Join(opts.Dir, runID),
+		artifacts: make(map[string]*Artifact),
+	}
+}
+
+// NewArtifactStoreFromConfig 根据配置创建工件存储，未启用时返回 nil
+func NewArtifactStoreFromConfig(cfg config.ToolArtifactsConfig) *ArtifactStore {
+	if !cfg.Enabled {
+		return nil
+	}
+	return NewArtifactStore(ArtifactOptions{
+		Dir:            cfg.Dir,
+		ThresholdBytes: cfg.ThresholdBytes,
+		PreviewChars:   cfg.PreviewChars,
+	})
+}
+
+// SetSummarizer 设置摘要器，设置后替换为引用的观测结果附带摘要
+func (s *ArtifactStore) SetSummarizer(summarizer ArtifactSummarizer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.summarizer = summarizer
+}
+
+// RunDir 返回本次运行的工件目录
+func (s *ArtifactStore) RunDir() string {
+	return s.runDir
+}
+
+// Check 检查工件根目录是否可写
+func (s *ArtifactStore) Check() error {
+	if err := os.MkdirAll(s.opts.Dir, 0755); err != nil {
+		return fmt.Errorf("failed to create artifact dir: %w", err)
+	}
+	probe, err := os.CreateTemp(s.opts.Dir, ".probe_*")
+	if err != nil {
+		return fmt.Errorf("artifact dir is not writable: %w", err)
+	}
+	probe.Close()
+	return os.Remove(probe.Name())
+}
+
+// Offload 在输出超过阈值时将其存为工件，返回替换后的输出和工件 ID
+//
+// 替换后的输出包含工件 ID、大小、预览（以及可选的摘要），并保留原输出中的简短顶层标量字段
+// （如 success、count），便于规划器判断结果。未超过阈值或 store 为 nil 时原样返回。
+func (s *ArtifactStore) Offload(ctx context.Context, toolName string, output map[string]any) (map[string]any, string, error) {
+	if s == nil || output == nil || toolName == ArtifactToolName {
+		return output, "", nil
+	}
+	data, err := json.Marshal(output)
+	if err != nil || len(data) <= s.opts.ThresholdBytes {
+		return output, "", nil
+	}
+
+	text := renderArtifactText(output)
+	artifact, err := s.save(toolName, data, text)
+	if err != nil {
+		return output, "", err
+	}
+
+	replaced := map[string]any{
+		OutputKeyArtifactID: artifact.ID,
+		"artifact_size":     artifact.Size,
+		"artifact_lines":    artifact.Lines,
+		OutputKeyPreview:    truncateRunes(text, s.opts.PreviewChars),
+		OutputKeyTruncated:  true,
+		"note": fmt.Sprintf("Output too large (%d bytes); full result stored as artifact %s. "+
+			"Use the artifact tool (operation read or grep) to inspect it.", artifact.Size, artifact.ID),
+	}
+	for key, value := range output {
+		if isInlineScalar(value) {
+			replaced[key] = value
+		}
+	}
+
+	s.mu.Lock()
+	summarizer := s.summarizer
+	s.mu.Unlock()
+	if summarizer != nil {
+		summary, call, err := summarizer(ctx, toolName, truncateRunes(text, maxSummaryInputChars))
+		state.ReportLLMCall(ctx, call)
+		if err != nil {
+			logger.Warnw("tool.artifact.summarize_failed", "tool", toolName, "artifact", artifact.ID, "error", err)
+		} else if summary != "" {
+			replaced[OutputKeySummary] = summary
+		}
+	}
+
+	logger.Infof("📦 [ARTIFACT] Stored %d bytes from %s as %s", artifact.Size, toolName, artifact.ID)
+	logger.Debugw("tool.artifact.store", "tool", toolName, "artifact", artifact.ID, "size", artifact.Size, "path", artifact.Path)
+	return replaced, artifact.ID, nil
+}
+
+// save 将原始 JSON 和文本视图写入运行目录
+func (s *ArtifactStore) save(toolName string, data []byte, text string) (*Artifact, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(s.runDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create artifact directory: %w", err)
+	}
+
+	id := fmt.Sprintf("%s_%03d", unsafePathChars.ReplaceAllString(toolName, "_"), len(s.order)+1)
+	artifact := &Artifact{
+		ID:        id,
+		Tool:      toolName,
+		Path:      filepath.Join(s.runDir, id+".txt"),
+		DataPath:  filepath.Join(s.runDir, id+".json"),
+		Size:      len(data),
+		Lines:     strings.Count(text, "\n") + 1,
+		CreatedAt: time.Now(),
+	}
+	if err := os.WriteFile(artifact.DataPath, data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write artifact: %w", err)
+	}
+	if err := os.WriteFile(artifact.Path, []byte(text), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write artifact: %w", err)
+	}
+	s.artifacts[id] = artifact
+	s.order = append(s.order, id)
+	return artifact, nil
+}
+
+// Get 返回指定工件
+func (s *ArtifactStore) Get(id string) (*Artifact, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	artifact, ok := s.artifacts[id]
+	if !ok {
+		return nil, fmt.Errorf("artifact not found: %s", id)
+	}
+	return artifact, nil
+}
+
+// List 返回本次运行的全部工件（按创建顺序）
+func (s *ArtifactStore) List() []Artifact {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := make([]Artifact, 0, len(s.order))
+	for _, id := range s.order {
+		list = append(list, *s.artifacts[id])
+	}
+	return list
+}
+
+// ArtifactPage 工件文本视图的一页
+type ArtifactPage struct {
+	ID         string `json:"id"`
+	StartLine  int    `json:"start_line"` // 从 1 开始
+	EndLine    int    `json:"end_line"`
+	TotalLines int    `json:"total_lines"`
+	Content    string `json:"content"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// Read 读取从 startLine（从 1 开始）起最多 limit 行，单页内容超过 maxChars 时提前截止
+func (s *ArtifactStore) Read(id string, startLine, limit, maxChars int) (*ArtifactPage, error) {
+	artifact, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if startLine < 1 {
+		startLine = 1
+	}
+
+	page := &ArtifactPage{ID: id, StartLine: startLine, TotalLines: artifact.Lines}
+	var content strings.Builder
+	err = scanArtifact(artifact.Path, func(lineNo int, line string) bool {
+		if lineNo < startLine {
+			return true
+		}
+		if lineNo >= startLine+limit || (maxChars > 0 && content.Len() > 0 && content.Len()+len(line) > maxChars) {
+			page.HasMore = true
+			return false
+		}
+		content.WriteString(line)
+		content.WriteString("\n")
+		page.EndLine = lineNo
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	page.Content = content.String()
+	if page.EndLine < artifact.Lines && page.EndLine >= startLine {
+		page.HasMore = true
+	}
+	return page, nil
+}
+
+// ArtifactMatch 工件中的一处匹配
+type ArtifactMatch struct {
+	Line    int    `json:"line"`
+	Text    string `json:"text"`
+	Context string `json:"context,omitempty"`
+}
+
+// Grep 在工件文本视图中按正则搜索，返回最多 maxMatches 处匹配（context 为前后附带的行数）
+func (s *ArtifactStore) Grep(id, pattern string, ignoreCase bool, contextLines, maxMatches int) ([]ArtifactMatch, bool, error) {
+	artifact, err := s.Get(id)
+	if err != nil {
+		return nil, false, err
+	}
+	if ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	var lines []string
+	if err := scanArtifact(artifact.Path, func(_ int, line string) bool {
+		lines = append(lines, line)
+		return true
+	}); err != nil {
+		return nil, false, err
+	}
+
+	var matches []ArtifactMatch
+	for i, line := range lines {
+		if !re.MatchString(line) {
+			continue
+		}
+		if len(matches) >= maxMatches {
+			return matches, true, nil
+		}
+		match := ArtifactMatch{Line: i + 1, Text: truncateRunes(line, 500)}
+		if contextLines > 0 {
+			from, to := max(0, i-contextLines), min(len(lines), i+contextLines+1)
+			match.Context = strings.Join(lines[from:to], "\n")
+		}
+		matches = append(matches, match)
+	}
+	return matches, false, nil
+}
+
+// scanArtifact 逐行读取工件文本，fn 返回 false 时停止
+func scanArtifact(path string, fn func(lineNo int, line string) bool) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open artifact: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		if !fn(lineNo, scanner.Text()) {
+			return nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read artifact: %w", err)
+	}
+	return nil
+}
+
+// renderArtifactText 将输出渲染为便于分页和搜索的文本：
+// 字符串字段原样输出（保留换行），其他字段输出为缩进 JSON，每个字段以 "## 字段名" 开头
+func renderArtifactText(output map[string]any) string {
+	keys := make([]string, 0, len(output))
+	for key := range output {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for i, key := range keys {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("## " + key + "\n")
+		switch v := output[key].(type) {
+		case string:
+			b.WriteString(v)
+		default:
+			data, err := json.MarshalIndent(v, "", "  ")
+			if err != nil {
+				b.WriteString(fmt.Sprintf("%v", v))
+			} else {
+				b.Write(data)
+			}
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// isInlineScalar 判断顶层字段是否足够短，可以保留在替换后的输出中
+func isInlineScalar(value any) bool {
+	switch v := value.(type) {
+	case bool, float64, float32, int, int64, int32, nil:
+		return true
+	case string:
+		return len(v) <= maxInlineScalarLen
+	}
+	return false
+}
+
+// truncateRunes 按字符截断文本，不破坏 UTF-8 编码
+func truncateRunes(text string, maxChars int) string {
+	if maxChars <= 0 || utf8.RuneCountInString(text) <= maxChars {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:maxChars]) + "..."
+}
@@ -0,0 +1,165 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+
+	"openmanus-go/pkg/tool"
+)
+
+// 分页和搜索的默认值与上限
+const (
+	artifactDefaultLines   = 100
+	artifactMaxLines       = 500
+	artifactMaxPageChars   = 8000
+	artifactDefaultMatches = 20
+	artifactMaxMatches     = 200
+)
+
+// ArtifactTool 读取和搜索被转存为工件的大工具输出
+type ArtifactTool struct {
+	*tool.BaseTool
+	store *tool.ArtifactStore
+}
+
+// NewArtifactTool 创建工件工具
+func NewArtifactTool(store *tool.ArtifactStore) *ArtifactTool {
+	inputSchema := tool.CreateJSONSchema("object", map[string]any{
+		"operation":   tool.StringProperty("操作类型：read（分页读取）, grep（正则搜索）, list（列出本次运行的工件）"),
+		"id":          tool.StringProperty("工件 ID（观测结果中的 artifact_id），read 和 grep 必填"),
+		"offset":      tool.NumberProperty("read 的起始行号（从 1 开始），默认 1"),
+		"limit":       tool.NumberProperty(fmt.Sprintf("read 的最大行数，默认 %d，最大 %d", artifactDefaultLines, artifactMaxLines)),
+		"pattern":     tool.StringProperty("grep 的正则表达式"),
+		"ignore_case": tool.BooleanProperty("grep 是否忽略大小写"),
+		"context":     tool.NumberProperty("grep 每处匹配前后附带的行数，默认 0"),
+		"max_matches": tool.NumberProperty(fmt.Sprintf("grep 最多返回的匹配数，默认 %d", artifactDefaultMatches)),
+	}, []string{"operation"})
+
+	outputSchema := tool.CreateJSONSchema("object", map[string]any{
+		"success":     tool.BooleanProperty("操作是否成功"),
+		"content":     tool.StringProperty("读取的内容"),
+		"start_line":  tool.NumberProperty("起始行号"),
+		"end_line":    tool.NumberProperty("结束行号"),
+		"total_lines": tool.NumberProperty("总行数"),
+		"has_more":    tool.BooleanProperty("是否还有后续内容"),
+		"matches":     tool.ArrayProperty("匹配结果", tool.ObjectProperty("匹配", nil)),
+		"artifacts":   tool.ArrayProperty("工件列表", tool.ObjectProperty("工件", nil)),
+		"error":       tool.StringProperty("错误信息"),
+	}, []string{"success"})
+
+	baseTool := tool.NewBaseTool(
+		tool.ArtifactToolName,
+		"读取或搜索因过大而被转存的工具输出。观测结果包含 artifact_id 时，用 read 分页查看完整内容，或用 grep 查找需要的部分",
+		inputSchema,
+		outputSchema,
+	)
+
+	return &ArtifactTool{
+		BaseTool: baseTool,
+		store:    store,
+	}
+}
+
+// Invoke 执行工件操作
+func (a *ArtifactTool) Invoke(ctx context.Context, args map[string]any) (map[string]any, error) {
+	if a.store == nil {
+		return a.errorResult("artifact store is not enabled"), nil
+	}
+
+	operation, _ := args["operation"].(string)
+	id, _ := args["id"].(string)
+
+	switch operation {
+	case "list":
+		return map[string]any{
+			"success":   true,
+			"artifacts": a.store.List(),
+		}, nil
+	case "read":
+		if id == "" {
+			return a.errorResult("id is required"), nil
+		}
+		offset, _ := args["offset"].(float64)
+		return a.read(id, int(offset), clampInt(args["limit"], artifactDefaultLines, artifactMaxLines))
+	case "grep":
+		if id == "" {
+			return a.errorResult("id is required"), nil
+		}
+		pattern, _ := args["pattern"].(string)
+		if pattern == "" {
+			return a.errorResult("pattern is required"), nil
+		}
+		ignoreCase, _ := args["ignore_case"].(bool)
+		contextLines, _ := args["context"].(float64)
+		return a.grep(id, pattern, ignoreCase, int(contextLines), clampInt(args["max_matches"], artifactDefaultMatches, artifactMaxMatches))
+	default:
+		return a.errorResult(fmt.Sprintf("unsupported operation: %s", operation)), nil
+	}
+}
+
+// Ping 检查工件目录是否可写
+func (a *ArtifactTool) Ping(ctx context.Context) (tool.HealthStatus, error) {
+	if a.store == nil {
+		return tool.HealthStatus{}, fmt.Errorf("artifact store is not enabled")
+	}
+	if err := a.store.Check(); err != nil {
+		return tool.HealthStatus{}, err
+	}
+	return tool.HealthStatus{Detail: "run dir " + a.store.RunDir()}, nil
+}
+
+// read 分页读取工件
+func (a *ArtifactTool) read(id string, offset, limit int) (map[string]any, error) {
+	page, err := a.store.Read(id, offset, limit, artifactMaxPageChars)
+	if err != nil {
+		return a.errorResult(err.Error()), nil
+	}
+
+	result := map[string]any{
+		"success":     true,
+		"content":     page.Content,
+		"start_line":  page.StartLine,
+		"end_line":    page.EndLine,
+		"total_lines": page.TotalLines,
+		"has_more":    page.HasMore,
+	}
+	if page.HasMore {
+		result["next_offset"] = page.EndLine + 1
+	}
+	return result, nil
+}
+
+// grep 搜索工件
+func (a *ArtifactTool) grep(id, pattern string, ignoreCase bool, contextLines, maxMatches int) (map[string]any, error) {
+	matches, more, err := a.store.Grep(id, pattern, ignoreCase, contextLines, maxMatches)
+	if err != nil {
+		return a.errorResult(err.Error()), nil
+	}
+
+	return map[string]any{
+		"success":  true,
+		"matches":  matches,
+		"count":    len(matches),
+		"has_more": more,
+	}, nil
+}
+
+// errorResult 创建错误结果
+func (a *ArtifactTool) errorResult(message string) map[string]any {
+	return map[string]any{
+		"success": false,
+		"error":   message,
+	}
+}
+
+// clampInt 读取数字参数，缺省或非正数时使用默认值，并限制最大值
+func clampInt(value any, defaultValue, maxValue int) int {
+	n, ok := value.(float64)
+	if !ok || n <= 0 {
+		return defaultValue
+	}
+	if int(n) > maxValue {
+		return maxValue
+	}
+	return int(n)
+}
@@ -3,6 +3,8 @@ package builtin
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -12,6 +14,9 @@ import (
 	"github.com/go-rod/rod/lib/launcher"
 )
 
+// screenshotDir 截图保存目录
+const screenshotDir = "./workspace/screenshots"
+
 // BrowserTool 浏览器自动化工具
 type BrowserTool struct {
 	*tool.BaseTool
@@ -349,24 +354,33 @@ func (b *BrowserTool) screenshot(ctx context.Context) (map[string]any, error) {
 	default:
 	}
 
-	page := b.browser.MustPage()
-	defer page.Close()
-
-	// 生成截图文件名
-	filename := fmt.Sprintf("screenshot_%d.png", time.Now().Unix())
+	// 优先截取当前页面，没有当前页面时截取空白页
+	page := b.currentPage
+	if page == nil {
+		page = b.browser.MustPage()
+		defer page.Close()
+	}
 
 	data, err := page.Screenshot(true, nil)
 	if err != nil {
 		return b.errorResult(fmt.Sprintf("screenshot failed: %v", err)), nil
 	}
 
-	// 这里可以保存到文件或返回 base64 数据
-	// 为简化，这里只返回数据长度
+	// 保存截图文件，路径通过 images 字段附加到观测结果，供视觉模型查看
+	if err := os.MkdirAll(screenshotDir, 0755); err != nil {
+		return b.errorResult(fmt.Sprintf("failed to create screenshot directory: %v", err)), nil
+	}
+	filename := filepath.Join(screenshotDir, fmt.Sprintf("screenshot_%d.png", time.Now().UnixNano()))
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		return b.errorResult(fmt.Sprintf("failed to save screenshot: %v", err)), nil
+	}
+
 	return map[string]any{
-		"success":    true,
-		"result":     "Screenshot taken successfully",
-		"screenshot": filename,
-		"size":       len(data),
+		"success":            true,
+		"result":             "Screenshot taken successfully",
+		"screenshot":         filename,
+		"size":               len(data),
+		tool.OutputKeyImages: []string{filename},
 	}, nil
 }
 
@@ -465,6 +479,19 @@ func (b *BrowserTool) errorResult(message string) map[string]any {
 	}
 }
 
+// Ping 检查浏览器连接，返回浏览器版本
+func (b *BrowserTool) Ping(ctx context.Context) (tool.HealthStatus, error) {
+	if b.browser == nil {
+		return tool.HealthStatus{}, fmt.Errorf("browser is not connected")
+	}
+	start := time.Now()
+	version, err := b.browser.Context(ctx).Version()
+	if err != nil {
+		return tool.HealthStatus{}, fmt.Errorf("browser is not responding: %w", err)
+	}
+	return tool.HealthStatus{Latency: time.Since(start), Detail: version.Product}, nil
+}
+
 // Close 关闭浏览器
 func (b *BrowserTool) Close() error {
 	if b.browser != nil {
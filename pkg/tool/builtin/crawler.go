@@ -71,8 +71,18 @@ func NewCrawlerTool(userAgent string, allowedDomains, blockedDomains []string) *
 	}
 }
 
+// Ping 爬虫工具不依赖外部服务，始终可用
+func (c *CrawlerTool) Ping(ctx context.Context) (tool.HealthStatus, error) {
+	return tool.HealthStatus{Detail: noDependencyDetail}, nil
+}
+
 // Invoke 执行爬虫操作
 func (c *CrawlerTool) Invoke(ctx context.Context, args map[string]any) (map[string]any, error) {
+	return c.InvokeStream(ctx, args, nil)
+}
+
+// InvokeStream 执行爬虫操作，crawl 每抓取一个页面报告一次进度和已抓取的页面
+func (c *CrawlerTool) InvokeStream(ctx context.Context, args map[string]any, report tool.ProgressReporter) (map[string]any, error) {
 	operation, ok := args["operation"].(string)
 	if !ok {
 		return c.errorResult("operation is required"), nil
@@ -89,7 +99,7 @@ func (c *CrawlerTool) Invoke(ctx context.Context, args map[string]any) (map[stri
 		depth, _ := args["depth"].(float64)
 		maxPages, _ := args["max_pages"].(float64)
 		followLinks, _ := args["follow_links"].(bool)
-		return c.crawl(ctx, startURL, int(depth), int(maxPages), followLinks)
+		return c.crawl(ctx, startURL, int(depth), int(maxPages), followLinks, report)
 	case "extract_links":
 		url, _ := args["url"].(string)
 		return c.extractLinks(ctx, url)
@@ -171,7 +181,7 @@ func (c *CrawlerTool) scrape(ctx context.Context, targetURL, selector, attribute
 }
 
 // crawl 爬取多个页面
-func (c *CrawlerTool) crawl(ctx context.Context, startURL string, depth, maxPages int, followLinks bool) (map[string]any, error) {
+func (c *CrawlerTool) crawl(ctx context.Context, startURL string, depth, maxPages int, followLinks bool, report tool.ProgressReporter) (map[string]any, error) {
 	if startURL == "" {
 		return c.errorResult("url is required"), nil
 	}
@@ -199,6 +209,13 @@ func (c *CrawlerTool) crawl(ctx context.Context, startURL string, depth, maxPage
 	var allLinks []string
 	visitedCount := 0
 
+	// 上下文取消（超时或被提前停止）后不再发起新请求
+	collector.OnRequest(func(r *colly.Request) {
+		if ctx.Err() != nil {
+			r.Abort()
+		}
+	})
+
 	// 页面访问回调
 	collector.OnHTML("html", func(e *colly.HTMLElement) {
 		if visitedCount >= maxPages {
@@ -226,6 +243,15 @@ func (c *CrawlerTool) crawl(ctx context.Context, startURL string, depth, maxPage
 		pages = append(pages, page)
 		visitedCount++
 
+		if report != nil {
+			report(tool.Progress{
+				Message: fmt.Sprintf("Crawled %s", e.Request.URL.String()),
+				Current: visitedCount,
+				Total:   maxPages,
+				Partial: crawlResult(startURL, append([]map[string]any(nil), pages...), append([]string(nil), allLinks...)),
+			})
+		}
+
 		// 如果需要跟随链接且未达到深度限制
 		if followLinks && len(pageLinks) > 0 && visitedCount < maxPages {
 			for _, link := range pageLinks {
@@ -247,14 +273,22 @@ func (c *CrawlerTool) crawl(ctx context.Context, startURL string, depth, maxPage
 	}
 
 	collector.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return crawlResult(startURL, pages, allLinks), nil
+}
 
+// crawlResult 构建 crawl 操作的输出，也用作进度报告中的部分输出
+func crawlResult(startURL string, pages []map[string]any, allLinks []string) map[string]any {
 	return map[string]any{
 		"success":     true,
 		"result":      fmt.Sprintf("Crawled %d pages starting from %s", len(pages), startURL),
 		"pages":       pages,
 		"pages_count": len(pages),
 		"all_links":   allLinks,
-	}, nil
+	}
 }
 
 // extractLinks 提取页面中的所有链接
@@ -427,6 +461,11 @@ func (c *CrawlerTool) checkDomain(targetURL string) error {
 	return nil
 }
 
+// CachePolicy 爬虫的所有操作都是只读的，结果可缓存
+func (c *CrawlerTool) CachePolicy(args map[string]any) (time.Duration, bool) {
+	return 10 * time.Minute, true
+}
+
 // errorResult 创建错误结果
 func (c *CrawlerTool) errorResult(message string) map[string]any {
 	return map[string]any{
@@ -27,6 +27,11 @@ func NewDirectAnswerTool() *DirectAnswerTool {
 	}
 }
 
+// Ping 直接回答工具不依赖外部服务，始终可用
+func (t *DirectAnswerTool) Ping(ctx context.Context) (tool.HealthStatus, error) {
+	return tool.HealthStatus{Detail: noDependencyDetail}, nil
+}
+
 // Invoke 执行直接回答
 func (t *DirectAnswerTool) Invoke(ctx context.Context, args map[string]any) (map[string]any, error) {
 	answer, ok := args["answer"].(string)
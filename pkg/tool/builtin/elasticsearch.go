@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"openmanus-go/pkg/tool"
 
@@ -12,6 +13,9 @@ import (
 	"github.com/elastic/go-elasticsearch/v8/esapi"
 )
 
+// esBulkBatchSize bulk 操作每批提交的文档数，每批完成后报告一次进度
+const esBulkBatchSize = 500
+
 // ElasticsearchTool Elasticsearch 搜索引擎工具
 type ElasticsearchTool struct {
 	*tool.BaseTool
@@ -45,6 +49,7 @@ func NewElasticsearchTool(addresses []string, username, password string) (*Elast
 		"version":      tool.NumberProperty("文档版本"),
 		"created":      tool.BooleanProperty("是否新创建"),
 		"acknowledged": tool.BooleanProperty("索引操作是否被确认"),
+		"processed":    tool.NumberProperty("bulk 操作已提交的文档数"),
 		"error":        tool.StringProperty("错误信息"),
 	}, []string{"success"})
 
@@ -86,6 +91,12 @@ func NewElasticsearchTool(addresses []string, username, password string) (*Elast
 
 // Invoke 执行 Elasticsearch 操作
 func (es *ElasticsearchTool) Invoke(ctx context.Context, args map[string]any) (map[string]any, error) {
+	return es.InvokeStream(ctx, args, nil)
+}
+
+// InvokeStream 执行 Elasticsearch 操作，bulk 操作每提交一批文档报告一次进度；
+// bulk 是写操作，进度不附带部分输出，避免被提前停止而留下写了一半的索引
+func (es *ElasticsearchTool) InvokeStream(ctx context.Context, args map[string]any, report tool.ProgressReporter) (map[string]any, error) {
 	operation, ok := args["operation"].(string)
 	if !ok {
 		return es.errorResult("operation is required"), nil
@@ -131,7 +142,7 @@ func (es *ElasticsearchTool) Invoke(ctx context.Context, args map[string]any) (m
 		return es.putMapping(ctx, index, mapping)
 	case "bulk":
 		documents := args["documents"]
-		return es.bulkOperation(ctx, index, documents)
+		return es.bulkOperation(ctx, index, documents, report)
 	default:
 		return es.errorResult(fmt.Sprintf("unsupported operation: %s", operation)), nil
 	}
@@ -523,8 +534,8 @@ func (es *ElasticsearchTool) putMapping(ctx context.Context, index string, mappi
 	}, nil
 }
 
-// bulkOperation 批量操作
-func (es *ElasticsearchTool) bulkOperation(ctx context.Context, index string, documents any) (map[string]any, error) {
+// bulkOperation 批量操作，按 esBulkBatchSize 分批提交
+func (es *ElasticsearchTool) bulkOperation(ctx context.Context, index string, documents any, report tool.ProgressReporter) (map[string]any, error) {
 	if documents == nil {
 		return es.errorResult("documents is required for bulk operation"), nil
 	}
@@ -534,9 +545,52 @@ func (es *ElasticsearchTool) bulkOperation(ctx context.Context, index string, do
 		return es.errorResult("documents must be an array"), nil
 	}
 
+	took := 0
+	errors := false
+	processed := 0
+	for processed < len(docsList) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		end := processed + esBulkBatchSize
+		if end > len(docsList) {
+			end = len(docsList)
+		}
+		batchTook, batchErrors, errResult := es.bulkBatch(ctx, index, docsList[processed:end])
+		if errResult != nil {
+			if processed > 0 {
+				errResult["processed"] = processed
+			}
+			return errResult, nil
+		}
+		took += batchTook
+		errors = errors || batchErrors
+		processed = end
+
+		if report != nil && processed < len(docsList) {
+			report(tool.Progress{
+				Message: fmt.Sprintf("Indexed %d of %d documents", processed, len(docsList)),
+				Current: processed,
+				Total:   len(docsList),
+			})
+		}
+	}
+
+	return map[string]any{
+		"success":   true,
+		"result":    fmt.Sprintf("Bulk operation completed, processed %d documents", len(docsList)),
+		"took":      took,
+		"errors":    errors,
+		"processed": processed,
+	}, nil
+}
+
+// bulkBatch 提交一批文档，返回耗时、是否有文档失败；请求失败时返回错误结果
+func (es *ElasticsearchTool) bulkBatch(ctx context.Context, index string, docs []any) (int, bool, map[string]any) {
 	// 构建批量操作请求体
 	var bulkBody strings.Builder
-	for _, doc := range docsList {
+	for _, doc := range docs {
 		// 索引操作头
 		indexAction := map[string]any{
 			"index": map[string]any{
@@ -559,17 +613,17 @@ func (es *ElasticsearchTool) bulkOperation(ctx context.Context, index string, do
 
 	res, err := req.Do(ctx, es.client)
 	if err != nil {
-		return es.errorResult(fmt.Sprintf("bulk operation failed: %v", err)), nil
+		return 0, false, es.errorResult(fmt.Sprintf("bulk operation failed: %v", err))
 	}
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return es.errorResult(fmt.Sprintf("bulk operation error: %s", res.String())), nil
+		return 0, false, es.errorResult(fmt.Sprintf("bulk operation error: %s", res.String()))
 	}
 
 	var response map[string]any
 	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
-		return es.errorResult(fmt.Sprintf("failed to decode bulk response: %v", err)), nil
+		return 0, false, es.errorResult(fmt.Sprintf("failed to decode bulk response: %v", err))
 	}
 
 	took := 0
@@ -581,13 +635,17 @@ func (es *ElasticsearchTool) bulkOperation(ctx context.Context, index string, do
 	if errorsValue, ok := response["errors"].(bool); ok {
 		errors = errorsValue
 	}
+	return took, errors, nil
+}
 
-	return map[string]any{
-		"success": true,
-		"result":  fmt.Sprintf("Bulk operation completed, processed %d documents", len(docsList)),
-		"took":    took,
-		"errors":  errors,
-	}, nil
+// CachePolicy 只缓存 search 和 get 操作
+func (es *ElasticsearchTool) CachePolicy(args map[string]any) (time.Duration, bool) {
+	operation, _ := args["operation"].(string)
+	switch strings.ToLower(operation) {
+	case "search", "get":
+		return time.Minute, true
+	}
+	return 0, false
 }
 
 // errorResult 创建错误结果
@@ -604,17 +662,31 @@ func (es *ElasticsearchTool) Close() error {
 	return nil
 }
 
-// Ping 测试 Elasticsearch 连接
-func (es *ElasticsearchTool) Ping(ctx context.Context) error {
-	res, err := es.client.Info()
+// Ping 测试 Elasticsearch 连接，返回集群名称和版本
+func (es *ElasticsearchTool) Ping(ctx context.Context) (tool.HealthStatus, error) {
+	start := time.Now()
+	res, err := es.client.Info(es.client.Info.WithContext(ctx))
 	if err != nil {
-		return err
+		return tool.HealthStatus{}, fmt.Errorf("elasticsearch connection failed: %w", err)
 	}
 	defer res.Body.Close()
+	latency := time.Since(start)
 
 	if res.IsError() {
-		return fmt.Errorf("elasticsearch ping failed: %s", res.String())
+		return tool.HealthStatus{}, fmt.Errorf("elasticsearch ping failed: %s", res.String())
 	}
 
-	return nil
+	var info struct {
+		ClusterName string `json:"cluster_name"`
+		Version     struct {
+			Number string `json:"number"`
+		} `json:"version"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&info); err != nil {
+		return tool.HealthStatus{Latency: latency}, nil
+	}
+	return tool.HealthStatus{
+		Latency: latency,
+		Detail:  fmt.Sprintf("cluster %s, version %s", info.ClusterName, info.Version.Number),
+	}, nil
 }
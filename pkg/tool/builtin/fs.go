@@ -90,6 +90,19 @@ func (fs *FileSystemTool) Invoke(ctx context.Context, args map[string]any) (map[
 	}
 }
 
+// Ping 检查允许访问的路径是否存在
+func (fs *FileSystemTool) Ping(ctx context.Context) (tool.HealthStatus, error) {
+	for _, allowedPath := range fs.allowedPaths {
+		if _, err := os.Stat(allowedPath); err != nil {
+			return tool.HealthStatus{}, fmt.Errorf("allowed path is not accessible: %w", err)
+		}
+	}
+	if len(fs.allowedPaths) == 0 {
+		return tool.HealthStatus{Detail: "no path restrictions"}, nil
+	}
+	return tool.HealthStatus{Detail: fmt.Sprintf("%d allowed paths", len(fs.allowedPaths))}, nil
+}
+
 // checkPath 检查路径是否被允许访问
 func (fs *FileSystemTool) checkPath(path string) error {
 	// 获取绝对路径
@@ -297,6 +310,11 @@ func NewFileCopyTool() *FileCopyTool {
 	}
 }
 
+// Ping 文件复制工具不依赖外部服务，始终可用
+func (fc *FileCopyTool) Ping(ctx context.Context) (tool.HealthStatus, error) {
+	return tool.HealthStatus{Detail: noDependencyDetail}, nil
+}
+
 // Invoke 执行文件复制
 func (fc *FileCopyTool) Invoke(ctx context.Context, args map[string]any) (map[string]any, error) {
 	source, ok := args["source"].(string)
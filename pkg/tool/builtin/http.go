@@ -54,6 +54,11 @@ func NewHTTPTool() *HTTPTool {
 	}
 }
 
+// Ping HTTP 工具不依赖外部服务，始终可用
+func (h *HTTPTool) Ping(ctx context.Context) (tool.HealthStatus, error) {
+	return tool.HealthStatus{Detail: noDependencyDetail}, nil
+}
+
 // Invoke 执行 HTTP 请求
 func (h *HTTPTool) Invoke(ctx context.Context, args map[string]any) (map[string]any, error) {
 	// 解析参数
@@ -137,6 +142,16 @@ func (h *HTTPTool) Invoke(ctx context.Context, args map[string]any) (map[string]
 	return result, nil
 }
 
+// CachePolicy 只缓存 GET 和 HEAD 请求
+func (h *HTTPTool) CachePolicy(args map[string]any) (time.Duration, bool) {
+	method, _ := args["method"].(string)
+	switch strings.ToUpper(method) {
+	case "", "GET", "HEAD":
+		return 5 * time.Minute, true
+	}
+	return 0, false
+}
+
 // HTTPClientTool 高级 HTTP 客户端工具
 type HTTPClientTool struct {
 	*tool.BaseTool
@@ -191,6 +206,11 @@ func NewHTTPClientTool() *HTTPClientTool {
 	}
 }
 
+// Ping HTTP 客户端工具不依赖外部服务，始终可用
+func (hc *HTTPClientTool) Ping(ctx context.Context) (tool.HealthStatus, error) {
+	return tool.HealthStatus{Detail: noDependencyDetail}, nil
+}
+
 // Invoke 执行批量 HTTP 请求
 func (hc *HTTPClientTool) Invoke(ctx context.Context, args map[string]any) (map[string]any, error) {
 	start := time.Now()
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 
 	"openmanus-go/pkg/tool"
 
@@ -323,6 +324,31 @@ func (m *MySQLTool) showTables(ctx context.Context) (map[string]any, error) {
 	}, nil
 }
 
+// CachePolicy 只缓存只读操作：describe、show_tables 以及单条 SELECT / SHOW / DESCRIBE / EXPLAIN 查询；
+// 多语句 SQL 可能包含写操作，缓存命中会导致写操作不被执行，因此不缓存
+func (m *MySQLTool) CachePolicy(args map[string]any) (time.Duration, bool) {
+	operation, _ := args["operation"].(string)
+	switch strings.ToLower(operation) {
+	case "describe", "show_tables":
+		return 5 * time.Minute, true
+	case "query":
+		sqlStr, _ := args["sql"].(string)
+		verbs := tool.SQLVerbs(sqlStr)
+		if len(verbs) != 1 {
+			return 0, false
+		}
+		switch verbs[0] {
+		case "SELECT", "SHOW", "DESCRIBE", "DESC", "EXPLAIN":
+			// SELECT ... FOR UPDATE 会加锁，不缓存
+			if strings.Contains(strings.ToUpper(sqlStr), "FOR UPDATE") {
+				return 0, false
+			}
+			return time.Minute, true
+		}
+	}
+	return 0, false
+}
+
 // errorResult 创建错误结果
 func (m *MySQLTool) errorResult(message string) map[string]any {
 	return map[string]any{
@@ -336,7 +362,17 @@ func (m *MySQLTool) Close() error {
 	return m.db.Close()
 }
 
-// Ping 测试数据库连接
-func (m *MySQLTool) Ping(ctx context.Context) error {
-	return m.db.PingContext(ctx)
+// Ping 测试数据库连接，返回服务器版本
+func (m *MySQLTool) Ping(ctx context.Context) (tool.HealthStatus, error) {
+	start := time.Now()
+	if err := m.db.PingContext(ctx); err != nil {
+		return tool.HealthStatus{}, fmt.Errorf("mysql connection failed: %w", err)
+	}
+	latency := time.Since(start)
+
+	var version string
+	if err := m.db.QueryRowContext(ctx, "SELECT VERSION()").Scan(&version); err != nil {
+		return tool.HealthStatus{Latency: latency}, nil
+	}
+	return tool.HealthStatus{Latency: latency, Detail: "MySQL " + version}, nil
 }
@@ -352,6 +352,16 @@ func (r *RedisTool) zrange(ctx context.Context, key string, start, stop int64) (
 	}, nil
 }
 
+// CachePolicy 只缓存读操作；rpop 等会修改数据的操作不可缓存
+func (r *RedisTool) CachePolicy(args map[string]any) (time.Duration, bool) {
+	operation, _ := args["operation"].(string)
+	switch operation {
+	case "get", "exists", "keys", "hget", "zrange":
+		return 30 * time.Second, true
+	}
+	return 0, false
+}
+
 // errorResult 创建错误结果
 func (r *RedisTool) errorResult(message string) map[string]any {
 	return map[string]any{
@@ -366,6 +376,13 @@ func (r *RedisTool) Close() error {
 }
 
 // Ping 测试 Redis 连接
-func (r *RedisTool) Ping(ctx context.Context) error {
-	return r.client.Ping(ctx).Err()
+func (r *RedisTool) Ping(ctx context.Context) (tool.HealthStatus, error) {
+	start := time.Now()
+	if err := r.client.Ping(ctx).Err(); err != nil {
+		return tool.HealthStatus{}, fmt.Errorf("redis connection failed: %w", err)
+	}
+	return tool.HealthStatus{
+		Latency: time.Since(start),
+		Detail:  fmt.Sprintf("redis %s db %d", r.client.Options().Addr, r.client.Options().DB),
+	}, nil
 }
@@ -5,11 +5,21 @@ import (
 	"time"
 
 	"openmanus-go/pkg/config"
+	"openmanus-go/pkg/llm"
+	"openmanus-go/pkg/logger"
 	"openmanus-go/pkg/tool"
 )
 
-// RegisterBuiltinTools 注册所有内置工具
+// noDependencyDetail 不依赖外部服务的工具的健康检查信息
+const noDependencyDetail = "no external dependency"
+
+// RegisterBuiltinTools 注册所有内置工具，并按配置设置注册表的中间件链（权限策略、缓存等），
+// 之后注册到同一注册表的插件、OpenAPI 和 MCP 工具同样受其约束
 func RegisterBuiltinTools(registry *tool.Registry, cfg *config.Config) error {
+	if err := tool.ConfigureMiddlewares(registry, cfg.Tools); err != nil {
+		return fmt.Errorf("failed to configure tool middlewares: %w", err)
+	}
+
 	// 注册 HTTP 工具
 	httpTool := NewHTTPTool()
 	if err := registry.Register(httpTool); err != nil {
@@ -37,6 +47,17 @@ func RegisterBuiltinTools(registry *tool.Registry, cfg *config.Config) error {
 		return fmt.Errorf("failed to register file_copy tool: %w", err)
 	}
 
+	// 注册 shell 工具（如果启用了）
+	if cfg.Tools.Shell.Enabled {
+		shellTool, err := NewShellToolFromConfig(cfg.Tools.Shell)
+		if err != nil {
+			return fmt.Errorf("failed to create shell tool: %w", err)
+		}
+		if err := registry.Register(shellTool); err != nil {
+			return fmt.Errorf("failed to register shell tool: %w", err)
+		}
+	}
+
 	// 注册 Redis 工具（如果配置了）
 	if cfg.Tools.Database.Redis.Addr != "" {
 		redisTool := NewRedisTool(
@@ -107,6 +128,34 @@ func RegisterBuiltinTools(registry *tool.Registry, cfg *config.Config) error {
 		return fmt.Errorf("failed to register stop tool: %w", err)
 	}
 
+	// 输出校验模式
+	validation, err := tool.ParseOutputValidation(cfg.Tools.Output.Validation)
+	if err != nil {
+		return err
+	}
+	registry.SetOutputValidation(validation)
+
+	// 注册工件工具（大输出转存后由 Agent 分页读取或搜索）
+	if artifactStore := tool.NewArtifactStoreFromConfig(cfg.Tools.Artifacts); artifactStore != nil {
+		registry.SetArtifactStore(artifactStore)
+		if err := registry.Register(NewArtifactTool(artifactStore)); err != nil {
+			return fmt.Errorf("failed to register artifact tool: %w", err)
+		}
+	}
+
+	// 注册工具检索工具（工具很多时每步只发送相关工具，其余由 Agent 检索加载）
+	if cfg.Tools.Selection.Enabled {
+		retriever := tool.NewToolRetriever(registry, newSelectionEmbedder(cfg), tool.RetrieverOptions{
+			TopK:          cfg.Tools.Selection.TopK,
+			MinTools:      cfg.Tools.Selection.MinTools,
+			AlwaysInclude: cfg.Tools.Selection.AlwaysInclude,
+		})
+		registry.SetRetriever(retriever)
+		if err := registry.Register(NewSearchToolsTool(retriever)); err != nil {
+			return fmt.Errorf("failed to register search_tools tool: %w", err)
+		}
+	}
+
 	// MCP 工具现在由 Agent 的智能 MCP 系统处理
 	// 不再需要在这里注册旧的 MCP 桥接工具
 
@@ -120,6 +169,7 @@ func GetBuiltinToolsList() []string {
 		"http_client",
 		"fs",
 		"file_copy",
+		"shell",
 		"redis",
 		"mysql",
 		"elasticsearch",
@@ -127,10 +177,25 @@ func GetBuiltinToolsList() []string {
 		"crawler",
 		"direct_answer",
 		"stop",
+		"artifact",
+		"search_tools",
 	}
 }
 
-// CreateToolFromConfig 根据配置创建特定工具
+// newSelectionEmbedder 创建工具检索使用的向量化客户端，未启用或创建失败时返回 nil（只使用关键词匹配）
+func newSelectionEmbedder(cfg *config.Config) llm.Embedder {
+	if !cfg.Tools.Selection.Embeddings {
+		return nil
+	}
+	embedder, err := llm.NewEmbedder(cfg.ToEmbeddingConfig())
+	if err != nil {
+		logger.Warnw("tool.retriever.embedder_failed", "error", err)
+		return nil
+	}
+	return embedder
+}
+
+// CreateToolFromConfig 根据配置创建特定工具（独立实例，不依赖注册表中的共享组件）
 func CreateToolFromConfig(toolName string, cfg *config.Config) (tool.Tool, error) {
 	switch toolName {
 	case "http":
@@ -144,6 +209,11 @@ func CreateToolFromConfig(toolName string, cfg *config.Config) (tool.Tool, error
 		), nil
 	case "file_copy":
 		return NewFileCopyTool(), nil
+	case "shell":
+		if !cfg.Tools.Shell.Enabled {
+			return nil, fmt.Errorf("shell tool is disabled")
+		}
+		return NewShellToolFromConfig(cfg.Tools.Shell)
 	case "redis":
 		if cfg.Tools.Database.Redis.Addr == "" {
 			return nil, fmt.Errorf("redis configuration is missing")
@@ -180,6 +250,17 @@ func CreateToolFromConfig(toolName string, cfg *config.Config) (tool.Tool, error
 		return NewDirectAnswerTool(), nil
 	case "stop":
 		return NewStopTool(), nil
+	case "artifact":
+		// 独立的工件存储：与注册表用于转存大输出的存储不共享，只能读取本实例保存的工件；
+		// Agent 使用的 artifact 工具由 RegisterBuiltinTools 绑定到注册表的存储
+		return NewArtifactTool(tool.NewArtifactStore(tool.ArtifactOptions{
+			Dir:            cfg.Tools.Artifacts.Dir,
+			ThresholdBytes: cfg.Tools.Artifacts.ThresholdBytes,
+			PreviewChars:   cfg.Tools.Artifacts.PreviewChars,
+		})), nil
+	case "search_tools":
+		registry := tool.NewRegistry()
+		return NewSearchToolsTool(tool.NewToolRetriever(registry, nil, tool.RetrieverOptions{})), nil
 	default:
 		return nil, fmt.Errorf("unknown builtin tool: %s", toolName)
 	}
@@ -200,7 +281,11 @@ func ValidateToolConfig(toolName string, cfg *config.Config) error {
 		if len(cfg.Tools.Database.Elasticsearch.Addresses) == 0 {
 			return fmt.Errorf("elasticsearch.addresses is required")
 		}
-	case "http", "http_client", "fs", "file_copy", "browser", "crawler", "direct_answer", "stop":
+	case "shell":
+		if !cfg.Tools.Shell.Enabled {
+			return fmt.Errorf("shell.enabled is false")
+		}
+	case "http", "http_client", "fs", "file_copy", "browser", "crawler", "direct_answer", "stop", "artifact", "search_tools":
 		// 这些工具有默认配置，无需特殊验证
 		return nil
 	default:
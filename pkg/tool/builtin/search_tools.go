@@ -0,0 +1,86 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+
+	"openmanus-go/pkg/tool"
+)
+
+// 检索结果数量的默认值与上限
+const (
+	searchToolsDefaultLimit = 5
+	searchToolsMaxLimit     = 20
+)
+
+// SearchToolsTool 按描述检索当前未提供给模型的工具，命中的工具在后续步骤中可直接调用
+type SearchToolsTool struct {
+	*tool.BaseTool
+	retriever *tool.ToolRetriever
+}
+
+// NewSearchToolsTool 创建工具检索工具
+func NewSearchToolsTool(retriever *tool.ToolRetriever) *SearchToolsTool {
+	inputSchema := tool.CreateJSONSchema("object", map[string]any{
+		"query": tool.StringProperty("要查找的能力描述，如 \"query stock price\" 或 \"发送邮件\""),
+		"limit": tool.NumberProperty(fmt.Sprintf("最多返回的工具数，默认 %d，最大 %d", searchToolsDefaultLimit, searchToolsMaxLimit)),
+	}, []string{"query"})
+
+	outputSchema := tool.CreateJSONSchema("object", map[string]any{
+		"success": tool.BooleanProperty("检索是否成功"),
+		"tools":   tool.ArrayProperty("命中的工具（名称、描述、类型、得分）", tool.ObjectProperty("工具", nil)),
+		"count":   tool.NumberProperty("命中的工具数"),
+		"note":    tool.StringProperty("使用说明"),
+		"error":   tool.StringProperty("错误信息"),
+	}, []string{"success"})
+
+	baseTool := tool.NewBaseTool(
+		tool.SearchToolsName,
+		"检索更多可用工具。当前提供的工具无法完成任务时，用自然语言描述需要的能力，命中的工具会在下一步起可直接调用",
+		inputSchema,
+		outputSchema,
+	)
+
+	return &SearchToolsTool{
+		BaseTool:  baseTool,
+		retriever: retriever,
+	}
+}
+
+// Ping 检索工具只依赖注册表，始终可用
+func (s *SearchToolsTool) Ping(ctx context.Context) (tool.HealthStatus, error) {
+	if s.retriever == nil {
+		return tool.HealthStatus{}, fmt.Errorf("tool selection is not enabled")
+	}
+	return tool.HealthStatus{Detail: fmt.Sprintf("%d tools loaded via search", len(s.retriever.Loaded()))}, nil
+}
+
+// Invoke 检索工具并加载命中的工具
+func (s *SearchToolsTool) Invoke(ctx context.Context, args map[string]any) (map[string]any, error) {
+	if s.retriever == nil {
+		return map[string]any{"success": false, "error": "tool selection is not enabled"}, nil
+	}
+
+	query, _ := args["query"].(string)
+	if query == "" {
+		return map[string]any{"success": false, "error": "query is required"}, nil
+	}
+
+	matches := s.retriever.Search(ctx, query, clampInt(args["limit"], searchToolsDefaultLimit, searchToolsMaxLimit))
+	names := make([]string, 0, len(matches))
+	for _, match := range matches {
+		names = append(names, match.Name)
+	}
+	s.retriever.Load(names...)
+
+	note := "These tools are now available and can be called from the next step."
+	if len(matches) == 0 {
+		note = "No matching tools found. Try a different description."
+	}
+	return map[string]any{
+		"success": true,
+		"tools":   matches,
+		"count":   len(matches),
+		"note":    note,
+	}, nil
+}
@@ -0,0 +1,443 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"openmanus-go/pkg/config"
+	"openmanus-go/pkg/tool"
+)
+
+// shell 工具默认值
+const (
+	defaultShellWorkspace  = "./workspace"
+	defaultShellTimeout    = 30 * time.Second
+	defaultShellMaxOutput  = 64 * 1024
+	defaultShellCPUSeconds = 60
+	defaultShellMemoryMB   = 2048
+
+	// shellErrorTailChars 失败时错误信息中附带的 stderr 末尾字符数
+	shellErrorTailChars = 1000
+)
+
+// shellMetaChars 不加引号时不支持的 shell 运算符（命令不经过 shell 解释）
+const shellMetaChars = "|&;<>$`(){}\n"
+
+// ShellOptions shell 工具选项
+type ShellOptions struct {
+	Workspace       string        // 命令的工作目录根，cwd 和路径参数只能位于其中
+	AllowedCommands []string      // 允许的命令名称，空表示除 DeniedCommands 外全部允许；解释器（python3、node 等）可执行任意代码，不受工作区限制
+	DeniedCommands  []string      // 禁止的命令名称，优先于 AllowedCommands
+	Timeout         time.Duration // 单条命令的最长运行时间
+	MaxOutputBytes  int           // stdout、stderr 各自保留的最大字节数
+	CPUSeconds      int           // CPU 时间上限（RLIMIT_CPU），0 表示不限制
+	MemoryMB        int           // 虚拟内存上限（RLIMIT_AS），0 表示不限制
+	Env             []string      // 额外传递的环境变量：NAME 从当前进程透传，NAME=VALUE 直接设置
+}
+
+// ShellTool 在工作区内运行命令的工具
+type ShellTool struct {
+	*tool.BaseTool
+	opts      ShellOptions
+	workspace string // 解析符号链接后的工作区绝对路径
+	allowed   map[string]bool
+	denied    map[string]bool
+}
+
+// NewShellTool 创建 shell 工具，工作区不存在时自动创建
+func NewShellTool(opts ShellOptions) (*ShellTool, error) {
+	if opts.Workspace == "" {
+		opts.Workspace = defaultShellWorkspace
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultShellTimeout
+	}
+	if opts.MaxOutputBytes <= 0 {
+		opts.MaxOutputBytes = defaultShellMaxOutput
+	}
+
+	if err := os.MkdirAll(opts.Workspace, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create shell workspace: %w", err)
+	}
+	absWorkspace, err := filepath.Abs(opts.Workspace)
+	if err != nil {
+		return nil, fmt.Errorf("invalid shell workspace: %w", err)
+	}
+	workspace, err := filepath.EvalSymlinks(absWorkspace)
+	if err != nil {
+		return nil, fmt.Errorf("invalid shell workspace: %w", err)
+	}
+
+	inputSchema := tool.CreateJSONSchema("object", map[string]any{
+		"command": tool.StringProperty("要运行的命令，如 \"wc -l main.go\"。不经过 shell 解释：支持引号，不支持管道、重定向、&&、变量展开；路径参数必须位于工作区内"),
+		"cwd":     tool.StringProperty("工作目录，相对于工作区（默认为工作区根目录）"),
+		"timeout": tool.NumberProperty(fmt.Sprintf("超时时间（秒），不超过 %d", int(opts.Timeout.Seconds()))),
+		"stdin":   tool.StringProperty("写入命令标准输入的内容"),
+	}, []string{"command"})
+
+	outputSchema := tool.CreateJSONSchema("object", map[string]any{
+		"success":     tool.BooleanProperty("命令是否以退出码 0 正常结束"),
+		"exit_code":   tool.NumberProperty("退出码，被信号终止时为 -1"),
+		"stdout":      tool.StringProperty("标准输出"),
+		"stderr":      tool.StringProperty("标准错误"),
+		"duration_ms": tool.NumberProperty("运行时间（毫秒）"),
+		"timed_out":   tool.BooleanProperty("是否因超时被终止"),
+		"truncated":   tool.BooleanProperty("输出是否超过上限被截断"),
+		"command":     tool.StringProperty("运行的命令"),
+		"cwd":         tool.StringProperty("工作目录（相对于工作区）"),
+		"error":       tool.StringProperty("错误信息"),
+	}, []string{"success"})
+
+	description := fmt.Sprintf("在工作区 %s 内运行命令（如运行脚本、测试），返回退出码、stdout 和 stderr", opts.Workspace)
+	if len(opts.AllowedCommands) > 0 {
+		description += fmt.Sprintf("。允许的命令：%s", strings.Join(opts.AllowedCommands, ", "))
+	}
+
+	return &ShellTool{
+		BaseTool:  tool.NewBaseTool("shell", description, inputSchema, outputSchema),
+		opts:      opts,
+		workspace: workspace,
+		allowed:   commandSet(opts.AllowedCommands),
+		denied:    commandSet(opts.DeniedCommands),
+	}, nil
+}
+
+// NewShellToolFromConfig 根据 tools.shell 配置创建 shell 工具
+func NewShellToolFromConfig(cfg config.ToolShellConfig) (*ShellTool, error) {
+	opts := ShellOptions{
+		Workspace:       cfg.Workspace,
+		AllowedCommands: cfg.AllowedCommands,
+		DeniedCommands:  cfg.DeniedCommands,
+		MaxOutputBytes:  cfg.MaxOutputBytes,
+		CPUSeconds:      cfg.CPUSeconds,
+		MemoryMB:        cfg.MemoryMB,
+		Env:             cfg.Env,
+	}
+	if cfg.Timeout != "" {
+		timeout, err := time.ParseDuration(cfg.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tools.shell.timeout: %w", err)
+		}
+		opts.Timeout = timeout
+	}
+	return NewShellTool(opts)
+}
+
+// commandSet 构建命令名称集合
+func commandSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			set[name] = true
+		}
+	}
+	return set
+}
+
+// Invoke 运行命令
+func (s *ShellTool) Invoke(ctx context.Context, args map[string]any) (map[string]any, error) {
+	command, _ := args["command"].(string)
+	if strings.TrimSpace(command) == "" {
+		return s.errorResult("command is required"), nil
+	}
+	argv, err := splitCommand(command)
+	if err != nil {
+		return s.errorResult(err.Error()), nil
+	}
+
+	cwdArg, _ := args["cwd"].(string)
+	dir, err := s.resolveDir(cwdArg)
+	if err != nil {
+		return s.errorResult(err.Error()), nil
+	}
+	if argv[0], err = s.checkCommand(argv[0], dir); err != nil {
+		return s.errorResult(err.Error()), nil
+	}
+	if err := s.checkArgs(argv[1:], dir); err != nil {
+		return s.errorResult(err.Error()), nil
+	}
+
+	timeout := s.opts.Timeout
+	if seconds, ok := args["timeout"].(float64); ok && seconds > 0 && time.Duration(seconds*float64(time.Second)) < timeout {
+		timeout = time.Duration(seconds * float64(time.Second))
+	}
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	stdout := &cappedBuffer{limit: s.opts.MaxOutputBytes}
+	stderr := &cappedBuffer{limit: s.opts.MaxOutputBytes}
+	cmd := shellCommand(runCtx, argv, s.opts.CPUSeconds, s.opts.MemoryMB)
+	cmd.Dir = dir
+	cmd.Env = tool.ProcessEnv(s.opts.Env)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if stdin, ok := args["stdin"].(string); ok && stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+
+	start := time.Now()
+	runErr := cmd.Run()
+	duration := time.Since(start)
+
+	var exitErr *exec.ExitError
+	if runErr != nil && !errors.As(runErr, &exitErr) && cmd.ProcessState == nil {
+		return s.errorResult(fmt.Sprintf("failed to start command: %v", runErr)), nil
+	}
+
+	exitCode := -1
+	if cmd.ProcessState != nil {
+		exitCode = cmd.ProcessState.ExitCode()
+	}
+	timedOut := errors.Is(runCtx.Err(), context.DeadlineExceeded)
+	relDir, _ := filepath.Rel(s.workspace, dir)
+
+	result := map[string]any{
+		"success":     exitCode == 0 && !timedOut,
+		"exit_code":   exitCode,
+		"stdout":      stdout.String(),
+		"stderr":      stderr.String(),
+		"duration_ms": duration.Milliseconds(),
+		"timed_out":   timedOut,
+		"truncated":   stdout.truncated || stderr.truncated,
+		"command":     command,
+		"cwd":         relDir,
+	}
+
+	switch {
+	case timedOut:
+		result["error"] = fmt.Sprintf("command timed out after %s", timeout)
+	case ctx.Err() != nil:
+		result["error"] = fmt.Sprintf("command canceled: %v", ctx.Err())
+	case exitCode == -1:
+		result["error"] = fmt.Sprintf("command terminated (%s)%s", cmd.ProcessState, stderrTail(stderr.String()))
+	case exitCode != 0:
+		result["error"] = fmt.Sprintf("command exited with code %d%s", exitCode, stderrTail(stderr.String()))
+	}
+	return result, nil
+}
+
+// Ping 检查工作区可写、命令包装器可用
+func (s *ShellTool) Ping(ctx context.Context) (tool.HealthStatus, error) {
+	probe, err := os.CreateTemp(s.workspace, ".shell-ping-*")
+	if err != nil {
+		return tool.HealthStatus{}, fmt.Errorf("workspace is not writable: %w", err)
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+
+	if err := checkShellPlatform(); err != nil {
+		return tool.HealthStatus{}, err
+	}
+	return tool.HealthStatus{Detail: fmt.Sprintf("workspace %s", s.workspace)}, nil
+}
+
+// resolveDir 解析工作目录，必须位于工作区内（解析符号链接后判断）
+func (s *ShellTool) resolveDir(dir string) (string, error) {
+	path := dir
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(s.workspace, dir)
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("cwd not found: %s", dir)
+	}
+	if !withinDir(s.workspace, resolved) {
+		return "", fmt.Errorf("cwd must be inside the workspace: %s", dir)
+	}
+	info, err := os.Stat(resolved)
+	if err != nil || !info.IsDir() {
+		return "", fmt.Errorf("cwd is not a directory: %s", dir)
+	}
+	return resolved, nil
+}
+
+// checkCommand 按允许/禁止列表检查命令；带路径的命令必须是工作区内的文件，返回实际执行的路径
+func (s *ShellTool) checkCommand(name, dir string) (string, error) {
+	base := filepath.Base(name)
+	if s.denied[base] {
+		return "", fmt.Errorf("command %q is denied", base)
+	}
+	if len(s.allowed) > 0 && !s.allowed[base] {
+		return "", fmt.Errorf("command %q is not in the allowed commands: %s", base, strings.Join(s.opts.AllowedCommands, ", "))
+	}
+	if !strings.ContainsRune(name, '/') && !strings.ContainsRune(name, filepath.Separator) {
+		return name, nil
+	}
+
+	path := name
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, name)
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("command not found: %s", name)
+	}
+	if !withinDir(s.workspace, resolved) {
+		return "", fmt.Errorf("command path must be inside the workspace: %s", name)
+	}
+	return resolved, nil
+}
+
+// checkArgs 检查参数中的路径：每个参数（--flag=value 取值部分，-I/path 取路径部分）按 dir 解析后
+// 必须位于工作区内，拒绝绝对路径和通过 .. 或符号链接逃出工作区的路径。
+// 只检查命令行，解释器执行的代码（如 python3 -c）不在检查范围内
+func (s *ShellTool) checkArgs(args []string, dir string) error {
+	for _, arg := range args {
+		value := arg
+		if strings.HasPrefix(arg, "-") {
+			if i := strings.IndexByte(arg, '='); i >= 0 {
+				value = arg[i+1:]
+			} else if i := strings.IndexAny(arg, "/"+string(filepath.Separator)); i > 0 {
+				value = arg[i:]
+			} else {
+				continue
+			}
+		}
+		if value == "" {
+			continue
+		}
+		if !withinDir(s.workspace, resolvePath(value, dir)) {
+			return fmt.Errorf("argument %q refers to a path outside the workspace", arg)
+		}
+	}
+	return nil
+}
+
+// resolvePath 将 path 按 dir 解析为绝对路径；路径或其父目录存在时解析符号链接
+func resolvePath(path, dir string) string {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	path = filepath.Clean(path)
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	if parent, err := filepath.EvalSymlinks(filepath.Dir(path)); err == nil {
+		return filepath.Join(parent, filepath.Base(path))
+	}
+	return path
+}
+
+// errorResult 创建错误结果
+func (s *ShellTool) errorResult(message string) map[string]any {
+	return map[string]any{
+		"success": false,
+		"error":   message,
+	}
+}
+
+// withinDir path 是否为 root 或位于 root 之下
+func withinDir(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// stderrTail 返回附加到错误信息中的 stderr 末尾内容
+func stderrTail(stderr string) string {
+	stderr = strings.TrimSpace(stderr)
+	if stderr == "" {
+		return ""
+	}
+	if runes := []rune(stderr); len(runes) > shellErrorTailChars {
+		stderr = "..." + string(runes[len(runes)-shellErrorTailChars:])
+	}
+	return ": " + stderr
+}
+
+// splitCommand 按 shell 引号规则拆分命令行，不支持的运算符（管道、重定向、变量展开等）返回错误
+func splitCommand(line string) ([]string, error) {
+	var (
+		argv    []string
+		current strings.Builder
+		inArg   bool
+		quote   rune
+		escaped bool
+	)
+	for _, r := range line {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case quote == '"':
+			switch r {
+			case '"':
+				quote = 0
+			case '\\':
+				escaped = true
+			default:
+				current.WriteRune(r)
+			}
+		case r == '\\':
+			escaped, inArg = true, true
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t':
+			if inArg {
+				argv = append(argv, current.String())
+				current.Reset()
+				inArg = false
+			}
+		case strings.ContainsRune(shellMetaChars, r):
+			return nil, fmt.Errorf("shell operator %q is not supported: run one command at a time without pipes, redirects or variables", r)
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("unterminated quote or escape in command")
+	}
+	if inArg {
+		argv = append(argv, current.String())
+	}
+	if len(argv) == 0 {
+		return nil, fmt.Errorf("command is required")
+	}
+	return argv, nil
+}
+
+// cappedBuffer 只保留前 limit 个字节的输出，超出部分丢弃并标记截断
+type cappedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+// Write 实现 io.Writer，始终报告写入成功，避免命令因管道错误提前退出
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.limit - b.buf.Len(); remaining > 0 {
+		if len(p) > remaining {
+			b.buf.Write(p[:remaining])
+			b.truncated = true
+		} else {
+			b.buf.Write(p)
+		}
+	} else if len(p) > 0 {
+		b.truncated = true
+	}
+	return len(p), nil
+}
+
+// String 返回保留的输出，截断时附加提示
+func (b *cappedBuffer) String() string {
+	if b.truncated {
+		return strings.ToValidUTF8(b.buf.String(), "") + fmt.Sprintf("\n... [output truncated at %d bytes]", b.limit)
+	}
+	return b.buf.String()
+}
@@ -0,0 +1,48 @@
+//go:build !windows
+
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// shellWrapper 通过 /bin/sh 设置资源限制后 exec 目标命令；命令本身作为参数传入，不经过 shell 解释
+const shellWrapper = "/bin/sh"
+
+// shellWaitDelay 命令被终止后等待输出管道关闭的最长时间（子进程可能仍持有管道）
+const shellWaitDelay = 2 * time.Second
+
+// shellCommand 创建带资源限制的命令：CPU 时间（ulimit -t）、虚拟内存（ulimit -v），
+// 任一限制设置失败时不运行命令（退出码非零，stderr 中包含 ulimit 的错误）；
+// 命令在独立的进程组中运行，超时或取消时整个进程组被终止
+func shellCommand(ctx context.Context, argv []string, cpuSeconds, memoryMB int) *exec.Cmd {
+	script := ""
+	if cpuSeconds > 0 {
+		script += fmt.Sprintf("ulimit -t %d && ", cpuSeconds)
+	}
+	if memoryMB > 0 {
+		script += fmt.Sprintf("ulimit -v %d && ", memoryMB*1024)
+	}
+	script += `exec "$@"`
+
+	cmd := exec.CommandContext(ctx, shellWrapper, append([]string{"-c", script, "shell"}, argv...)...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = shellWaitDelay
+	return cmd
+}
+
+// checkShellPlatform 检查命令包装器是否可用
+func checkShellPlatform() error {
+	if _, err := os.Stat(shellWrapper); err != nil {
+		return fmt.Errorf("%s is not available: %w", shellWrapper, err)
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+//go:build windows
+
+package builtin
+
+import (
+	"context"
+	"os/exec"
+	"time"
+)
+
+// shellWaitDelay 命令被终止后等待输出管道关闭的最长时间
+const shellWaitDelay = 2 * time.Second
+
+// shellCommand 创建命令；Windows 没有 rlimit，只应用超时
+func shellCommand(ctx context.Context, argv []string, cpuSeconds, memoryMB int) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.WaitDelay = shellWaitDelay
+	return cmd
+}
+
+// checkShellPlatform Windows 上无额外依赖
+func checkShellPlatform() error {
+	return nil
+}
@@ -28,6 +28,11 @@ func NewStopTool() *StopTool {
 	}
 }
 
+// Ping 停止工具不依赖外部服务，始终可用
+func (t *StopTool) Ping(ctx context.Context) (tool.HealthStatus, error) {
+	return tool.HealthStatus{Detail: noDependencyDetail}, nil
+}
+
 // Invoke 执行停止操作
 func (t *StopTool) Invoke(ctx context.Context, args map[string]any) (map[string]any, error) {
 	reason, ok := args["reason"].(string)
@@ -0,0 +1,314 @@
+package tool
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"openmanus-go/pkg/logger"
+)
+
+// 缓存状态，记录在 Call.CacheStatus 和 Observation.Cache 中
+const (
+	CacheHit  = "hit"
+	CacheMiss = "miss"
+)
+
+// Cacheable 由结果可缓存的工具实现，按具体操作声明是否可缓存
+//
+// 返回 ok=false 表示本次调用不可缓存（如写操作）；ttl 为 0 时使用缓存的默认 TTL。
+type Cacheable interface {
+	CachePolicy(args map[string]any) (ttl time.Duration, ok bool)
+}
+
+// ResultCache 工具结果缓存后端
+type ResultCache interface {
+	// Get 返回未过期的缓存结果
+	Get(key CacheKey) (map[string]any, bool)
+	// Set 写入缓存结果，ttl <= 0 表示永不过期
+	Set(key CacheKey, result map[string]any, ttl time.Duration) error
+	// Invalidate 清除指定工具的全部缓存（工具执行写操作后调用）
+	Invalidate(toolName string) error
+}
+
+// CacheKey 缓存键：工具名称 + 规范化参数的摘要
+type CacheKey struct {
+	Tool   string
+	Digest string
+}
+
+// NewCacheKey 由工具名称和参数计算缓存键
+//
+// 参数按 JSON 规范化（对象键排序、数字统一为 float64），因此 {"a":1,"b":2} 与 {"b":2,"a":1.0} 得到相同的键。
+func NewCacheKey(toolName string, args map[string]any) (CacheKey, error) {
+	data, err := json.Marshal(normalizeValue(args))
+	if err != nil {
+		return CacheKey{}, fmt.Errorf("failed to canonicalize args: %w", err)
+	}
+	sum := sha256.Sum256(append([]byte(toolName+"\x00"), data...))
+	return CacheKey{Tool: toolName, Digest: hex.EncodeToString(sum[:])}, nil
+}
+
+// memoryCacheEntry 内存缓存条目
+type memoryCacheEntry struct {
+	result    map[string]any
+	expiresAt time.Time
+}
+
+// MemoryResultCache 进程内结果缓存
+type MemoryResultCache struct {
+	mu         sync.Mutex
+	entries    map[CacheKey]memoryCacheEntry
+	maxEntries int
+}
+
+// NewMemoryResultCache 创建内存结果缓存，maxEntries <= 0 表示不限制条目数
+func NewMemoryResultCache(maxEntries int) *MemoryResultCache {
+	return &MemoryResultCache{
+		entries:    make(map[CacheKey]memoryCacheEntry),
+		maxEntries: maxEntries,
+	}
+}
+
+// Get 返回未过期的缓存结果
+func (c *MemoryResultCache) Get(key CacheKey) (map[string]any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return copyResult(entry.result), true
+}
+
+// Set 写入缓存结果
+func (c *MemoryResultCache) Set(key CacheKey, result map[string]any, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if c.maxEntries > 0 && len(c.entries) >= c.maxEntries {
+		// 先清理过期条目，仍然已满时淘汰最早过期的条目
+		var oldestKey CacheKey
+		var oldest time.Time
+		for k, e := range c.entries {
+			if !e.expiresAt.IsZero() && now.After(e.expiresAt) {
+				delete(c.entries, k)
+				continue
+			}
+			if oldest.IsZero() || (!e.expiresAt.IsZero() && e.expiresAt.Before(oldest)) {
+				oldestKey, oldest = k, e.expiresAt
+			}
+		}
+		if len(c.entries) >= c.maxEntries {
+			delete(c.entries, oldestKey)
+		}
+	}
+
+	entry := memoryCacheEntry{result: copyResult(result)}
+	if ttl > 0 {
+		entry.expiresAt = now.Add(ttl)
+	}
+	c.entries[key] = entry
+	return nil
+}
+
+// Invalidate 清除指定工具的全部缓存
+func (c *MemoryResultCache) Invalidate(toolName string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for k := range c.entries {
+		if k.Tool == toolName {
+			delete(c.entries, k)
+		}
+	}
+	return nil
+}
+
+// diskCacheEntry 磁盘上的一条缓存记录
+type diskCacheEntry struct {
+	Tool      string         `json:"tool"`
+	CreatedAt time.Time      `json:"created_at"`
+	ExpiresAt time.Time      `json:"expires_at,omitempty"`
+	Result    map[string]any `json:"result"`
+}
+
+// unsafePathChars 工具名称中不能直接用作目录名的字符
+var unsafePathChars = regexp.MustCompile(`[^a-zA-Z0-9._-]`)
+
+// DiskResultCache 磁盘结果缓存，可跨运行复用；每个工具一个子目录，每条结果一个 JSON 文件
+type DiskResultCache struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewDiskResultCache 创建磁盘结果缓存
+func NewDiskResultCache(dir string) (*DiskResultCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create tool cache directory: %w", err)
+	}
+	return &DiskResultCache{dir: dir}, nil
+}
+
+func (c *DiskResultCache) toolDir(toolName string) string {
+	return filepath.Join(c.dir, unsafePathChars.ReplaceAllString(toolName, "_"))
+}
+
+func (c *DiskResultCache) path(key CacheKey) string {
+	return filepath.Join(c.toolDir(key.Tool), key.Digest+".json")
+}
+
+// Get 返回未过期的缓存结果
+func (c *DiskResultCache) Get(key CacheKey) (map[string]any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+
+	var entry diskCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		logger.Warnw("tool.cache.corrupt_entry", "tool", key.Tool, "error", err)
+		_ = os.Remove(c.path(key))
+		return nil, false
+	}
+	if !entry.ExpiresAt.IsZero() && time.Now().After(entry.ExpiresAt) {
+		_ = os.Remove(c.path(key))
+		return nil, false
+	}
+	return entry.Result, true
+}
+
+// Set 写入缓存结果（先写临时文件再重命名，避免读到半写入的文件）
+func (c *DiskResultCache) Set(key CacheKey, result map[string]any, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := diskCacheEntry{Tool: key.Tool, CreatedAt: time.Now(), Result: result}
+	if ttl > 0 {
+		entry.ExpiresAt = entry.CreatedAt.Add(ttl)
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache entry: %w", err)
+	}
+
+	if err := os.MkdirAll(c.toolDir(key.Tool), 0755); err != nil {
+		return fmt.Errorf("failed to create tool cache directory: %w", err)
+	}
+	tmp := c.path(key) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := os.Rename(tmp, c.path(key)); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	return nil
+}
+
+// Invalidate 清除指定工具的全部缓存
+func (c *DiskResultCache) Invalidate(toolName string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := os.RemoveAll(c.toolDir(toolName)); err != nil {
+		return fmt.Errorf("failed to invalidate tool cache: %w", err)
+	}
+	return nil
+}
+
+// CacheOptions 结果缓存中间件选项
+type CacheOptions struct {
+	Cache ResultCache   // 缓存后端
+	TTL   time.Duration // 默认 TTL，工具未声明 TTL 时使用
+
+	// All 忽略工具的 Cacheable 声明，缓存所有成功的调用（用于对单个工具显式开启缓存）
+	All bool
+}
+
+// CachingMiddleware 缓存幂等工具调用的成功结果
+//
+// 只有实现 Cacheable 且本次操作可缓存的调用才会读写缓存（All 为 true 时除外）；
+// 可缓存工具执行了不可缓存的操作（如写入）后，该工具的缓存会被清除。
+// 命中情况记录在 Call.CacheStatus 中。
+func CachingMiddleware(opts CacheOptions) Middleware {
+	if opts.Cache == nil {
+		opts.Cache = NewMemoryResultCache(0)
+	}
+
+	return func(next Invoker) Invoker {
+		return func(ctx context.Context, call *Call) (map[string]any, error) {
+			ttl, cacheable := opts.TTL, opts.All
+			policy, hasPolicy := call.Target.(Cacheable)
+			if !opts.All && hasPolicy {
+				var policyTTL time.Duration
+				policyTTL, cacheable = policy.CachePolicy(call.Args)
+				if policyTTL > 0 {
+					ttl = policyTTL
+				}
+			}
+
+			if !cacheable {
+				result, err := next(ctx, call)
+				if err == nil && hasPolicy {
+					if invalidateErr := opts.Cache.Invalidate(call.Tool); invalidateErr != nil {
+						logger.Warnw("tool.cache.invalidate_failed", "tool", call.Tool, "error", invalidateErr)
+					}
+				}
+				return result, err
+			}
+
+			key, err := NewCacheKey(call.Tool, call.Args)
+			if err != nil {
+				logger.Warnw("tool.cache.key_failed", "tool", call.Tool, "error", err)
+				return next(ctx, call)
+			}
+
+			if result, ok := opts.Cache.Get(key); ok {
+				call.CacheStatus = CacheHit
+				logger.Infof("💾 [TOOL_CACHE] Cache hit for %s", call.Tool)
+				logger.Debugw("tool.cache.hit", "tool", call.Tool, "key", key.Digest)
+				return result, nil
+			}
+
+			call.CacheStatus = CacheMiss
+			result, err := next(ctx, call)
+			if err != nil || OutputError(result) != "" {
+				return result, err
+			}
+			if err := opts.Cache.Set(key, result, ttl); err != nil {
+				logger.Warnw("tool.cache.write_failed", "tool", call.Tool, "error", err)
+			} else {
+				logger.Debugw("tool.cache.store", "tool", call.Tool, "key", key.Digest, "ttl", ttl.String())
+			}
+			return result, nil
+		}
+	}
+}
+
+// copyResult 返回结果的浅拷贝，避免调用方修改缓存内容
+func copyResult(result map[string]any) map[string]any {
+	if result == nil {
+		return nil
+	}
+	copied := make(map[string]any, len(result))
+	for k, v := range result {
+		copied[k] = v
+	}
+	return copied
+}
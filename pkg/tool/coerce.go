@@ -0,0 +1,273 @@
+package tool
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CoerceArgs 按输入 Schema 修正模型生成的参数中常见的类型偏差
+//
+// 支持：数字/布尔值写成字符串（"5" → 5，"true" → true）、标量写成单元素以外的形式
+// （"a" → ["a"]）、数组或对象被编码为 JSON 字符串、数字写成字符串形式的整数等。
+// 返回修正后的参数副本和修正说明；无法修正的值保持原样，由 CheckArgs 报告。
+func CoerceArgs(args map[string]any, schema map[string]any) (map[string]any, []string) {
+	if args == nil {
+		return nil, nil
+	}
+
+	properties, _ := schema["properties"].(map[string]any)
+	coerced := make(map[string]any, len(args))
+	var changes []string
+	for name, value := range args {
+		propSchema, ok := properties[name].(map[string]any)
+		if !ok {
+			coerced[name] = value
+			continue
+		}
+		newValue, notes := coerceValue(name, value, propSchema)
+		coerced[name] = newValue
+		changes = append(changes, notes...)
+	}
+	sort.Strings(changes)
+	return coerced, changes
+}
+
+// coerceValue 按属性 Schema 修正单个值，path 用于修正说明
+func coerceValue(path string, value any, schema map[string]any) (any, []string) {
+	types := SchemaTypes(schema)
+	if len(types) == 0 || value == nil || matchesAnyType(value, types) {
+		return coerceNested(path, value, schema)
+	}
+
+	for _, typ := range types {
+		if converted, ok := convertValue(value, typ, schema); ok {
+			note := fmt.Sprintf("%s: %s → %s", path, describeValue(value), typ)
+			result, notes := coerceNested(path, converted, schema)
+			return result, append([]string{note}, notes...)
+		}
+	}
+	return value, nil
+}
+
+// coerceNested 递归修正数组元素和嵌套对象属性
+func coerceNested(path string, value any, schema map[string]any) (any, []string) {
+	switch v := value.(type) {
+	case []any:
+		items, ok := schema["items"].(map[string]any)
+		if !ok {
+			return value, nil
+		}
+		var changes []string
+		result := make([]any, len(v))
+		for i, item := range v {
+			var notes []string
+			result[i], notes = coerceValue(fmt.Sprintf("%s[%d]", path, i), item, items)
+			changes = append(changes, notes...)
+		}
+		return result, changes
+	case map[string]any:
+		if _, ok := schema["properties"].(map[string]any); !ok {
+			return value, nil
+		}
+		result, notes := CoerceArgs(v, schema)
+		for i, note := range notes {
+			notes[i] = path + "." + note
+		}
+		return result, notes
+	}
+	return value, nil
+}
+
+// convertValue 尝试将值转换为目标类型
+func convertValue(value any, typ string, schema map[string]any) (any, bool) {
+	switch typ {
+	case "number", "integer":
+		s, ok := value.(string)
+		if !ok {
+			return nil, false
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil {
+			return nil, false
+		}
+		if typ == "integer" && f != math.Trunc(f) {
+			return nil, false
+		}
+		return f, true
+	case "boolean":
+		if s, ok := value.(string); ok {
+			switch strings.ToLower(strings.TrimSpace(s)) {
+			case "true", "yes", "1":
+				return true, true
+			case "false", "no", "0":
+				return false, true
+			}
+		}
+		return nil, false
+	case "string":
+		switch v := value.(type) {
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), true
+		case bool:
+			return strconv.FormatBool(v), true
+		}
+		return nil, false
+	case "array":
+		if s, ok := value.(string); ok {
+			var arr []any
+			if trimmed := strings.TrimSpace(s); strings.HasPrefix(trimmed, "[") && json.Unmarshal([]byte(trimmed), &arr) == nil {
+				return arr, true
+			}
+		}
+		if _, isMap := value.(map[string]any); isMap {
+			if items, ok := schema["items"].(map[string]any); !ok || !matchesAnyType(value, SchemaTypes(items)) {
+				return nil, false
+			}
+		}
+		// 单个值包装为单元素数组
+		return []any{value}, true
+	case "object":
+		if s, ok := value.(string); ok {
+			var obj map[string]any
+			if trimmed := strings.TrimSpace(s); strings.HasPrefix(trimmed, "{") && json.Unmarshal([]byte(trimmed), &obj) == nil {
+				return obj, true
+			}
+		}
+		return nil, false
+	case "null":
+		if s, ok := value.(string); ok && strings.TrimSpace(s) == "null" {
+			return nil, true
+		}
+		return nil, false
+	}
+	return nil, false
+}
+
+// CheckArgs 按输入 Schema 完整校验参数，返回全部问题描述
+func CheckArgs(args map[string]any, schema map[string]any) []string {
+	errs := ValidateArgs(args, schema)
+	problems := make([]string, len(errs))
+	for i, err := range errs {
+		problems[i] = err.String()
+	}
+	return problems
+}
+
+// SchemaRequired 返回 Schema 的必填字段，兼容 []string 和 JSON 解码得到的 []any
+func SchemaRequired(schema map[string]any) []string {
+	switch values := schema["required"].(type) {
+	case []string:
+		return values
+	case []any:
+		required := make([]string, 0, len(values))
+		for _, v := range values {
+			if s, ok := v.(string); ok {
+				required = append(required, s)
+			}
+		}
+		return required
+	}
+	return nil
+}
+
+// SchemaTypes 返回 Schema 声明的类型列表，兼容字符串和数组两种 type 写法
+func SchemaTypes(schema map[string]any) []string {
+	switch t := schema["type"].(type) {
+	case string:
+		return []string{t}
+	case []string:
+		return t
+	case []any:
+		types := make([]string, 0, len(t))
+		for _, v := range t {
+			if s, ok := v.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+// matchesAnyType 判断值是否属于任一 JSON Schema 类型
+func matchesAnyType(value any, types []string) bool {
+	for _, typ := range types {
+		if matchesType(value, typ) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesType 判断值是否属于指定的 JSON Schema 类型
+func matchesType(value any, typ string) bool {
+	switch typ {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		switch value.(type) {
+		case float64, float32, int, int64, int32, json.Number:
+			return true
+		}
+	case "integer":
+		switch v := value.(type) {
+		case int, int64, int32:
+			return true
+		case float64:
+			return v == math.Trunc(v)
+		case json.Number:
+			_, err := v.Int64()
+			return err == nil
+		}
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "array":
+		switch value.(type) {
+		case []any, []string, []map[string]any:
+			return true
+		}
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "null":
+		return value == nil
+	}
+	return false
+}
+
+// describeValue 返回值的 JSON 类型和简短预览，用于错误和修正说明
+func describeValue(value any) string {
+	var typ string
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		typ = "string"
+	case bool:
+		typ = "boolean"
+	case float64, float32, int, int64, int32, json.Number:
+		typ = "number"
+	case []any:
+		typ = "array"
+	case map[string]any:
+		typ = "object"
+	default:
+		typ = fmt.Sprintf("%T", value)
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return typ
+	}
+	text := string(data)
+	if len(text) > 40 {
+		text = text[:40] + "..."
+	}
+	return fmt.Sprintf("%s %s", typ, text)
+}
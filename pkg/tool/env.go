@@ -0,0 +1,24 @@
+package tool
+
+import (
+	"os"
+	"strings"
+)
+
+// DefaultProcessEnv 默认从当前进程透传给子进程（插件、shell 命令）的环境变量，
+// 其余变量（API Key、数据库密码等）不会传递
+var DefaultProcessEnv = []string{"PATH", "HOME", "USER", "LANG", "LC_ALL", "TZ", "TMPDIR"}
+
+// ProcessEnv 构建子进程的环境变量：DefaultProcessEnv 加上 extra，
+// extra 中的 NAME 从当前进程透传，NAME=VALUE 直接设置
+func ProcessEnv(extra []string) []string {
+	env := make([]string, 0, len(DefaultProcessEnv)+len(extra))
+	for _, name := range append(append([]string(nil), DefaultProcessEnv...), extra...) {
+		if strings.Contains(name, "=") {
+			env = append(env, name)
+		} else if value, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+value)
+		}
+	}
+	return env
+}
@@ -9,6 +9,12 @@ import (
 	"openmanus-go/pkg/state"
 )
 
+// OutputKeyImages 工具输出中用于附加图片的字段，值为本地路径、URL 或 data URL 列表
+const OutputKeyImages = "images"
+
+// observationSummaryChars 观测结果摘要的最大字符数
+const observationSummaryChars = 200
+
 // Executor 工具执行器
 type Executor struct {
 	registry *Registry
@@ -32,49 +38,155 @@ func NewExecutor(registry *Registry, timeout time.Duration) *Executor {
 
 // Execute 执行工具调用并返回观测结果
 func (e *Executor) Execute(ctx context.Context, action state.Action) (*state.Observation, error) {
-	// 创建带超时的上下文
-	execCtx, cancel := context.WithTimeout(ctx, e.timeout)
-	defer cancel()
+	return e.ExecuteWithProgress(ctx, action, nil)
+}
 
+// ExecuteWithProgress 执行工具调用，流式工具的进度事件交给 handler
+//
+// handler 调用 stop 后工具的上下文被取消，观测结果使用最近一次报告的部分输出并标记为 Partial；
+// 工具出错（如超时）但已有部分输出时，同样保留部分输出。
+func (e *Executor) ExecuteWithProgress(ctx context.Context, action state.Action, handler ProgressHandler) (*state.Observation, error) {
 	start := time.Now()
 
-	// 获取工具信息以显示类型
+	// 按输入 Schema 校验参数，失败时不调用工具，将具体问题作为观测结果返回给规划器
 	toolInfo := e.getToolInfo(action.Name)
-	toolTypeSymbol := "🔧" // 默认内置工具
-	toolTypeText := "Built-in"
-	if toolInfo != nil && toolInfo.Type == ToolTypeMCP {
-		toolTypeSymbol = "🌐"
-		toolTypeText = "MCP"
+	if toolInfo != nil {
+		if errs := ValidateArgs(action.Args, toolInfo.InputSchema); len(errs) > 0 {
+			validationErr := &ValidationError{Tool: action.Name, Errors: errs}
+			logger.Warnw("tool.exec.invalid_args", "tool", action.Name, "errors", len(errs), "error", validationErr)
+			return &state.Observation{
+				Tool:   action.Name,
+				Status: state.ObservationError,
+				ErrMsg: validationErr.Error(),
+				Output: map[string]any{
+					"error":             validationErr.Error(),
+					"validation_errors": errs,
+				},
+				Latency: time.Since(start).Milliseconds(),
+			}, nil
+		}
 	}
 
-	logger.Infof("🔧 [TOOL] Executing %s %s (%s tool)", toolTypeSymbol, action.Name, toolTypeText)
-	if toolInfo != nil && toolInfo.ServerName != "" {
-		logger.Infof("📡 [SERVER] Calling MCP server: %s", toolInfo.ServerName)
+	// 创建带超时的上下文（在并发和频率限制上排队的时间同样计入）
+	execCtx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+	execStart := time.Now()
+	tracker := newProgressTracker(action.Name, handler, cancel)
+	execCtx = withProgressReporter(execCtx, tracker.report)
+
+	// 经过注册表的中间件链调用工具（日志、策略、缓存、并发和频率限制等）
+	call := &Call{Tool: action.Name, Args: action.Args}
+	result, err := e.registry.InvokeCall(execCtx, call)
+	latency := time.Since(execStart) - call.Wait
+
+	// 提前停止或出错时，以最近的部分输出作为结果
+	stopReason, partial, events := tracker.finish()
+	if stopReason != "" && err == nil {
+		// 停止请求到达前工具已正常完成，使用完整结果
+		logger.Debugw("tool.exec.stop_ignored", "tool", action.Name, "reason", stopReason)
+		stopReason = ""
+	}
+	isPartial := false
+	if stopReason != "" {
+		logger.Debugw("tool.exec.stopped", "tool", action.Name, "reason", stopReason, "events", events, "has_partial", partial != nil)
+		if partial != nil {
+			result, err, isPartial = partial, nil, true
+		} else {
+			result, err = nil, fmt.Errorf("tool stopped before reporting partial output: %s", stopReason)
+		}
+	} else if err != nil && partial != nil {
+		logger.Debugw("tool.exec.partial_on_error", "tool", action.Name, "events", events, "error", err)
+		result, isPartial = partial, true
+	}
+
+	// 按 OutputSchema 校验成功的输出，strict 模式下不符合的输出作为错误返回
+	var outputSchema map[string]any
+	if toolInfo != nil {
+		outputSchema = toolInfo.OutputSchema
+	}
+	if mode := e.registry.OutputValidation(); err == nil && !isPartial && mode != OutputValidationOff {
+		if errs := ValidateOutput(result, outputSchema); len(errs) > 0 {
+			validationErr := &ValidationError{Tool: action.Name, Errors: errs, Output: true}
+			logger.Warnw("tool.exec.invalid_output", "tool", action.Name, "mode", mode, "errors", len(errs), "error", validationErr)
+			if mode == OutputValidationStrict {
+				err = validationErr
+			}
+		}
 	}
 
-	// 调用工具
-	result, err := e.registry.Invoke(execCtx, action.Name, action.Args)
-	latency := time.Since(start)
+	// 超过阈值的大输出转存为工件，观测结果只保留引用和预览，避免撑爆后续提示
+	var artifacts []string
+	if result != nil {
+		offloaded, artifactID, offloadErr := e.registry.ArtifactStore().Offload(ctx, action.Name, result)
+		if offloadErr != nil {
+			logger.Warnw("tool.artifact.store_failed", "tool", action.Name, "error", offloadErr)
+		} else if artifactID != "" {
+			result = offloaded
+			artifacts = []string{artifactID}
+		}
+	}
 
 	// 构建观测结果
 	observation := &state.Observation{
-		Tool:    action.Name,
-		Output:  result,
-		Latency: latency.Milliseconds(),
+		Tool:      action.Name,
+		Output:    result,
+		Images:    outputImages(result),
+		Latency:   latency.Milliseconds(),
+		Cache:     call.CacheStatus,
+		Wait:      call.Wait.Milliseconds(),
+		Artifacts: artifacts,
+		Partial:   isPartial,
+		Stopped:   stopReason,
 	}
 
 	if err != nil {
-		observation.ErrMsg = err.Error()
-		logger.Warnw("tool.exec.error", "tool", action.Name, "error", err, "latency_ms", observation.Latency)
 		// 即使出错，也返回观测结果，让 Agent 能够处理错误
-		return observation, nil
+		observation.ErrMsg = err.Error()
+	} else if msg := OutputError(result); msg != "" {
+		// 工具以 success=false 或 error 字段报告的失败同样归一化为错误
+		observation.ErrMsg = msg
 	}
 
-	logger.Infow("tool.exec.ok", "tool", action.Name, "latency_ms", observation.Latency, "output_preview", previewResult(result))
+	// 归一化状态，并按 OutputSchema 生成摘要（转存为工件的输出没有对应的 Schema）
+	switch {
+	case observation.ErrMsg != "":
+		observation.Status = state.ObservationError
+	case isPartial:
+		observation.Status = state.ObservationPartial
+	default:
+		observation.Status = state.ObservationOK
+	}
+	if observation.ErrMsg == "" {
+		if len(artifacts) > 0 {
+			outputSchema = nil
+		}
+		observation.Summary = SummarizeOutput(result, outputSchema, observationSummaryChars)
+	}
 
 	return observation, nil
 }
 
+// outputImages 提取工具输出中附加的图片引用
+func outputImages(output map[string]any) []string {
+	switch images := output[OutputKeyImages].(type) {
+	case []string:
+		return images
+	case []any:
+		refs := make([]string, 0, len(images))
+		for _, image := range images {
+			if ref, ok := image.(string); ok && ref != "" {
+				refs = append(refs, ref)
+			}
+		}
+		return refs
+	case string:
+		if images != "" {
+			return []string{images}
+		}
+	}
+	return nil
+}
+
 // ExecuteWithRetry 带重试的工具执行
 func (e *Executor) ExecuteWithRetry(ctx context.Context, action state.Action, maxRetries int, backoff time.Duration) (*state.Observation, error) {
 	var lastErr error
@@ -150,13 +262,15 @@ func (e *Executor) BatchExecute(ctx context.Context, actions []state.Action) ([]
 
 // ValidateAction 验证动作是否有效
 func (e *Executor) ValidateAction(action state.Action) error {
-	_, err := e.registry.Get(action.Name)
+	t, err := e.registry.Get(action.Name)
 	if err != nil {
 		return fmt.Errorf("tool not found: %w", err)
 	}
 
-	// 验证输入参数（如果工具支持的话）
-	// 注意：这里需要具体的工具类型来进行验证
+	// 按输入 Schema 校验参数
+	if errs := ValidateArgs(action.Args, t.InputSchema()); len(errs) > 0 {
+		return &ValidationError{Tool: action.Name, Errors: errs}
+	}
 
 	return nil
 }
@@ -173,13 +287,11 @@ func (e *Executor) SetTimeout(timeout time.Duration) {
 
 // getToolInfo 获取工具信息
 func (e *Executor) getToolInfo(toolName string) *ToolInfo {
-	manifest := e.registry.GetToolsManifest()
-	for _, toolInfo := range manifest {
-		if toolInfo.Name == toolName {
-			return &toolInfo
-		}
+	toolInfo, ok := e.registry.ToolInfo(toolName)
+	if !ok {
+		return nil
 	}
-	return nil
+	return &toolInfo
 }
 
 func previewResult(m map[string]any) any {
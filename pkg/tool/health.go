@@ -0,0 +1,129 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"openmanus-go/pkg/logger"
+)
+
+// 健康检查状态
+const (
+	HealthOK      = "ok"
+	HealthFail    = "fail"
+	HealthSkipped = "skipped" // 工具未实现 HealthChecker 或未配置
+)
+
+// defaultHealthConcurrency 默认的并发检查数
+const defaultHealthConcurrency = 8
+
+// HealthStatus 一次健康检查的结果
+type HealthStatus struct {
+	Latency time.Duration // 依赖的往返延迟，为 0 时使用整个 Ping 的耗时
+	Detail  string        // 附加信息，如服务器版本、进程号
+}
+
+// HealthChecker 可检查自身依赖（数据库连接、MCP 服务器、插件进程等）是否可用的工具
+type HealthChecker interface {
+	Ping(ctx context.Context) (HealthStatus, error)
+}
+
+// HealthReport 单个工具的健康检查报告
+type HealthReport struct {
+	Tool       string        `json:"tool"`
+	Type       ToolType      `json:"type"`
+	ServerName string        `json:"server_name,omitempty"`
+	Status     string        `json:"status"`
+	Latency    time.Duration `json:"-"`
+	LatencyMS  int64         `json:"latency_ms"`
+	Detail     string        `json:"detail,omitempty"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// CheckHealth 在超时时间内检查单个工具，Ping 的 panic 视为失败
+func CheckHealth(ctx context.Context, t Tool, timeout time.Duration) (report HealthReport) {
+	info := toolInfoOf(t)
+	report = HealthReport{Tool: info.Name, Type: info.Type, ServerName: info.ServerName}
+
+	checker, ok := t.(HealthChecker)
+	if !ok {
+		report.Status = HealthSkipped
+		report.Detail = "health check not supported"
+		return report
+	}
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			report.Status = HealthFail
+			report.Error = fmt.Sprintf("panic: %v", r)
+			report.Latency = time.Since(start)
+			report.LatencyMS = report.Latency.Milliseconds()
+		}
+	}()
+
+	status, err := checker.Ping(ctx)
+	report.Latency = status.Latency
+	if report.Latency == 0 {
+		report.Latency = time.Since(start)
+	}
+	report.LatencyMS = report.Latency.Milliseconds()
+	report.Detail = status.Detail
+	if err != nil {
+		report.Status = HealthFail
+		report.Error = err.Error()
+	} else {
+		report.Status = HealthOK
+	}
+	return report
+}
+
+// CheckHealth 并发检查注册表中的工具，names 为空时检查全部工具，结果按名称排序
+func (r *Registry) CheckHealth(ctx context.Context, names []string, timeout time.Duration, concurrency int) ([]HealthReport, error) {
+	var tools []Tool
+	if len(names) == 0 {
+		tools = r.List()
+	} else {
+		for _, name := range names {
+			t, err := r.Get(name)
+			if err != nil {
+				return nil, err
+			}
+			tools = append(tools, t)
+		}
+	}
+	if concurrency <= 0 {
+		concurrency = defaultHealthConcurrency
+	}
+
+	reports := make([]HealthReport, len(tools))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, t := range tools {
+		wg.Add(1)
+		go func(i int, t Tool) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			reports[i] = CheckHealth(ctx, t, timeout)
+			logger.Debugw("tool.health.check", "tool", reports[i].Tool, "status", reports[i].Status,
+				"latency_ms", reports[i].LatencyMS, "error", reports[i].Error)
+		}(i, t)
+	}
+	wg.Wait()
+
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].Tool < reports[j].Tool
+	})
+	return reports, nil
+}
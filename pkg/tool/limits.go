@@ -0,0 +1,229 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"openmanus-go/pkg/config"
+)
+
+// Limiter 按工具和 MCP 服务器限制并发数和调用频率
+//
+// 并发限制使用信号量，频率限制使用令牌桶；超出限制的调用排队等待，等待可被 context 取消。
+// Limiter 挂在注册表上，共享同一注册表的所有执行器（多个 Agent、并行执行）共用同一组限制。
+type Limiter struct {
+	mu      sync.Mutex
+	specs   config.ToolLimitsConfig
+	tools   map[string]*limit
+	servers map[string]*limit
+}
+
+// limit 单个工具或服务器的限制状态
+type limit struct {
+	sem    chan struct{} // 并发信号量，nil 表示不限制
+	bucket *tokenBucket  // 令牌桶，nil 表示不限制
+}
+
+// NewLimiter 根据配置创建限制器，未配置任何限制时返回 nil
+func NewLimiter(cfg config.ToolLimitsConfig) (*Limiter, error) {
+	if err := validateLimitSpec(cfg.Default); err != nil {
+		return nil, fmt.Errorf("invalid tools.limits.default: %w", err)
+	}
+	for name, spec := range cfg.Tools {
+		if err := validateLimitSpec(spec); err != nil {
+			return nil, fmt.Errorf("invalid tools.limits.tools.%s: %w", name, err)
+		}
+	}
+	for name, spec := range cfg.Servers {
+		if err := validateLimitSpec(spec); err != nil {
+			return nil, fmt.Errorf("invalid tools.limits.servers.%s: %w", name, err)
+		}
+	}
+	if len(cfg.Tools) == 0 && len(cfg.Servers) == 0 && !limitSpecSet(cfg.Default) {
+		return nil, nil
+	}
+
+	return &Limiter{
+		specs:   cfg,
+		tools:   make(map[string]*limit),
+		servers: make(map[string]*limit),
+	}, nil
+}
+
+// limitSpecSet 限制是否生效
+func limitSpecSet(spec config.ToolLimitConfig) bool {
+	return spec.MaxConcurrent > 0 || spec.Rate > 0
+}
+
+func validateLimitSpec(spec config.ToolLimitConfig) error {
+	if spec.MaxConcurrent < 0 || spec.Rate < 0 || spec.Burst < 0 {
+		return fmt.Errorf("max_concurrent, rate and burst must be non-negative")
+	}
+	return nil
+}
+
+// Acquire 等待工具（及其所属 MCP 服务器）的并发和频率配额
+//
+// 返回释放函数和排队等待的总时间；context 取消时返回错误，已获取的配额会被释放。
+// 总是先获取服务器级配额再获取工具级配额，避免交叉等待造成死锁。
+func (l *Limiter) Acquire(ctx context.Context, toolName, serverName string) (func(), time.Duration, error) {
+	if l == nil {
+		return func() {}, 0, nil
+	}
+
+	var limits []*limit
+	if serverName != "" {
+		if lim := l.get(l.servers, l.specs.Servers, serverName, config.ToolLimitConfig{}); lim != nil {
+			limits = append(limits, lim)
+		}
+	}
+	if lim := l.get(l.tools, l.specs.Tools, toolName, l.specs.Default); lim != nil {
+		limits = append(limits, lim)
+	}
+
+	start := time.Now()
+	acquired := make([]*limit, 0, len(limits))
+	release := func() {
+		for _, lim := range acquired {
+			if lim.sem != nil {
+				<-lim.sem
+			}
+		}
+	}
+
+	for _, lim := range limits {
+		if lim.sem != nil {
+			select {
+			case lim.sem <- struct{}{}:
+			case <-ctx.Done():
+				release()
+				return func() {}, time.Since(start), ctx.Err()
+			}
+		}
+		acquired = append(acquired, lim)
+
+		if lim.bucket != nil {
+			if _, err := lim.bucket.wait(ctx); err != nil {
+				release()
+				return func() {}, time.Since(start), err
+			}
+		}
+	}
+
+	return release, time.Since(start), nil
+}
+
+// Describe 返回工具的限制说明，未限制时返回空字符串
+func (l *Limiter) Describe(toolName string) string {
+	if l == nil {
+		return ""
+	}
+	spec, ok := l.specs.Tools[toolName]
+	if !ok {
+		spec = l.specs.Default
+	}
+	return describeLimitSpec(spec)
+}
+
+func describeLimitSpec(spec config.ToolLimitConfig) string {
+	switch {
+	case spec.MaxConcurrent > 0 && spec.Rate > 0:
+		return fmt.Sprintf("max %d concurrent, %.4g/s", spec.MaxConcurrent, spec.Rate)
+	case spec.MaxConcurrent > 0:
+		return fmt.Sprintf("max %d concurrent", spec.MaxConcurrent)
+	case spec.Rate > 0:
+		return fmt.Sprintf("%.4g/s", spec.Rate)
+	}
+	return ""
+}
+
+// get 返回名称对应的限制状态，首次使用时按配置创建；未单独配置时使用 fallback（每个名称独立计数）
+func (l *Limiter) get(states map[string]*limit, specs map[string]config.ToolLimitConfig, name string, fallback config.ToolLimitConfig) *limit {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if lim, ok := states[name]; ok {
+		return lim
+	}
+	spec, ok := specs[name]
+	if !ok {
+		spec = fallback
+	}
+	if !limitSpecSet(spec) {
+		states[name] = nil
+		return nil
+	}
+
+	lim := &limit{}
+	if spec.MaxConcurrent > 0 {
+		lim.sem = make(chan struct{}, spec.MaxConcurrent)
+	}
+	if spec.Rate > 0 {
+		lim.bucket = newTokenBucket(spec.Rate, spec.Burst)
+	}
+	states[name] = lim
+	return lim
+}
+
+// tokenBucket 令牌桶
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64 // 每秒补充的令牌数
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// reserve 取出一个令牌并返回需要等待的时间
+func (b *tokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// refund 归还一个未使用的令牌（等待被取消时）
+func (b *tokenBucket) refund() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens++
+}
+
+// wait 取出一个令牌，必要时等待，返回实际等待时间
+func (b *tokenBucket) wait(ctx context.Context) (time.Duration, error) {
+	delay := b.reserve()
+	if delay <= 0 {
+		return 0, nil
+	}
+
+	start := time.Now()
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		b.refund()
+		return time.Since(start), ctx.Err()
+	case <-timer.C:
+		return time.Since(start), nil
+	}
+}
@@ -0,0 +1,317 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"openmanus-go/pkg/config"
+	"openmanus-go/pkg/logger"
+)
+
+// Call 表示一次经过中间件链的工具调用
+type Call struct {
+	Tool   string         // 工具名称
+	Args   map[string]any // 调用参数
+	Info   ToolInfo       // 工具信息（类型、MCP 服务器等）
+	Target Tool           // 被调用的工具实例
+
+	// CacheStatus 结果缓存状态（CacheHit / CacheMiss），未经过缓存时为空
+	CacheStatus string
+
+	// Wait 在限流或并发限制上排队等待的时间
+	Wait time.Duration
+
+	// Redact 需要在日志中隐藏的参数名（小写），由 RedactionMiddleware 填充
+	Redact map[string]bool
+}
+
+// Invoker 执行一次工具调用
+type Invoker func(ctx context.Context, call *Call) (map[string]any, error)
+
+// Middleware 包装 Invoker，用于实现日志、指标、缓存、限流等横切逻辑
+//
+// 注册表级中间件按 Use 的顺序由外到内执行，随后是工具级中间件，最内层为工具本身。
+type Middleware func(next Invoker) Invoker
+
+// DefaultRedactKeys 默认在日志中隐藏的参数名（按小写子串匹配）
+var DefaultRedactKeys = []string{"password", "passwd", "secret", "token", "api_key", "apikey", "authorization", "credential"}
+
+// redactedValue 日志中替代敏感参数的占位符
+const redactedValue = "***"
+
+// SafeArgs 返回用于日志的参数副本，敏感参数被替换为占位符
+func (c *Call) SafeArgs() map[string]any {
+	return redactMap(c.Args, c.Redact)
+}
+
+// redactMap 递归替换敏感字段：命中 DefaultRedactKeys 子串或 extra 中的精确名称
+func redactMap(args map[string]any, extra map[string]bool) map[string]any {
+	if args == nil {
+		return nil
+	}
+	safe := make(map[string]any, len(args))
+	for key, value := range args {
+		if isSensitiveKey(key, extra) {
+			safe[key] = redactedValue
+			continue
+		}
+		switch v := value.(type) {
+		case map[string]any:
+			safe[key] = redactMap(v, extra)
+		default:
+			safe[key] = value
+		}
+	}
+	return safe
+}
+
+func isSensitiveKey(key string, extra map[string]bool) bool {
+	lower := strings.ToLower(key)
+	if extra[lower] {
+		return true
+	}
+	for _, sensitive := range DefaultRedactKeys {
+		if strings.Contains(lower, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// chain 将中间件依次包装到 Invoker 上，mws[0] 位于最外层
+func chain(invoker Invoker, mws ...Middleware) Invoker {
+	for i := len(mws) - 1; i >= 0; i-- {
+		invoker = mws[i](invoker)
+	}
+	return invoker
+}
+
+// DefaultMiddlewares 返回注册表默认使用的中间件（控制台与结构化日志）
+func DefaultMiddlewares() []Middleware {
+	return []Middleware{LoggingMiddleware()}
+}
+
+// LoggingMiddleware 输出工具调用的控制台日志和结构化日志
+func LoggingMiddleware() Middleware {
+	return func(next Invoker) Invoker {
+		return func(ctx context.Context, call *Call) (map[string]any, error) {
+			toolTypeSymbol := "🔧" // 默认内置工具
+			toolTypeText := "Built-in"
+			switch call.Info.Type {
+			case ToolTypeMCP:
+				toolTypeSymbol = "🌐"
+				toolTypeText = "MCP"
+			case ToolTypePlugin:
+				toolTypeSymbol = "🧩"
+				toolTypeText = "Plugin"
+			case ToolTypeOpenAPI:
+				toolTypeSymbol = "🔌"
+				toolTypeText = "OpenAPI"
+			}
+
+			logger.Infof("🔧 [TOOL] Executing %s %s (%s tool)", toolTypeSymbol, call.Tool, toolTypeText)
+			if call.Info.Type == ToolTypeMCP && call.Info.ServerName != "" {
+				logger.Infof("📡 [SERVER] Calling MCP server: %s", call.Info.ServerName)
+			}
+			logger.Debugw("tool.invoke.start", "tool", call.Tool, "args", call.SafeArgs())
+
+			start := time.Now()
+			result, err := next(ctx, call)
+			latency := time.Since(start).Milliseconds()
+
+			if err != nil {
+				logger.Warnw("tool.exec.error", "tool", call.Tool, "error", err, "latency_ms", latency)
+				return result, err
+			}
+			logger.Infow("tool.exec.ok", "tool", call.Tool, "latency_ms", latency, "output_preview", previewResult(result))
+			return result, nil
+		}
+	}
+}
+
+// RedactionMiddleware 标记需要在日志中隐藏的参数名，需位于 LoggingMiddleware 之前
+func RedactionMiddleware(keys ...string) Middleware {
+	redact := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		redact[strings.ToLower(key)] = true
+	}
+	return func(next Invoker) Invoker {
+		return func(ctx context.Context, call *Call) (map[string]any, error) {
+			if call.Redact == nil {
+				call.Redact = make(map[string]bool, len(redact))
+			}
+			for key := range redact {
+				call.Redact[key] = true
+			}
+			return next(ctx, call)
+		}
+	}
+}
+
+// ToolMetrics 单个工具的调用统计
+type ToolMetrics struct {
+	Calls        int64         `json:"calls"`
+	Errors       int64         `json:"errors"`
+	TotalLatency time.Duration `json:"total_latency"`
+	MaxLatency   time.Duration `json:"max_latency"`
+}
+
+// AvgLatency 返回平均延迟
+func (m ToolMetrics) AvgLatency() time.Duration {
+	if m.Calls == 0 {
+		return 0
+	}
+	return m.TotalLatency / time.Duration(m.Calls)
+}
+
+// Metrics 按工具汇总调用次数、错误数和延迟
+type Metrics struct {
+	mu    sync.Mutex
+	tools map[string]*ToolMetrics
+}
+
+// NewMetrics 创建调用统计
+func NewMetrics() *Metrics {
+	return &Metrics{tools: make(map[string]*ToolMetrics)}
+}
+
+// Record 记录一次调用
+func (m *Metrics) Record(toolName string, latency time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats, ok := m.tools[toolName]
+	if !ok {
+		stats = &ToolMetrics{}
+		m.tools[toolName] = stats
+	}
+	stats.Calls++
+	if err != nil {
+		stats.Errors++
+	}
+	stats.TotalLatency += latency
+	if latency > stats.MaxLatency {
+		stats.MaxLatency = latency
+	}
+}
+
+// Snapshot 返回当前统计的副本
+func (m *Metrics) Snapshot() map[string]ToolMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := make(map[string]ToolMetrics, len(m.tools))
+	for name, stats := range m.tools {
+		snapshot[name] = *stats
+	}
+	return snapshot
+}
+
+// MetricsMiddleware 将每次调用的结果和延迟记录到 metrics
+func MetricsMiddleware(metrics *Metrics) Middleware {
+	return func(next Invoker) Invoker {
+		return func(ctx context.Context, call *Call) (map[string]any, error) {
+			start := time.Now()
+			result, err := next(ctx, call)
+			metrics.Record(call.Tool, time.Since(start), err)
+			return result, err
+		}
+	}
+}
+
+// LimitMiddleware 等待工具（及其所属 MCP 服务器）的并发和频率配额，等待时间累加到 Call.Wait
+//
+// 注册表总是将其放在中间件链最内层：被策略拒绝的调用和缓存命中不占用配额。
+func LimitMiddleware(limiter *Limiter) Middleware {
+	return func(next Invoker) Invoker {
+		return func(ctx context.Context, call *Call) (map[string]any, error) {
+			release, wait, err := limiter.Acquire(ctx, call.Tool, call.Info.ServerName)
+			call.Wait += wait
+			if err != nil {
+				logger.Warnw("tool.limit.wait_aborted", "tool", call.Tool, "wait_ms", wait.Milliseconds(), "error", err)
+				return nil, fmt.Errorf("aborted while waiting for tool limits: %w", err)
+			}
+			defer release()
+			if wait > time.Millisecond {
+				logger.Infof("⏳ [TOOL_LIMIT] Waited %s for %s", wait.Round(time.Millisecond), call.Tool)
+			}
+			return next(ctx, call)
+		}
+	}
+}
+
+// ConfigureMiddlewares 按配置重建注册表的中间件链
+//
+// 注册表级：RedactionMiddleware → LoggingMiddleware → PolicyMiddleware → [CachingMiddleware]；
+// tools.middleware.overrides 中的工具级缓存叠加在注册表级中间件之后；
+// tools.limits 设置的 Limiter 由注册表作为最内层的 LimitMiddleware 应用。
+func ConfigureMiddlewares(r *Registry, cfg config.ToolsConfig) error {
+	cache, err := NewResultCacheFromConfig(cfg.Cache)
+	if err != nil {
+		return err
+	}
+
+	limiter, err := NewLimiter(cfg.Limits)
+	if err != nil {
+		return err
+	}
+	r.SetLimiter(limiter)
+
+	policy, err := NewPolicy(cfg.Policy)
+	if err != nil {
+		return err
+	}
+
+	mws := []Middleware{RedactionMiddleware(cfg.Middleware.RedactKeys...), LoggingMiddleware(), PolicyMiddleware(policy)}
+	if cfg.Cache.Enabled {
+		ttl, err := parseCacheTTL(cfg.Cache.TTL)
+		if err != nil {
+			return fmt.Errorf("invalid tools.cache.ttl: %w", err)
+		}
+		mws = append(mws, CachingMiddleware(CacheOptions{Cache: cache, TTL: ttl}))
+		logger.Infof("💾 [TOOL_CACHE] Result cache enabled: %s (ttl: %s)", cfg.Cache.Backend, ttl)
+	}
+	r.SetMiddlewares(mws...)
+
+	for name, override := range cfg.Middleware.Overrides {
+		var toolMws []Middleware
+		if override.CacheTTL != "" {
+			ttl, err := parseCacheTTL(override.CacheTTL)
+			if err != nil {
+				return fmt.Errorf("invalid tools.middleware.overrides.%s.cache_ttl: %w", name, err)
+			}
+			if ttl > 0 {
+				toolMws = append(toolMws, CachingMiddleware(CacheOptions{Cache: cache, TTL: ttl, All: true}))
+			}
+		}
+		r.UseFor(name, toolMws...)
+	}
+	return nil
+}
+
+// NewResultCacheFromConfig 根据配置创建结果缓存后端（memory 或 disk）
+func NewResultCacheFromConfig(cfg config.ToolCacheConfig) (ResultCache, error) {
+	switch cfg.Backend {
+	case "", "memory":
+		return NewMemoryResultCache(cfg.MaxEntries), nil
+	case "disk":
+		cache, err := NewDiskResultCache(cfg.Dir)
+		if err != nil {
+			return nil, err
+		}
+		return cache, nil
+	default:
+		return nil, fmt.Errorf("unsupported tools.cache.backend: %s", cfg.Backend)
+	}
+}
+
+// parseCacheTTL 解析缓存时长，空字符串表示 0
+func parseCacheTTL(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(value)
+}
@@ -0,0 +1,678 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"openmanus-go/pkg/config"
+	"openmanus-go/pkg/logger"
+)
+
+// openAPIMethods OpenAPI 路径项中表示操作的字段
+var openAPIMethods = []string{"get", "put", "post", "delete", "patch", "head", "options"}
+
+// 默认值和限制
+const (
+	defaultOpenAPITimeout   = 30 * time.Second
+	maxOpenAPIResponseBytes = 10 * 1024 * 1024
+	maxToolNameLength       = 64
+	maxOpenAPIDescription   = 1024 // 字符数
+	maxOpenAPISchemaDepth   = 32
+)
+
+// invalidToolNameChars 工具名称中不允许的字符（LLM function name 只允许字母、数字、下划线和连字符）
+var invalidToolNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// pathParamPattern 匹配路径模板中的参数，如 /users/{id}
+var pathParamPattern = regexp.MustCompile(`\{([^}]+)\}`)
+
+// openAPIParam 工具参数与 HTTP 请求位置的映射
+type openAPIParam struct {
+	In   string // path | query | header | body
+	Name string // 请求中的原始名称
+}
+
+// OpenAPITool 由 OpenAPI 操作生成的工具
+type OpenAPITool struct {
+	*BaseTool
+	service     string
+	method      string
+	path        string
+	baseURL     string
+	params      map[string]openAPIParam // 键为工具参数名
+	contentType string                  // 请求体类型，空表示无请求体
+	auth        config.OpenAPIAuthConfig
+	headers     map[string]string
+	client      *http.Client
+}
+
+// Type 返回工具类型（OpenAPI 工具）
+func (t *OpenAPITool) Type() ToolType {
+	return ToolTypeOpenAPI
+}
+
+// ServerName 返回服务名称（可用于 tools.limits.servers）
+func (t *OpenAPITool) ServerName() string {
+	return t.service
+}
+
+// CachePolicy 只缓存 GET 和 HEAD 请求
+func (t *OpenAPITool) CachePolicy(args map[string]any) (time.Duration, bool) {
+	switch t.method {
+	case http.MethodGet, http.MethodHead:
+		return time.Minute, true
+	}
+	return 0, false
+}
+
+// Invoke 按参数位置组装 HTTP 请求并返回解析后的响应
+func (t *OpenAPITool) Invoke(ctx context.Context, args map[string]any) (map[string]any, error) {
+	path := t.path
+	query := url.Values{}
+	headers := http.Header{}
+	var body any
+	for name, value := range args {
+		param, ok := t.params[name]
+		if !ok || value == nil {
+			continue
+		}
+		switch param.In {
+		case "path":
+			path = strings.ReplaceAll(path, "{"+param.Name+"}", url.PathEscape(formatParamValue(value)))
+		case "query":
+			if values, ok := value.([]any); ok {
+				for _, item := range values {
+					query.Add(param.Name, formatParamValue(item))
+				}
+			} else {
+				query.Set(param.Name, formatParamValue(value))
+			}
+		case "header":
+			headers.Set(param.Name, formatParamValue(value))
+		case "body":
+			body = value
+		}
+	}
+	if strings.Contains(path, "{") {
+		return nil, fmt.Errorf("missing path parameters for %s", t.path)
+	}
+
+	reqBody, err := t.encodeBody(body)
+	if err != nil {
+		return nil, err
+	}
+	t.applyAuth(headers, query)
+
+	target := strings.TrimRight(t.baseURL, "/") + path
+	if encoded := query.Encode(); encoded != "" {
+		target += "?" + encoded
+	}
+	req, err := http.NewRequestWithContext(ctx, t.method, target, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for key, value := range t.headers {
+		req.Header.Set(key, value)
+	}
+	for key, values := range headers {
+		req.Header[key] = values
+	}
+	if reqBody != nil {
+		req.Header.Set("Content-Type", t.contentType)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "OpenManus-Go/1.0")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxOpenAPIResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	result := map[string]any{
+		"success":      resp.StatusCode >= 200 && resp.StatusCode < 300,
+		"status_code":  resp.StatusCode,
+		"content_type": resp.Header.Get("Content-Type"),
+	}
+	var data any
+	if len(respBody) > 0 && json.Unmarshal(respBody, &data) == nil {
+		result["data"] = data
+	} else {
+		result["body"] = string(respBody)
+	}
+	if !result["success"].(bool) {
+		result["error"] = fmt.Sprintf("%s %s returned HTTP %d", t.method, t.path, resp.StatusCode)
+	}
+	return result, nil
+}
+
+// Ping 检查服务地址是否可达，任何 HTTP 响应（包括 4xx）都视为可达
+func (t *OpenAPITool) Ping(ctx context.Context) (HealthStatus, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, t.baseURL, nil)
+	if err != nil {
+		return HealthStatus{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	start := time.Now()
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return HealthStatus{}, fmt.Errorf("service %s is unreachable: %w", t.service, err)
+	}
+	resp.Body.Close()
+	return HealthStatus{
+		Latency: time.Since(start),
+		Detail:  fmt.Sprintf("HTTP %d from %s", resp.StatusCode, t.baseURL),
+	}, nil
+}
+
+// encodeBody 按请求体类型编码
+func (t *OpenAPITool) encodeBody(body any) (io.Reader, error) {
+	if body == nil || t.contentType == "" {
+		return nil, nil
+	}
+	switch {
+	case strings.Contains(t.contentType, "json"):
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request body: %w", err)
+		}
+		return bytes.NewReader(data), nil
+	case t.contentType == "application/x-www-form-urlencoded":
+		form := url.Values{}
+		if fields, ok := body.(map[string]any); ok {
+			for key, value := range fields {
+				form.Set(key, formatParamValue(value))
+			}
+		}
+		return strings.NewReader(form.Encode()), nil
+	default:
+		return strings.NewReader(formatParamValue(body)), nil
+	}
+}
+
+// applyAuth 按配置添加认证信息
+func (t *OpenAPITool) applyAuth(headers http.Header, query url.Values) {
+	switch t.auth.Type {
+	case "bearer":
+		headers.Set("Authorization", "Bearer "+t.auth.Token)
+	case "basic":
+		credentials := base64.StdEncoding.EncodeToString([]byte(t.auth.Username + ":" + t.auth.Password))
+		headers.Set("Authorization", "Basic "+credentials)
+	case "header":
+		headers.Set(t.auth.Name, t.auth.Value)
+	case "query":
+		query.Set(t.auth.Name, t.auth.Value)
+	}
+}
+
+// formatParamValue 将参数值格式化为字符串，对象和数组编码为 JSON
+func formatParamValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
+
+// LoadOpenAPISpec 读取 OpenAPI 规范文件（JSON 或 YAML），返回标准 JSON 类型的文档
+func LoadOpenAPISpec(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read openapi spec: %w", err)
+	}
+
+	var raw any
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		err = json.Unmarshal(trimmed, &raw)
+	} else {
+		err = yaml.Unmarshal(data, &raw)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse openapi spec %s: %w", path, err)
+	}
+
+	// YAML 解码得到 int 等类型，经 JSON 往返统一为 float64 / map[string]any
+	normalized, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to normalize openapi spec: %w", err)
+	}
+	var spec map[string]any
+	if err := json.Unmarshal(normalized, &spec); err != nil {
+		return nil, fmt.Errorf("failed to normalize openapi spec: %w", err)
+	}
+	version, _ := spec["openapi"].(string)
+	if !strings.HasPrefix(version, "3.") {
+		return nil, fmt.Errorf("unsupported openapi version %q in %s (only 3.x is supported)", version, path)
+	}
+	return spec, nil
+}
+
+// NewOpenAPITools 将规范中的每个操作转换为工具
+func NewOpenAPITools(cfg config.OpenAPIConfig) ([]Tool, error) {
+	spec, err := LoadOpenAPISpec(cfg.Spec)
+	if err != nil {
+		return nil, err
+	}
+
+	baseURL, err := openAPIBaseURL(spec, cfg.BaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("openapi %s: %w", cfg.Name, err)
+	}
+	timeout, err := parseDurationOr(cfg.Timeout, defaultOpenAPITimeout)
+	if err != nil {
+		return nil, fmt.Errorf("openapi %s: invalid timeout: %w", cfg.Name, err)
+	}
+	client := &http.Client{Timeout: timeout}
+
+	auth := cfg.Auth
+	auth.Token = os.ExpandEnv(auth.Token)
+	auth.Password = os.ExpandEnv(auth.Password)
+	auth.Value = os.ExpandEnv(auth.Value)
+	headers := make(map[string]string, len(cfg.Headers))
+	for key, value := range cfg.Headers {
+		headers[key] = os.ExpandEnv(value)
+	}
+
+	include := make(map[string]bool, len(cfg.Operations))
+	for _, op := range cfg.Operations {
+		include[op] = true
+	}
+
+	paths, _ := spec["paths"].(map[string]any)
+	pathKeys := make([]string, 0, len(paths))
+	for path := range paths {
+		pathKeys = append(pathKeys, path)
+	}
+	sort.Strings(pathKeys)
+
+	var tools []Tool
+	for _, path := range pathKeys {
+		item, ok := resolveOpenAPIRef(spec, paths[path]).(map[string]any)
+		if !ok {
+			continue
+		}
+		for _, method := range openAPIMethods {
+			operation, ok := item[method].(map[string]any)
+			if !ok {
+				continue
+			}
+			operationID, _ := operation["operationId"].(string)
+			if len(include) > 0 && !include[operationID] {
+				continue
+			}
+
+			t, err := newOpenAPITool(spec, cfg.Name, path, method, item, operation)
+			if err != nil {
+				logger.Warnw("tool.openapi.skip_operation", "service", cfg.Name, "method", method, "path", path, "error", err)
+				continue
+			}
+			t.baseURL = baseURL
+			t.auth = auth
+			t.headers = headers
+			t.client = client
+			tools = append(tools, t)
+		}
+	}
+	return tools, nil
+}
+
+// newOpenAPITool 根据单个操作生成工具：参数来自路径项和操作的 parameters 以及 requestBody
+func newOpenAPITool(spec map[string]any, service, path, method string, item, operation map[string]any) (*OpenAPITool, error) {
+	t := &OpenAPITool{
+		service: service,
+		method:  strings.ToUpper(method),
+		path:    path,
+		params:  make(map[string]openAPIParam),
+	}
+
+	properties := make(map[string]any)
+	var required []string
+
+	// 操作级参数覆盖同名同位置的路径项参数
+	merged := make(map[string]map[string]any)
+	var order []string
+	for _, source := range []any{item["parameters"], operation["parameters"]} {
+		list, _ := source.([]any)
+		for _, raw := range list {
+			param, ok := resolveOpenAPIRef(spec, raw).(map[string]any)
+			if !ok {
+				continue
+			}
+			name, _ := param["name"].(string)
+			in, _ := param["in"].(string)
+			key := in + ":" + name
+			if _, exists := merged[key]; !exists {
+				order = append(order, key)
+			}
+			merged[key] = param
+		}
+	}
+
+	for _, key := range order {
+		param := merged[key]
+		name, _ := param["name"].(string)
+		in, _ := param["in"].(string)
+		if name == "" || in == "cookie" {
+			continue
+		}
+		if in == "header" && isManagedHeader(name) {
+			continue
+		}
+
+		argName := name
+		if _, taken := t.params[argName]; taken {
+			argName = in + "_" + name
+		}
+		schema := openAPISchema(spec, param["schema"], map[string]bool{})
+		if desc, ok := param["description"].(string); ok && desc != "" {
+			schema["description"] = desc
+		}
+		properties[argName] = schema
+		t.params[argName] = openAPIParam{In: in, Name: name}
+		if req, _ := param["required"].(bool); req || in == "path" {
+			required = append(required, argName)
+		}
+	}
+
+	if requestBody, ok := resolveOpenAPIRef(spec, operation["requestBody"]).(map[string]any); ok {
+		contentType, media := pickMediaType(requestBody["content"])
+		if contentType != "" {
+			schema := openAPISchema(spec, media["schema"], map[string]bool{})
+			if desc, ok := requestBody["description"].(string); ok && desc != "" {
+				schema["description"] = desc
+			}
+			if len(SchemaTypes(schema)) == 0 && !strings.Contains(contentType, "json") {
+				schema["type"] = "string"
+			}
+			properties["body"] = schema
+			t.params["body"] = openAPIParam{In: "body", Name: "body"}
+			t.contentType = contentType
+			if req, _ := requestBody["required"].(bool); req {
+				required = append(required, "body")
+			}
+		}
+	}
+
+	for _, match := range pathParamPattern.FindAllStringSubmatch(path, -1) {
+		if !t.hasPathParam(match[1]) {
+			return nil, fmt.Errorf("path parameter %q is not declared", match[1])
+		}
+	}
+
+	name := openAPIToolName(service, method, path, operation)
+	description := openAPIDescription(method, path, operation)
+	inputSchema := CreateJSONSchema("object", properties, required)
+	outputSchema := CreateJSONSchema("object", map[string]any{
+		"success":      BooleanProperty("是否返回 2xx 状态码"),
+		"status_code":  NumberProperty("HTTP 状态码"),
+		"content_type": StringProperty("响应类型"),
+		"data":         map[string]any{"description": "解析后的 JSON 响应"},
+		"body":         StringProperty("非 JSON 响应的原始内容"),
+		"error":        StringProperty("错误信息"),
+	}, []string{"success", "status_code"})
+	t.BaseTool = NewBaseTool(name, description, inputSchema, outputSchema)
+	return t, nil
+}
+
+// hasPathParam 判断是否声明了指定的路径参数
+func (t *OpenAPITool) hasPathParam(name string) bool {
+	for _, param := range t.params {
+		if param.In == "path" && param.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isManagedHeader 由工具自身设置的请求头不作为参数暴露
+func isManagedHeader(name string) bool {
+	switch strings.ToLower(name) {
+	case "authorization", "content-type", "accept":
+		return true
+	}
+	return false
+}
+
+// pickMediaType 选择请求体的媒体类型，优先 JSON
+func pickMediaType(content any) (string, map[string]any) {
+	media, _ := content.(map[string]any)
+	if len(media) == 0 {
+		return "", nil
+	}
+	types := make([]string, 0, len(media))
+	for contentType := range media {
+		types = append(types, contentType)
+	}
+	sort.Strings(types)
+	for _, preferred := range []string{"application/json", "application/x-www-form-urlencoded"} {
+		if m, ok := media[preferred].(map[string]any); ok {
+			return preferred, m
+		}
+	}
+	for _, contentType := range types {
+		if strings.Contains(contentType, "json") {
+			m, _ := media[contentType].(map[string]any)
+			return contentType, m
+		}
+	}
+	m, _ := media[types[0]].(map[string]any)
+	return types[0], m
+}
+
+// openAPIToolName 工具名称：服务名_operationId，缺少 operationId 时使用方法和路径
+func openAPIToolName(service, method, path string, operation map[string]any) string {
+	base, _ := operation["operationId"].(string)
+	if base == "" {
+		base = method + "_" + path
+	}
+	name := base
+	if service != "" {
+		name = service + "_" + base
+	}
+	name = strings.Trim(invalidToolNameChars.ReplaceAllString(name, "_"), "_")
+	if len(name) > maxToolNameLength {
+		name = name[:maxToolNameLength]
+	}
+	return name
+}
+
+// openAPIDescription 工具描述：summary + description + 方法和路径
+func openAPIDescription(method, path string, operation map[string]any) string {
+	var parts []string
+	for _, key := range []string{"summary", "description"} {
+		if text, ok := operation[key].(string); ok && strings.TrimSpace(text) != "" {
+			parts = append(parts, strings.TrimSpace(text))
+		}
+	}
+	description := strings.Join(parts, ". ")
+	description = truncateRunes(description, maxOpenAPIDescription)
+	endpoint := fmt.Sprintf("(%s %s)", strings.ToUpper(method), path)
+	if description == "" {
+		return endpoint
+	}
+	return description + " " + endpoint
+}
+
+// openAPIBaseURL 确定请求的基础地址：配置优先，其次是规范中的第一个 server（替换变量默认值）
+func openAPIBaseURL(spec map[string]any, override string) (string, error) {
+	if override != "" {
+		return override, nil
+	}
+	servers, _ := spec["servers"].([]any)
+	if len(servers) == 0 {
+		return "", fmt.Errorf("base_url is required when the spec declares no servers")
+	}
+	server, _ := servers[0].(map[string]any)
+	serverURL, _ := server["url"].(string)
+	if variables, ok := server["variables"].(map[string]any); ok {
+		for name, raw := range variables {
+			variable, _ := raw.(map[string]any)
+			if def, ok := variable["default"].(string); ok {
+				serverURL = strings.ReplaceAll(serverURL, "{"+name+"}", def)
+			}
+		}
+	}
+	if !strings.HasPrefix(serverURL, "http://") && !strings.HasPrefix(serverURL, "https://") {
+		return "", fmt.Errorf("server url %q is not absolute; set base_url", serverURL)
+	}
+	return serverURL, nil
+}
+
+// resolveOpenAPIRef 解析 components 中的 $ref（参数、请求体、路径项等），非引用原样返回
+func resolveOpenAPIRef(spec map[string]any, value any) any {
+	for i := 0; i < maxOpenAPISchemaDepth; i++ {
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return value
+		}
+		ref, ok := obj["$ref"].(string)
+		if !ok {
+			return value
+		}
+		resolved, err := resolvePointer(spec, ref)
+		if err != nil {
+			logger.Warnw("tool.openapi.unresolved_ref", "ref", ref, "error", err)
+			return nil
+		}
+		value = resolved
+	}
+	return value
+}
+
+// openAPISchema 将 OpenAPI 3.0 Schema 转换为自包含的 JSON Schema：
+// 内联 $ref、nullable 转为 type 数组、布尔形式的 exclusiveMinimum/Maximum 转为数值形式，并去掉仅用于文档的字段。
+// refs 记录当前路径上已展开的 $ref，递归引用（如树节点）不再展开，替换为不限制结构的对象
+func openAPISchema(spec map[string]any, raw any, refs map[string]bool) map[string]any {
+	for i := 0; i < maxOpenAPISchemaDepth; i++ {
+		obj, ok := raw.(map[string]any)
+		if !ok {
+			break
+		}
+		ref, ok := obj["$ref"].(string)
+		if !ok {
+			break
+		}
+		if refs[ref] {
+			return map[string]any{
+				"type":        "object",
+				"description": fmt.Sprintf("Recursive reference to %s (not expanded)", ref),
+			}
+		}
+		resolved, err := resolvePointer(spec, ref)
+		if err != nil {
+			logger.Warnw("tool.openapi.unresolved_ref", "ref", ref, "error", err)
+			return map[string]any{}
+		}
+		refs[ref] = true
+		defer delete(refs, ref)
+		raw = resolved
+	}
+	obj, ok := raw.(map[string]any)
+	if !ok {
+		return map[string]any{}
+	}
+	if _, unresolved := obj["$ref"]; unresolved {
+		return map[string]any{}
+	}
+
+	schema := make(map[string]any, len(obj))
+	for key, value := range obj {
+		switch key {
+		case "example", "examples", "xml", "externalDocs", "discriminator", "deprecated", "readOnly", "writeOnly", "nullable":
+			continue
+		case "properties", "patternProperties":
+			if props, ok := value.(map[string]any); ok {
+				converted := make(map[string]any, len(props))
+				for name, prop := range props {
+					converted[name] = openAPISchema(spec, prop, refs)
+				}
+				schema[key] = converted
+			}
+		case "items", "not", "additionalProperties":
+			if _, isBool := value.(bool); isBool {
+				schema[key] = value
+			} else {
+				schema[key] = openAPISchema(spec, value, refs)
+			}
+		case "allOf", "anyOf", "oneOf":
+			if list, ok := value.([]any); ok {
+				converted := make([]any, len(list))
+				for i, item := range list {
+					converted[i] = openAPISchema(spec, item, refs)
+				}
+				schema[key] = converted
+			}
+		default:
+			schema[key] = value
+		}
+	}
+
+	if nullable, _ := obj["nullable"].(bool); nullable {
+		if typ, ok := schema["type"].(string); ok {
+			schema["type"] = []any{typ, "null"}
+		}
+	}
+	for _, bound := range []string{"Minimum", "Maximum"} {
+		exclusiveKey, limitKey := "exclusive"+bound, strings.ToLower(bound)
+		if exclusive, ok := schema[exclusiveKey].(bool); ok {
+			if limit, hasLimit := schema[limitKey]; exclusive && hasLimit {
+				schema[exclusiveKey] = limit
+				delete(schema, limitKey)
+			} else {
+				delete(schema, exclusiveKey)
+			}
+		}
+	}
+	return schema
+}
+
+// LoadOpenAPITools 加载配置中的 OpenAPI 规范并将生成的工具注册到注册表，返回注册的工具数量
+//
+// 单个规范加载失败或单个工具注册失败只记录警告并跳过。
+func LoadOpenAPITools(r *Registry, cfgs []config.OpenAPIConfig) int {
+	total := 0
+	for _, cfg := range cfgs {
+		tools, err := NewOpenAPITools(cfg)
+		if err != nil {
+			logger.Warnw("tool.openapi.load_failed", "service", cfg.Name, "spec", cfg.Spec, "error", err)
+			continue
+		}
+		registered := 0
+		for _, t := range tools {
+			if err := r.Register(t); err != nil {
+				logger.Warnw("tool.openapi.register_failed", "service", cfg.Name, "tool", t.Name(), "error", err)
+				continue
+			}
+			registered++
+		}
+		total += registered
+		logger.Infof("🔌 [OPENAPI] Loaded %d tools from %s (%s)", registered, cfg.Name, cfg.Spec)
+	}
+	return total
+}
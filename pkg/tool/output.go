@@ -0,0 +1,192 @@
+package tool
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"openmanus-go/pkg/state"
+)
+
+// OutputValidation 工具输出的 OutputSchema 校验模式
+type OutputValidation string
+
+const (
+	OutputValidationOff    OutputValidation = "off"    // 不校验
+	OutputValidationWarn   OutputValidation = "warn"   // 记录警告，输出照常返回
+	OutputValidationStrict OutputValidation = "strict" // 不符合 Schema 的输出作为错误返回给规划器
+)
+
+// 输出摘要的格式参数
+const (
+	summaryStringChars = 80 // 单个字符串字段的最大字符数
+	summaryObjectChars = 60 // 对象、数组元素预览的最大字符数
+)
+
+// ParseOutputValidation 解析校验模式，空字符串使用 warn
+func ParseOutputValidation(mode string) (OutputValidation, error) {
+	switch OutputValidation(strings.ToLower(strings.TrimSpace(mode))) {
+	case "", OutputValidationWarn:
+		return OutputValidationWarn, nil
+	case OutputValidationOff:
+		return OutputValidationOff, nil
+	case OutputValidationStrict:
+		return OutputValidationStrict, nil
+	}
+	return "", fmt.Errorf("unsupported output validation mode: %s", mode)
+}
+
+// ValidateOutput 按 OutputSchema 校验成功的工具输出
+//
+// 输出先按 JSON 语义归一化（结构体、整数、具体类型的切片等），并去除执行器附加的 latency_ms；
+// 工具以 success=false 或 error 字段报告的失败结果不校验。
+func ValidateOutput(output map[string]any, schema map[string]any) []SchemaError {
+	if len(schema) == 0 || OutputError(output) != "" {
+		return nil
+	}
+	data := make(map[string]any, len(output))
+	for key, value := range output {
+		if key != state.OutputKeyLatency {
+			data[key] = value
+		}
+	}
+	return ValidateArgs(data, schema)
+}
+
+// OutputError 返回工具以 success=false 或 error 字段报告的失败信息，成功时返回空字符串
+//
+// 部分工具以结果字段而不是 error 返回值报告失败，缓存、输出校验和观测结果都以此判断调用是否失败。
+func OutputError(output map[string]any) string {
+	if msg, ok := output[state.OutputKeyError].(string); ok && msg != "" {
+		return msg
+	}
+	if success, ok := output[state.OutputKeySuccess].(bool); !ok || success {
+		return ""
+	}
+	if result, ok := output["result"].(string); ok && result != "" {
+		return result
+	}
+	return "tool reported failure (success=false)"
+}
+
+// SummarizeOutput 按 OutputSchema 生成一行输出摘要，最长 maxLen 个字符
+//
+// 字段顺序：Schema 的必填字段、其他 Schema 字段（按名称）、Schema 未声明的字段（按名称）；
+// success、error、latency_ms 等元数据不计入。字符串和数字原样显示，数组显示元素数和首个元素，
+// 对象显示紧凑 JSON。
+func SummarizeOutput(output map[string]any, schema map[string]any, maxLen int) string {
+	if output == nil {
+		return "No output"
+	}
+	if msg := OutputError(output); msg != "" {
+		return truncateSummary("Operation failed: "+msg, maxLen)
+	}
+
+	data := normalizeValue(state.OutputData(output)).(map[string]any)
+	if len(data) == 0 {
+		return "Operation completed successfully"
+	}
+
+	parts := make([]string, 0, len(data))
+	for _, key := range summaryKeys(data, schema) {
+		parts = append(parts, key+": "+summarizeValue(data[key]))
+	}
+	return truncateSummary(strings.Join(parts, "; "), maxLen)
+}
+
+// FormatOutputData 按 OutputSchema 的字段顺序（与 SummarizeOutput 相同）完整输出数据字段，不截断：
+// 只有一个字段时直接输出其值，字符串原样显示，其他值显示为 JSON；元数据字段不计入
+func FormatOutputData(output map[string]any, schema map[string]any) string {
+	data := state.OutputData(output)
+	if len(data) == 0 {
+		return ""
+	}
+	keys := summaryKeys(data, schema)
+	if len(keys) == 1 {
+		return formatDataValue(data[keys[0]])
+	}
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, key+": "+formatDataValue(data[key]))
+	}
+	return strings.Join(parts, "\n")
+}
+
+// formatDataValue 字符串原样返回，其他值格式化为 JSON
+func formatDataValue(value any) string {
+	if text, ok := value.(string); ok {
+		return text
+	}
+	if data, err := json.Marshal(value); err == nil {
+		return string(data)
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// summaryKeys 返回摘要中字段的顺序
+func summaryKeys(data map[string]any, schema map[string]any) []string {
+	keys := make([]string, 0, len(data))
+	seen := make(map[string]bool, len(data))
+	add := func(key string) {
+		if _, ok := data[key]; ok && !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+
+	if required, ok := schemaArray(schema["required"]); ok {
+		for _, key := range required {
+			if name, ok := key.(string); ok {
+				add(name)
+			}
+		}
+	}
+	if properties, ok := schema["properties"].(map[string]any); ok {
+		names := make([]string, 0, len(properties))
+		for name := range properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			add(name)
+		}
+	}
+
+	var extra []string
+	for key := range data {
+		if !seen[key] {
+			extra = append(extra, key)
+		}
+	}
+	sort.Strings(extra)
+	return append(keys, extra...)
+}
+
+// summarizeValue 格式化单个字段的值
+func summarizeValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return truncateSummary(strings.Join(strings.Fields(v), " "), summaryStringChars)
+	case []any:
+		if len(v) == 0 {
+			return "0 items"
+		}
+		return fmt.Sprintf("%d items, first: %s", len(v), truncateSummary(compactJSON(v[0]), summaryObjectChars))
+	case map[string]any:
+		return truncateSummary(compactJSON(v), summaryObjectChars)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// truncateSummary 按字符截断摘要
+func truncateSummary(s string, maxLen int) string {
+	runes := []rune(s)
+	if maxLen <= 0 || len(runes) <= maxLen {
+		return s
+	}
+	return string(runes[:maxLen]) + "..."
+}
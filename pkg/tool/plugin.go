@@ -0,0 +1,482 @@
+package tool
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+	"time"
+
+	"openmanus-go/pkg/config"
+	"openmanus-go/pkg/logger"
+)
+
+// 插件协议：每行一个 JSON 对象，请求写入插件的 stdin，响应从 stdout 读取（stderr 作为插件日志）
+//
+//	→ {"id":1,"method":"describe"}
+//	← {"id":1,"result":{"tools":[{"name":"...","description":"...","input_schema":{...},"output_schema":{...}}]}}
+//	→ {"id":2,"method":"invoke","params":{"tool":"...","args":{...}}}
+//	← {"id":2,"result":{...}}            成功
+//	← {"id":2,"error":"message"}         失败（也可以是 {"message":"..."} 对象）
+//
+// describe 的 result 也可以直接是单个工具描述对象。
+const (
+	PluginMethodDescribe = "describe"
+	PluginMethodInvoke   = "invoke"
+)
+
+// 插件默认值
+const (
+	defaultPluginTimeout      = 30 * time.Second
+	defaultPluginStartTimeout = 10 * time.Second
+	defaultPluginMaxRestarts  = 3
+	pluginStopGracePeriod     = 2 * time.Second
+
+	// errPluginExited 进程退出时发给等待中调用的错误
+	errPluginExited = "plugin process exited"
+)
+
+// pluginRequest 发送给插件的请求
+type pluginRequest struct {
+	ID     int64  `json:"id"`
+	Method string `json:"method"`
+	Params any    `json:"params,omitempty"`
+}
+
+// pluginResponse 插件返回的响应
+type pluginResponse struct {
+	ID     int64           `json:"id"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  json.RawMessage `json:"error,omitempty"`
+}
+
+// errorMessage 解析响应中的错误，兼容字符串和 {"message": "..."} 两种形式
+func (r pluginResponse) errorMessage() string {
+	if len(r.Error) == 0 || string(r.Error) == "null" {
+		return ""
+	}
+	var message string
+	if err := json.Unmarshal(r.Error, &message); err == nil {
+		return message
+	}
+	var obj struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(r.Error, &obj); err == nil && obj.Message != "" {
+		return obj.Message
+	}
+	return string(r.Error)
+}
+
+// PluginToolSpec 插件 describe 返回的工具描述
+type PluginToolSpec struct {
+	Name         string         `json:"name"`
+	Description  string         `json:"description"`
+	InputSchema  map[string]any `json:"input_schema"`
+	OutputSchema map[string]any `json:"output_schema"`
+}
+
+// pluginProcess 一个长期运行的插件子进程，崩溃或超时后在下次调用时自动重启
+type pluginProcess struct {
+	cfg          config.PluginConfig
+	timeout      time.Duration
+	startTimeout time.Duration
+	maxRestarts  int
+
+	writeMu sync.Mutex // 保证请求行完整写入
+
+	mu       sync.Mutex
+	cmd      *exec.Cmd
+	stdin    io.WriteCloser
+	running  bool
+	closed   bool
+	nextID   int64
+	pending  map[int64]chan pluginResponse
+	restarts int // 连续重启次数，成功响应后清零
+}
+
+// newPluginProcess 根据配置创建插件进程（尚未启动）
+func newPluginProcess(cfg config.PluginConfig) (*pluginProcess, error) {
+	if cfg.Command == "" {
+		return nil, fmt.Errorf("plugin %s: command is required", cfg.Name)
+	}
+	timeout, err := parseDurationOr(cfg.Timeout, defaultPluginTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: invalid timeout: %w", cfg.Name, err)
+	}
+	startTimeout, err := parseDurationOr(cfg.StartTimeout, defaultPluginStartTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: invalid start_timeout: %w", cfg.Name, err)
+	}
+	maxRestarts := cfg.MaxRestarts
+	if maxRestarts == 0 {
+		maxRestarts = defaultPluginMaxRestarts
+	}
+	return &pluginProcess{
+		cfg:          cfg,
+		timeout:      timeout,
+		startTimeout: startTimeout,
+		maxRestarts:  maxRestarts,
+		pending:      make(map[int64]chan pluginResponse),
+	}, nil
+}
+
+// start 启动子进程，调用方需持有 p.mu
+func (p *pluginProcess) start() error {
+	cmd := exec.Command(p.cfg.Command, p.cfg.Args...)
+	cmd.Dir = p.cfg.Dir
+	cmd.Env = ProcessEnv(p.cfg.Env)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create plugin stdin: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create plugin stdout: %w", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create plugin stderr: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start plugin %s: %w", p.cfg.Name, err)
+	}
+
+	p.cmd = cmd
+	p.stdin = stdin
+	p.running = true
+	logger.Debugw("tool.plugin.start", "plugin", p.cfg.Name, "pid", cmd.Process.Pid)
+
+	stderrDone := make(chan struct{})
+	go func() {
+		defer close(stderrDone)
+		p.readStderr(stderr)
+	}()
+	go p.readLoop(cmd, stdout, stderrDone)
+	return nil
+}
+
+// readLoop 读取响应并分发给等待中的调用；stdout 关闭后视为进程退出
+func (p *pluginProcess) readLoop(cmd *exec.Cmd, stdout io.Reader, stderrDone <-chan struct{}) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var resp pluginResponse
+		if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil || resp.ID == 0 {
+			logger.Debugw("tool.plugin.stray_output", "plugin", p.cfg.Name, "line", preview(scanner.Text(), 200))
+			continue
+		}
+		p.mu.Lock()
+		ch, ok := p.pending[resp.ID]
+		delete(p.pending, resp.ID)
+		p.mu.Unlock()
+		if ok {
+			ch <- resp
+		}
+	}
+
+	<-stderrDone // Wait 会关闭管道，需在读取完成后调用
+	err := cmd.Wait()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cmd != cmd {
+		return
+	}
+	if !p.running {
+		return // 已由 kill 处理
+	}
+	p.running = false
+	if !p.closed {
+		logger.Warnw("tool.plugin.exited", "plugin", p.cfg.Name, "error", err)
+	}
+	// 通知所有等待中的调用进程已退出
+	for id, ch := range p.pending {
+		ch <- pluginResponse{ID: id, Error: json.RawMessage(`"` + errPluginExited + `"`)}
+		delete(p.pending, id)
+	}
+}
+
+// readStderr 将插件的 stderr 转为调试日志
+func (p *pluginProcess) readStderr(stderr io.Reader) {
+	scanner := bufio.NewScanner(stderr)
+	for scanner.Scan() {
+		logger.Debugw("tool.plugin.stderr", "plugin", p.cfg.Name, "line", scanner.Text())
+	}
+}
+
+// ensureRunning 确保子进程在运行，已退出时按重启策略重新启动，调用方需持有 p.mu
+func (p *pluginProcess) ensureRunning() error {
+	if p.closed {
+		return fmt.Errorf("plugin %s is closed", p.cfg.Name)
+	}
+	if p.running {
+		return nil
+	}
+	if p.cmd != nil {
+		if p.maxRestarts < 0 || p.restarts >= p.maxRestarts {
+			return fmt.Errorf("plugin %s is not running (restart limit %d reached)", p.cfg.Name, p.maxRestarts)
+		}
+		p.restarts++
+		logger.Infof("🔄 [PLUGIN] Restarting plugin %s (attempt %d/%d)", p.cfg.Name, p.restarts, p.maxRestarts)
+	}
+	return p.start()
+}
+
+// call 发送请求并等待响应；超时的插件被视为卡死并终止，下次调用时重启
+func (p *pluginProcess) call(ctx context.Context, method string, params any, timeout time.Duration) (json.RawMessage, error) {
+	p.mu.Lock()
+	if err := p.ensureRunning(); err != nil {
+		p.mu.Unlock()
+		return nil, err
+	}
+	p.nextID++
+	id := p.nextID
+	ch := make(chan pluginResponse, 1)
+	p.pending[id] = ch
+	cmd, stdin := p.cmd, p.stdin
+	p.mu.Unlock()
+
+	data, err := json.Marshal(pluginRequest{ID: id, Method: method, Params: params})
+	if err != nil {
+		p.dropPending(id)
+		return nil, fmt.Errorf("failed to marshal plugin request: %w", err)
+	}
+	p.writeMu.Lock()
+	_, err = stdin.Write(append(data, '\n'))
+	p.writeMu.Unlock()
+	if err != nil {
+		p.dropPending(id)
+		return nil, fmt.Errorf("failed to write to plugin %s: %w", p.cfg.Name, err)
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case resp := <-ch:
+		message := resp.errorMessage()
+		if message == errPluginExited {
+			return nil, fmt.Errorf("plugin %s: %s", p.cfg.Name, message)
+		}
+		// 插件正常响应（包括业务错误），重启计数清零
+		p.mu.Lock()
+		p.restarts = 0
+		p.mu.Unlock()
+		if message != "" {
+			return nil, fmt.Errorf("plugin %s: %s", p.cfg.Name, message)
+		}
+		return resp.Result, nil
+	case <-timer.C:
+		p.dropPending(id)
+		logger.Warnw("tool.plugin.timeout", "plugin", p.cfg.Name, "method", method, "timeout", timeout.String())
+		p.kill(cmd)
+		return nil, fmt.Errorf("plugin %s timed out after %s", p.cfg.Name, timeout)
+	case <-ctx.Done():
+		p.dropPending(id)
+		return nil, ctx.Err()
+	}
+}
+
+func (p *pluginProcess) dropPending(id int64) {
+	p.mu.Lock()
+	delete(p.pending, id)
+	p.mu.Unlock()
+}
+
+// kill 终止指定的子进程（仅当它仍是当前进程时），并立即将其标记为已退出
+//
+// 不等待 stdout 关闭：插件派生的子进程可能继续持有管道，下一次调用直接启动新进程。
+func (p *pluginProcess) kill(cmd *exec.Cmd) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cmd != cmd || !p.running {
+		return
+	}
+	if cmd.Process != nil {
+		_ = cmd.Process.Kill()
+	}
+	p.running = false
+	for id, ch := range p.pending {
+		ch <- pluginResponse{ID: id, Error: json.RawMessage(`"` + errPluginExited + `"`)}
+		delete(p.pending, id)
+	}
+}
+
+// pid 返回当前插件进程号，未运行时为 0
+func (p *pluginProcess) pid() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.running || p.cmd == nil || p.cmd.Process == nil {
+		return 0
+	}
+	return p.cmd.Process.Pid
+}
+
+// describe 请求插件提供的工具描述
+func (p *pluginProcess) describe(ctx context.Context) ([]PluginToolSpec, error) {
+	result, err := p.call(ctx, PluginMethodDescribe, nil, p.startTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	var many struct {
+		Tools []PluginToolSpec `json:"tools"`
+	}
+	if err := json.Unmarshal(result, &many); err == nil && len(many.Tools) > 0 {
+		return many.Tools, nil
+	}
+	var single PluginToolSpec
+	if err := json.Unmarshal(result, &single); err != nil || single.Name == "" {
+		return nil, fmt.Errorf("plugin %s returned an invalid describe response", p.cfg.Name)
+	}
+	return []PluginToolSpec{single}, nil
+}
+
+// invoke 调用插件中的工具
+func (p *pluginProcess) invoke(ctx context.Context, toolName string, args map[string]any) (map[string]any, error) {
+	params := map[string]any{"tool": toolName, "args": args}
+	result, err := p.call(ctx, PluginMethodInvoke, params, p.timeout)
+	if err != nil {
+		return nil, err
+	}
+	var output map[string]any
+	if err := json.Unmarshal(result, &output); err != nil {
+		// 非对象结果包装为 result 字段
+		var value any
+		if err := json.Unmarshal(result, &value); err != nil {
+			return nil, fmt.Errorf("plugin %s returned invalid result: %w", p.cfg.Name, err)
+		}
+		output = map[string]any{"result": value}
+	}
+	return output, nil
+}
+
+// close 关闭 stdin 请插件退出，超过宽限期后强制终止
+func (p *pluginProcess) close() {
+	p.mu.Lock()
+	p.closed = true
+	cmd, stdin, running := p.cmd, p.stdin, p.running
+	p.mu.Unlock()
+	if !running {
+		return
+	}
+
+	_ = stdin.Close()
+	deadline := time.Now().Add(pluginStopGracePeriod)
+	for time.Now().Before(deadline) {
+		p.mu.Lock()
+		running = p.running
+		p.mu.Unlock()
+		if !running {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if cmd.Process != nil {
+		_ = cmd.Process.Kill()
+	}
+}
+
+// PluginTool 由外部可执行插件提供的工具
+type PluginTool struct {
+	*BaseTool
+	process *pluginProcess
+}
+
+// Type 返回工具类型（插件工具）
+func (pt *PluginTool) Type() ToolType {
+	return ToolTypePlugin
+}
+
+// ServerName 返回插件名称（可用于 tools.limits.servers）
+func (pt *PluginTool) ServerName() string {
+	return pt.process.cfg.Name
+}
+
+// Invoke 通过插件协议调用工具
+func (pt *PluginTool) Invoke(ctx context.Context, args map[string]any) (map[string]any, error) {
+	return pt.process.invoke(ctx, pt.name, args)
+}
+
+// Ping 通过 describe 请求检查插件进程是否可响应，进程已退出时按重启策略重新启动
+func (pt *PluginTool) Ping(ctx context.Context) (HealthStatus, error) {
+	if _, err := pt.process.describe(ctx); err != nil {
+		return HealthStatus{}, err
+	}
+	return HealthStatus{Detail: fmt.Sprintf("plugin %s, pid %d", pt.process.cfg.Name, pt.process.pid())}, nil
+}
+
+// PluginManager 管理已加载的插件进程
+type PluginManager struct {
+	processes []*pluginProcess
+}
+
+// LoadPlugins 启动配置中的插件，通过 describe 获取工具并注册到注册表
+//
+// 单个插件启动或注册失败只记录警告并跳过，不影响其他插件和内置工具。
+func LoadPlugins(ctx context.Context, r *Registry, cfgs []config.PluginConfig) *PluginManager {
+	manager := &PluginManager{}
+	for _, cfg := range cfgs {
+		process, err := newPluginProcess(cfg)
+		if err != nil {
+			logger.Warnw("tool.plugin.invalid_config", "plugin", cfg.Name, "error", err)
+			continue
+		}
+		specs, err := process.describe(ctx)
+		if err != nil {
+			logger.Warnw("tool.plugin.describe_failed", "plugin", cfg.Name, "error", err)
+			process.close()
+			continue
+		}
+
+		registered := 0
+		for _, spec := range specs {
+			if spec.InputSchema == nil {
+				spec.InputSchema = CreateJSONSchema("object", map[string]any{}, nil)
+			}
+			pluginTool := &PluginTool{
+				BaseTool: NewBaseTool(spec.Name, spec.Description, spec.InputSchema, spec.OutputSchema),
+				process:  process,
+			}
+			if err := r.Register(pluginTool); err != nil {
+				logger.Warnw("tool.plugin.register_failed", "plugin", cfg.Name, "tool", spec.Name, "error", err)
+				continue
+			}
+			registered++
+		}
+		if registered == 0 {
+			process.close()
+			continue
+		}
+		manager.processes = append(manager.processes, process)
+		logger.Infof("🧩 [PLUGIN] Loaded plugin %s (%d tools)", cfg.Name, registered)
+	}
+	return manager
+}
+
+// Close 停止所有插件进程
+func (m *PluginManager) Close() {
+	if m == nil {
+		return
+	}
+	var wg sync.WaitGroup
+	for _, process := range m.processes {
+		wg.Add(1)
+		go func(p *pluginProcess) {
+			defer wg.Done()
+			p.close()
+		}(process)
+	}
+	wg.Wait()
+}
+
+// parseDurationOr 解析时长，空字符串返回默认值
+func parseDurationOr(value string, defaultValue time.Duration) (time.Duration, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	return time.ParseDuration(value)
+}
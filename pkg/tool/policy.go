@@ -0,0 +1,405 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"openmanus-go/pkg/config"
+	"openmanus-go/pkg/logger"
+)
+
+// 参数规则的匹配方式
+const (
+	MatchRegex   = "regex"
+	MatchGlob    = "glob"
+	MatchSQLVerb = "sql_verb"
+)
+
+// PolicyViolation 工具调用违反权限策略
+type PolicyViolation struct {
+	Tool   string `json:"tool"`
+	Rule   string `json:"rule"`          // tool, operation, arg
+	Arg    string `json:"arg,omitempty"` // 违反参数规则时的参数名
+	Reason string `json:"reason"`
+}
+
+// Error 实现 error 接口
+func (v *PolicyViolation) Error() string {
+	return fmt.Sprintf("policy violation for tool %s: %s", v.Tool, v.Reason)
+}
+
+// Policy 工具权限策略：按名称允许/禁止工具，限制操作类型，并按正则、通配或 SQL 动词约束参数
+type Policy struct {
+	defaultAllow bool
+	allow        []*regexp.Regexp
+	deny         []*regexp.Regexp
+	tools        map[string]*toolRule
+
+	allowPatterns []string
+	denyPatterns  []string
+}
+
+// toolRule 编译后的工具级规则
+type toolRule struct {
+	operationArg   string
+	operations     map[string]bool
+	denyOperations map[string]bool
+	args           []argRule
+}
+
+// argRule 编译后的参数规则
+type argRule struct {
+	arg   string
+	match string
+	allow []*regexp.Regexp
+	deny  []*regexp.Regexp
+	raw   config.ArgRuleConfig
+
+	allowVerbs map[string]bool // sql_verb 允许的动词
+	denyVerbs  map[string]bool // sql_verb 禁止的动词
+}
+
+// NewPolicy 根据配置创建权限策略
+func NewPolicy(cfg config.ToolPolicyConfig) (*Policy, error) {
+	p := &Policy{
+		defaultAllow:  cfg.Default != "deny",
+		tools:         make(map[string]*toolRule),
+		allowPatterns: cfg.Allow,
+		denyPatterns:  cfg.Deny,
+	}
+
+	var err error
+	if p.allow, err = compileGlobs(cfg.Allow, false); err != nil {
+		return nil, fmt.Errorf("invalid tools.policy.allow: %w", err)
+	}
+	if p.deny, err = compileGlobs(cfg.Deny, false); err != nil {
+		return nil, fmt.Errorf("invalid tools.policy.deny: %w", err)
+	}
+
+	for name, ruleCfg := range cfg.Tools {
+		rule := &toolRule{
+			operationArg:   ruleCfg.OperationArg,
+			operations:     lowerSet(ruleCfg.Operations),
+			denyOperations: lowerSet(ruleCfg.DenyOperations),
+		}
+		if rule.operationArg == "" {
+			rule.operationArg = "operation"
+		}
+		for i, argCfg := range ruleCfg.Args {
+			compiled, err := compileArgRule(argCfg)
+			if err != nil {
+				return nil, fmt.Errorf("invalid tools.policy.tools.%s.args[%d]: %w", name, i, err)
+			}
+			rule.args = append(rule.args, compiled)
+		}
+		p.tools[name] = rule
+	}
+
+	return p, nil
+}
+
+// compileArgRule 编译参数规则
+func compileArgRule(cfg config.ArgRuleConfig) (argRule, error) {
+	if cfg.Arg == "" {
+		return argRule{}, fmt.Errorf("arg is required")
+	}
+	rule := argRule{arg: cfg.Arg, match: cfg.Match, raw: cfg}
+	if rule.match == "" {
+		rule.match = MatchRegex
+	}
+
+	var err error
+	switch rule.match {
+	case MatchRegex:
+		if rule.allow, err = compileRegexps(cfg.Allow); err != nil {
+			return argRule{}, err
+		}
+		if rule.deny, err = compileRegexps(cfg.Deny); err != nil {
+			return argRule{}, err
+		}
+	case MatchGlob:
+		if rule.allow, err = compileGlobs(cfg.Allow, true); err != nil {
+			return argRule{}, err
+		}
+		if rule.deny, err = compileGlobs(cfg.Deny, true); err != nil {
+			return argRule{}, err
+		}
+	case MatchSQLVerb:
+		rule.allowVerbs = upperSet(cfg.Allow)
+		rule.denyVerbs = upperSet(cfg.Deny)
+	default:
+		return argRule{}, fmt.Errorf("unsupported match %q (regex, glob, sql_verb)", cfg.Match)
+	}
+	return rule, nil
+}
+
+// Check 检查工具调用是否被允许，违反策略时返回 *PolicyViolation
+func (p *Policy) Check(toolName string, args map[string]any) error {
+	if !p.toolAllowed(toolName) {
+		return &PolicyViolation{Tool: toolName, Rule: "tool", Reason: "tool is not allowed by policy"}
+	}
+
+	rule, ok := p.tools[toolName]
+	if !ok {
+		return nil
+	}
+
+	if operation, ok := args[rule.operationArg].(string); ok {
+		op := strings.ToLower(operation)
+		if rule.denyOperations[op] || (len(rule.operations) > 0 && !rule.operations[op]) {
+			return &PolicyViolation{
+				Tool:   toolName,
+				Rule:   "operation",
+				Arg:    rule.operationArg,
+				Reason: fmt.Sprintf("operation %q is not allowed (allowed: %s)", operation, describeOperations(rule)),
+			}
+		}
+	} else if len(rule.operations) > 0 {
+		// 配置了操作白名单时，缺少操作参数不能绕过检查
+		reason := fmt.Sprintf("operation is required (allowed: %s)", describeOperations(rule))
+		if _, present := args[rule.operationArg]; present {
+			reason = "operation must be a string"
+		}
+		return &PolicyViolation{Tool: toolName, Rule: "operation", Arg: rule.operationArg, Reason: reason}
+	}
+
+	for _, argRule := range rule.args {
+		value, ok := lookupArg(args, argRule.arg)
+		if !ok {
+			continue
+		}
+		for _, s := range stringValues(value) {
+			if reason := argRule.check(s); reason != "" {
+				return &PolicyViolation{Tool: toolName, Rule: "arg", Arg: argRule.arg, Reason: reason}
+			}
+		}
+	}
+	return nil
+}
+
+// toolAllowed 判断工具名称是否被允许（deny 优先于 allow）
+func (p *Policy) toolAllowed(name string) bool {
+	if matchAny(p.deny, name) {
+		return false
+	}
+	if matchAny(p.allow, name) {
+		return true
+	}
+	return p.defaultAllow
+}
+
+// check 检查单个字符串参数值，返回拒绝原因
+func (r argRule) check(value string) string {
+	if r.match == MatchSQLVerb {
+		// 多语句 SQL 逐条检查，避免 "SELECT 1; DROP TABLE users" 只检查第一条
+		verbs := SQLVerbs(value)
+		if len(verbs) == 0 {
+			verbs = []string{""}
+		}
+		for _, verb := range verbs {
+			if r.denyVerbs[verb] || (len(r.allowVerbs) > 0 && !r.allowVerbs[verb]) {
+				return fmt.Sprintf("SQL verb %q is not allowed for %s", verb, r.arg)
+			}
+		}
+		return ""
+	}
+
+	// 敏感参数的值不出现在拒绝原因中
+	shown := preview(value, 80)
+	if isSensitiveKey(r.arg[strings.LastIndex(r.arg, ".")+1:], nil) {
+		shown = redactedValue
+	}
+	for i, re := range r.deny {
+		if re.MatchString(value) {
+			return fmt.Sprintf("%s %q matches denied pattern %q", r.arg, shown, r.raw.Deny[i])
+		}
+	}
+	if len(r.allow) > 0 && !matchAny(r.allow, value) {
+		return fmt.Sprintf("%s %q does not match any allowed pattern (%s)", r.arg, shown, strings.Join(r.raw.Allow, ", "))
+	}
+	return ""
+}
+
+// Describe 返回工具的有效权限说明，用于 tools list
+func (p *Policy) Describe(toolName string) string {
+	if !p.toolAllowed(toolName) {
+		return "denied"
+	}
+	rule, ok := p.tools[toolName]
+	if !ok {
+		return "allowed"
+	}
+
+	var parts []string
+	if len(rule.operations) > 0 || len(rule.denyOperations) > 0 {
+		parts = append(parts, "operations: "+describeOperations(rule))
+	}
+	for _, argRule := range rule.args {
+		var constraint []string
+		if len(argRule.raw.Allow) > 0 {
+			constraint = append(constraint, fmt.Sprintf("%s in [%s]", argRule.match, strings.Join(argRule.raw.Allow, ", ")))
+		}
+		if len(argRule.raw.Deny) > 0 {
+			constraint = append(constraint, fmt.Sprintf("%s not in [%s]", argRule.match, strings.Join(argRule.raw.Deny, ", ")))
+		}
+		parts = append(parts, fmt.Sprintf("%s: %s", argRule.arg, strings.Join(constraint, ", ")))
+	}
+	if len(parts) == 0 {
+		return "allowed"
+	}
+	return "restricted (" + strings.Join(parts, "; ") + ")"
+}
+
+// describeOperations 描述允许/禁止的操作
+func describeOperations(rule *toolRule) string {
+	var parts []string
+	if len(rule.operations) > 0 {
+		parts = append(parts, strings.Join(sortedKeys(rule.operations), ", "))
+	} else {
+		parts = append(parts, "any")
+	}
+	if len(rule.denyOperations) > 0 {
+		parts = append(parts, "except "+strings.Join(sortedKeys(rule.denyOperations), ", "))
+	}
+	return strings.Join(parts, " ")
+}
+
+// PolicyMiddleware 在调用工具前检查权限策略，违反时不调用工具，返回结构化的违规结果
+func PolicyMiddleware(policy *Policy) Middleware {
+	return func(next Invoker) Invoker {
+		return func(ctx context.Context, call *Call) (map[string]any, error) {
+			if err := policy.Check(call.Tool, call.Args); err != nil {
+				logger.Warnf("🚫 [POLICY] %v", err)
+				result := map[string]any{"error": err.Error()}
+				if violation, ok := err.(*PolicyViolation); ok {
+					result["policy_violation"] = violation
+				}
+				return result, err
+			}
+			return next(ctx, call)
+		}
+	}
+}
+
+// lookupArg 按点号路径查找参数值
+func lookupArg(args map[string]any, path string) (any, bool) {
+	var current any = args
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := current.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if current, ok = obj[key]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// stringValues 返回参数中的字符串值（数组逐个检查）
+func stringValues(value any) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case []any:
+		var values []string
+		for _, item := range v {
+			values = append(values, stringValues(item)...)
+		}
+		return values
+	}
+	return nil
+}
+
+// compileGlobs 将通配模式编译为正则；pathAware 为 true 时 * 不跨越 /，** 匹配任意字符
+func compileGlobs(patterns []string, pathAware bool) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		var expr strings.Builder
+		expr.WriteString("^")
+		for i := 0; i < len(pattern); i++ {
+			switch c := pattern[i]; c {
+			case '*':
+				if i+1 < len(pattern) && pattern[i+1] == '*' {
+					expr.WriteString(".*")
+					i++
+				} else if pathAware {
+					expr.WriteString("[^/]*")
+				} else {
+					expr.WriteString(".*")
+				}
+			case '?':
+				expr.WriteString(".")
+			default:
+				expr.WriteString(regexp.QuoteMeta(string(c)))
+			}
+		}
+		expr.WriteString("$")
+		re, err := regexp.Compile(expr.String())
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// compileRegexps 编译正则列表
+func compileRegexps(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex %q: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+func matchAny(patterns []*regexp.Regexp, value string) bool {
+	for _, re := range patterns {
+		if re.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func lowerSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[strings.ToLower(v)] = true
+	}
+	return set
+}
+
+func upperSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[strings.ToUpper(v)] = true
+	}
+	return set
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// preview 截断过长的字符串
+func preview(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max] + "..."
+}
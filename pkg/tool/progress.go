@@ -0,0 +1,141 @@
+package tool
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Progress 流式工具在执行过程中报告的进度
+type Progress struct {
+	Message string `json:"message,omitempty"`
+	Current int    `json:"current,omitempty"` // 已完成的数量（页面、文档等）
+	Total   int    `json:"total,omitempty"`   // 总数，0 表示未知
+
+	// Partial 截至目前的部分输出，格式与最终输出一致；工具被提前停止时作为结果返回，报告后不应再修改。
+	// 附带 Partial 即允许 agent 在结果已足够时提前停止工具，只应在只读操作中设置
+	Partial map[string]any `json:"partial,omitempty"`
+}
+
+// Fraction 返回完成比例（0..1），总数未知时返回 -1
+func (p Progress) Fraction() float64 {
+	if p.Total <= 0 {
+		return -1
+	}
+	if p.Current >= p.Total {
+		return 1
+	}
+	return float64(p.Current) / float64(p.Total)
+}
+
+// ProgressReporter 流式工具用于报告进度的回调，可从任意 goroutine 调用
+type ProgressReporter func(progress Progress)
+
+// StreamingTool 可在执行过程中报告进度和部分输出的工具
+//
+// 工具应在 ctx 取消后尽快返回 ctx.Err()：执行器提前停止工具时会取消 ctx，
+// 并以最近一次报告的部分输出作为结果，返回值被忽略。
+type StreamingTool interface {
+	Tool
+	InvokeStream(ctx context.Context, args map[string]any, report ProgressReporter) (map[string]any, error)
+}
+
+// ProgressEvent 执行器转发给调用方的进度事件
+type ProgressEvent struct {
+	Tool     string        `json:"tool"`
+	Seq      int           `json:"seq"` // 本次调用内的事件序号，从 1 开始
+	Progress Progress      `json:"progress"`
+	Elapsed  time.Duration `json:"elapsed"`
+}
+
+// StopFunc 提前停止正在执行的工具，以最近的部分输出作为结果；工具结束后调用无效
+type StopFunc func(reason string)
+
+// ProgressHandler 接收进度事件，在工具的 goroutine 中同步调用，不应阻塞（耗时判断应异步进行后调用 stop）
+type ProgressHandler func(event ProgressEvent, stop StopFunc)
+
+// progressReporterKey 上下文中进度回调的键
+type progressReporterKey struct{}
+
+// withProgressReporter 将进度回调放入上下文，供最内层的 Invoker 交给流式工具
+func withProgressReporter(ctx context.Context, report ProgressReporter) context.Context {
+	return context.WithValue(ctx, progressReporterKey{}, report)
+}
+
+// progressReporterFrom 取出上下文中的进度回调，没有时返回丢弃进度的回调
+func progressReporterFrom(ctx context.Context) ProgressReporter {
+	if report, ok := ctx.Value(progressReporterKey{}).(ProgressReporter); ok && report != nil {
+		return report
+	}
+	return func(Progress) {}
+}
+
+// progressTracker 记录一次调用的进度，转发给处理器并处理提前停止
+type progressTracker struct {
+	tool    string
+	start   time.Time
+	handler ProgressHandler
+	cancel  context.CancelFunc
+
+	mu         sync.Mutex
+	seq        int
+	partial    map[string]any
+	stopReason string
+	finished   bool
+}
+
+// newProgressTracker 创建进度记录器，cancel 用于提前停止时取消工具的上下文
+func newProgressTracker(toolName string, handler ProgressHandler, cancel context.CancelFunc) *progressTracker {
+	return &progressTracker{
+		tool:    toolName,
+		start:   time.Now(),
+		handler: handler,
+		cancel:  cancel,
+	}
+}
+
+// report 实现 ProgressReporter
+func (t *progressTracker) report(progress Progress) {
+	t.mu.Lock()
+	if t.finished {
+		t.mu.Unlock()
+		return
+	}
+	t.seq++
+	if progress.Partial != nil {
+		t.partial = progress.Partial
+	}
+	event := ProgressEvent{
+		Tool:     t.tool,
+		Seq:      t.seq,
+		Progress: progress,
+		Elapsed:  time.Since(t.start),
+	}
+	t.mu.Unlock()
+
+	if t.handler != nil {
+		t.handler(event, t.stop)
+	}
+}
+
+// stop 实现 StopFunc
+func (t *progressTracker) stop(reason string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.finished || t.stopReason != "" {
+		return
+	}
+	if reason == "" {
+		reason = "stopped by caller"
+	}
+	t.stopReason = reason
+	t.cancel()
+}
+
+// finish 标记调用结束，返回提前停止的原因和最近的部分输出
+func (t *progressTracker) finish() (stopReason string, partial map[string]any, events int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.finished = true
+	return t.stopReason, t.partial, t.seq
+}
@@ -3,6 +3,8 @@ package tool
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"sort"
 	"sync"
 	"time"
 
@@ -13,48 +15,171 @@ import (
 type Registry struct {
 	tools map[string]Tool
 	mu    sync.RWMutex
+
+	middlewares     []Middleware            // 注册表级中间件，对所有工具生效
+	toolMiddlewares map[string][]Middleware // 工具级中间件
+	limiter         *Limiter                // 并发和频率限制，作为中间件链最内层应用
+	artifacts       *ArtifactStore          // 大输出工件存储，由执行器在调用后使用
+	retriever       *ToolRetriever          // 工具检索器，由规划器按相关性挑选每步发送的工具
+	validation      OutputValidation        // 输出的 OutputSchema 校验模式，由执行器在调用后使用
+
+	version        uint64                   // 每次添加、移除或替换工具时递增
+	manifest       []ToolInfo               // 缓存的工具清单（按名称排序），变更时失效
+	manifestIndex  map[string]int           // 工具名称到清单下标
+	changes        []RegistryEvent          // 最近的变更事件，供按版本增量读取
+	listeners      map[int]RegistryListener // 变更监听器
+	nextListenerID int
 }
 
-// NewRegistry 创建新的工具注册表
+// NewRegistry 创建新的工具注册表（使用 DefaultMiddlewares）
 func NewRegistry() *Registry {
 	return &Registry{
-		tools: make(map[string]Tool),
+		tools:           make(map[string]Tool),
+		middlewares:     DefaultMiddlewares(),
+		toolMiddlewares: make(map[string][]Middleware),
+		listeners:       make(map[int]RegistryListener),
+		validation:      OutputValidationWarn,
 	}
 }
 
+// Use 追加注册表级中间件
+func (r *Registry) Use(mws ...Middleware) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.middlewares = append(r.middlewares, mws...)
+}
+
+// UseFor 追加只对指定工具生效的中间件，工具尚未注册时同样有效
+func (r *Registry) UseFor(name string, mws ...Middleware) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.toolMiddlewares[name] = append(r.toolMiddlewares[name], mws...)
+}
+
+// SetLimiter 设置并发和频率限制，共享该注册表的所有执行器共用
+func (r *Registry) SetLimiter(limiter *Limiter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.limiter = limiter
+}
+
+// Limiter 返回注册表的并发和频率限制，未设置时为 nil
+func (r *Registry) Limiter() *Limiter {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.limiter
+}
+
+// SetArtifactStore 设置大输出工件存储，nil 表示不转存大输出
+func (r *Registry) SetArtifactStore(store *ArtifactStore) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.artifacts = store
+}
+
+// ArtifactStore 返回注册表的工件存储，未设置时为 nil
+func (r *Registry) ArtifactStore() *ArtifactStore {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.artifacts
+}
+
+// SetRetriever 设置工具检索器，nil 表示每步发送全部工具
+func (r *Registry) SetRetriever(retriever *ToolRetriever) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.retriever = retriever
+}
+
+// Retriever 返回注册表的工具检索器，未设置时为 nil
+func (r *Registry) Retriever() *ToolRetriever {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.retriever
+}
+
+// SetOutputValidation 设置输出校验模式
+func (r *Registry) SetOutputValidation(mode OutputValidation) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.validation = mode
+}
+
+// OutputValidation 返回输出校验模式
+func (r *Registry) OutputValidation() OutputValidation {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.validation
+}
+
+// SetMiddlewares 替换注册表级中间件（包括默认的日志中间件）
+func (r *Registry) SetMiddlewares(mws ...Middleware) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.middlewares = append([]Middleware(nil), mws...)
+}
+
 // DefaultRegistry 默认的全局工具注册表
 var DefaultRegistry = NewRegistry()
 
 // Register 注册工具
 func (r *Registry) Register(tool Tool) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
 	name := tool.Name()
 	if name == "" {
 		return fmt.Errorf("tool name cannot be empty")
 	}
 
+	r.mu.Lock()
 	if _, exists := r.tools[name]; exists {
+		r.mu.Unlock()
 		return fmt.Errorf("tool '%s' already registered", name)
 	}
 
 	r.tools[name] = tool
-	logger.Infow("tool.registry.register", "tool", name)
+	event := r.recordLocked(RegistryEventAdded, tool)
+	r.mu.Unlock()
+
+	logger.Infow("tool.registry.register", "tool", name, "version", event.Version)
+	r.notify([]RegistryEvent{event})
+	return nil
+}
+
+// Replace 注册或替换工具（热更新），已存在同名工具时发出 replaced 事件
+func (r *Registry) Replace(tool Tool) error {
+	name := tool.Name()
+	if name == "" {
+		return fmt.Errorf("tool name cannot be empty")
+	}
+
+	r.mu.Lock()
+	eventType := RegistryEventAdded
+	if _, exists := r.tools[name]; exists {
+		eventType = RegistryEventReplaced
+	}
+	r.tools[name] = tool
+	event := r.recordLocked(eventType, tool)
+	r.mu.Unlock()
+
+	logger.Infow("tool.registry."+string(eventType), "tool", name, "version", event.Version)
+	r.notify([]RegistryEvent{event})
 	return nil
 }
 
 // Unregister 取消注册工具
 func (r *Registry) Unregister(name string) error {
 	r.mu.Lock()
-	defer r.mu.Unlock()
-
-	if _, exists := r.tools[name]; !exists {
+	tool, exists := r.tools[name]
+	if !exists {
+		r.mu.Unlock()
 		return fmt.Errorf("tool '%s' not found", name)
 	}
 
 	delete(r.tools, name)
-	logger.Infow("tool.registry.unregister", "tool", name)
+	event := r.recordLocked(RegistryEventRemoved, tool)
+	r.mu.Unlock()
+
+	logger.Infow("tool.registry.unregister", "tool", name, "version", event.Version)
+	r.notify([]RegistryEvent{event})
 	return nil
 }
 
@@ -97,73 +222,159 @@ func (r *Registry) ListNames() []string {
 	return names
 }
 
-// GetToolsManifest 获取工具清单（用于 LLM 提示）
+// GetToolsManifest 获取工具清单（用于 LLM 提示），按名称排序
+//
+// 清单在注册表变更前一直缓存，返回的切片是副本，调用方可以修改。
 func (r *Registry) GetToolsManifest() []ToolInfo {
 	r.mu.RLock()
-	defer r.mu.RUnlock()
+	if r.manifest != nil {
+		manifest := append([]ToolInfo(nil), r.manifest...)
+		r.mu.RUnlock()
+		return manifest
+	}
+	r.mu.RUnlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.buildManifestLocked()
+	return append([]ToolInfo(nil), r.manifest...)
+}
+
+// ToolInfo 获取单个工具的信息（使用缓存的清单）
+func (r *Registry) ToolInfo(name string) (ToolInfo, bool) {
+	r.mu.RLock()
+	if r.manifest != nil {
+		defer r.mu.RUnlock()
+		return r.lookupLocked(name)
+	}
+	r.mu.RUnlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.buildManifestLocked()
+	return r.lookupLocked(name)
+}
+
+// lookupLocked 在缓存的清单中查找工具，调用方需持有锁
+func (r *Registry) lookupLocked(name string) (ToolInfo, bool) {
+	index, ok := r.manifestIndex[name]
+	if !ok {
+		return ToolInfo{}, false
+	}
+	return r.manifest[index], true
+}
+
+// buildManifestLocked 重建缓存的清单，调用方需持有写锁
+func (r *Registry) buildManifestLocked() {
+	if r.manifest != nil {
+		return
+	}
 
 	manifest := make([]ToolInfo, 0, len(r.tools))
 	for _, tool := range r.tools {
-		toolInfo := ToolInfo{
-			Name:         tool.Name(),
-			Description:  tool.Description(),
-			InputSchema:  tool.InputSchema(),
-			OutputSchema: tool.OutputSchema(),
-		}
+		manifest = append(manifest, toolInfoOf(tool))
+	}
+	sort.Slice(manifest, func(i, j int) bool {
+		return manifest[i].Name < manifest[j].Name
+	})
 
-		// 如果工具实现了 ToolWithType 接口，添加类型和服务器信息
-		if toolWithType, ok := tool.(ToolWithType); ok {
-			toolInfo.Type = toolWithType.Type()
-			toolInfo.ServerName = toolWithType.ServerName()
-		} else {
-			toolInfo.Type = ToolTypeBuiltin
-		}
+	r.manifestIndex = make(map[string]int, len(manifest))
+	for i, info := range manifest {
+		r.manifestIndex[info.Name] = i
+	}
+	r.manifest = manifest
+}
 
-		manifest = append(manifest, toolInfo)
+// toolInfoOf 构建工具信息
+func toolInfoOf(tool Tool) ToolInfo {
+	toolInfo := ToolInfo{
+		Name:         tool.Name(),
+		Description:  tool.Description(),
+		InputSchema:  tool.InputSchema(),
+		OutputSchema: tool.OutputSchema(),
 	}
 
-	return manifest
+	// 如果工具实现了 ToolWithType 接口，添加类型和服务器信息
+	if toolWithType, ok := tool.(ToolWithType); ok {
+		toolInfo.Type = toolWithType.Type()
+		toolInfo.ServerName = toolWithType.ServerName()
+	} else {
+		toolInfo.Type = ToolTypeBuiltin
+	}
+	return toolInfo
 }
 
-// Invoke 调用工具
+// Invoke 经过中间件链调用工具
 func (r *Registry) Invoke(ctx context.Context, name string, args map[string]any) (map[string]any, error) {
-	tool, err := r.Get(name)
-	if err != nil {
-		return nil, err
+	return r.InvokeCall(ctx, &Call{Tool: name, Args: args})
+}
+
+// InvokeCall 经过中间件链执行调用，中间件记录的状态（如 CacheStatus）可从 call 中读取
+func (r *Registry) InvokeCall(ctx context.Context, call *Call) (map[string]any, error) {
+	r.mu.RLock()
+	tool, exists := r.tools[call.Tool]
+	mws := make([]Middleware, 0, len(r.middlewares)+len(r.toolMiddlewares[call.Tool]))
+	mws = append(mws, r.middlewares...)
+	mws = append(mws, r.toolMiddlewares[call.Tool]...)
+	if r.limiter != nil {
+		mws = append(mws, LimitMiddleware(r.limiter))
 	}
+	r.mu.RUnlock()
 
-	// 验证输入参数（如果需要的话，由具体工具实现）
+	if !exists {
+		return nil, fmt.Errorf("tool '%s' not found", call.Tool)
+	}
 
-	// 执行工具并测量延迟
-	start := time.Now()
-	logger.Debugw("tool.invoke.start", "tool", name, "args", args)
-	result, err := tool.Invoke(ctx, args)
-	latency := time.Since(start)
+	call.Info = toolInfoOf(tool)
+	call.Target = tool
+	return chain(invokeTool(tool), mws...)(ctx, call)
+}
 
-	if err != nil {
-		logger.Warnw("tool.invoke.error", "tool", name, "error", err, "latency_ms", latency.Milliseconds())
-		return map[string]any{
-			"error":      err.Error(),
-			"latency_ms": latency.Milliseconds(),
-		}, err
-	}
+// invokeTool 返回直接调用工具的 Invoker，并在结果中附加延迟元数据
+//
+// 流式工具通过 InvokeStream 调用，进度交给上下文中的回调（由 Executor 设置）。
+func invokeTool(tool Tool) Invoker {
+	return func(ctx context.Context, call *Call) (map[string]any, error) {
+		start := time.Now()
+		var result map[string]any
+		var err error
+		if streaming, ok := tool.(StreamingTool); ok {
+			result, err = streaming.InvokeStream(ctx, call.Args, progressReporterFrom(ctx))
+		} else {
+			result, err = tool.Invoke(ctx, call.Args)
+		}
+		latency := time.Since(start)
 
-	// 添加元数据
-	if result == nil {
-		result = make(map[string]any)
-	}
-	result["latency_ms"] = latency.Milliseconds()
-	logger.Debugw("tool.invoke.ok", "tool", name, "latency_ms", latency.Milliseconds())
+		if err != nil {
+			return map[string]any{
+				"error":      err.Error(),
+				"latency_ms": latency.Milliseconds(),
+			}, err
+		}
 
-	return result, nil
+		// 添加元数据
+		if result == nil {
+			result = make(map[string]any)
+		}
+		result["latency_ms"] = latency.Milliseconds()
+		return result, nil
+	}
 }
 
-// RegisterMCPTools 注册MCP工具
+// RegisterMCPTools 注册MCP工具，已存在的同名工具被替换（定义未变化时保持不变，不发出事件）
 func (r *Registry) RegisterMCPTools(mcpTools []ToolInfo, executor MCPExecutor) error {
 	r.mu.Lock()
-	defer r.mu.Unlock()
-
+	var events []RegistryEvent
 	for _, toolInfo := range mcpTools {
+		eventType := RegistryEventAdded
+		if existing, exists := r.tools[toolInfo.Name]; exists {
+			if sameMCPTool(existing, toolInfo, executor) {
+				continue
+			}
+			eventType = RegistryEventReplaced
+			logger.Infow("tool.registry.replace_mcp", "tool", toolInfo.Name, "server", toolInfo.ServerName)
+		}
+
 		// 创建MCP工具实例
 		mcpTool := NewMCPTool(
 			toolInfo.Name,
@@ -173,42 +384,71 @@ func (r *Registry) RegisterMCPTools(mcpTools []ToolInfo, executor MCPExecutor) e
 			toolInfo.OutputSchema,
 			executor,
 		)
-
-		// 如果工具已存在，先删除旧的
-		if _, exists := r.tools[toolInfo.Name]; exists {
-			logger.Infow("tool.registry.replace_mcp", "tool", toolInfo.Name, "server", toolInfo.ServerName)
-			delete(r.tools, toolInfo.Name)
-		}
-
 		r.tools[toolInfo.Name] = mcpTool
+		events = append(events, r.recordLocked(eventType, mcpTool))
 		logger.Infow("tool.registry.register_mcp", "tool", toolInfo.Name, "server", toolInfo.ServerName)
 	}
+	r.mu.Unlock()
 
+	r.notify(events)
 	return nil
 }
 
+// SyncMCPTools 将指定服务器的 MCP 工具同步为 mcpTools：新增或替换变化的工具，移除服务器不再提供的工具
+func (r *Registry) SyncMCPTools(serverName string, mcpTools []ToolInfo, executor MCPExecutor) error {
+	current := make(map[string]bool, len(mcpTools))
+	for _, toolInfo := range mcpTools {
+		current[toolInfo.Name] = true
+	}
+	if err := r.RegisterMCPTools(mcpTools, executor); err != nil {
+		return err
+	}
+	return r.unregisterMCPTools(serverName, func(name string) bool { return !current[name] })
+}
+
 // UnregisterMCPTools 取消注册指定服务器的MCP工具
 func (r *Registry) UnregisterMCPTools(serverName string) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
+	return r.unregisterMCPTools(serverName, func(string) bool { return true })
+}
 
+// unregisterMCPTools 取消注册指定服务器上满足条件的 MCP 工具
+func (r *Registry) unregisterMCPTools(serverName string, match func(name string) bool) error {
+	r.mu.Lock()
 	var removedTools []string
+	var events []RegistryEvent
 	for name, tool := range r.tools {
 		if toolWithType, ok := tool.(ToolWithType); ok {
-			if toolWithType.Type() == ToolTypeMCP && toolWithType.ServerName() == serverName {
-				delete(r.tools, name)
+			if toolWithType.Type() == ToolTypeMCP && toolWithType.ServerName() == serverName && match(name) {
 				removedTools = append(removedTools, name)
 			}
 		}
 	}
+	sort.Strings(removedTools)
+	for _, name := range removedTools {
+		events = append(events, r.recordLocked(RegistryEventRemoved, r.tools[name]))
+		delete(r.tools, name)
+	}
+	r.mu.Unlock()
 
 	if len(removedTools) > 0 {
 		logger.Infow("tool.registry.unregister_mcp_tools", "server", serverName, "tools", removedTools)
 	}
-
+	r.notify(events)
 	return nil
 }
 
+// sameMCPTool 已注册的工具是否与 MCP 工具定义和执行器一致
+func sameMCPTool(existing Tool, toolInfo ToolInfo, executor MCPExecutor) bool {
+	mcpTool, ok := existing.(*MCPTool)
+	if !ok {
+		return false
+	}
+	return mcpTool.executor == executor &&
+		mcpTool.serverName == toolInfo.ServerName &&
+		mcpTool.description == toolInfo.Description &&
+		reflect.DeepEqual(mcpTool.inputSchema, toolInfo.InputSchema)
+}
+
 // RegisterDefaults 注册默认工具
 func (r *Registry) RegisterDefaults() error {
 	// 这里会注册所有内置工具
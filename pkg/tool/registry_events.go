@@ -0,0 +1,117 @@
+package tool
+
+import (
+	"sort"
+
+	"openmanus-go/pkg/logger"
+)
+
+// maxRegistryChanges 注册表保留的最近变更事件数
+const maxRegistryChanges = 256
+
+// RegistryEventType 注册表变更类型
+type RegistryEventType string
+
+const (
+	RegistryEventAdded    RegistryEventType = "added"
+	RegistryEventRemoved  RegistryEventType = "removed"
+	RegistryEventReplaced RegistryEventType = "replaced"
+)
+
+// RegistryEvent 注册表变更事件，Version 为变更后的注册表版本
+type RegistryEvent struct {
+	Type       RegistryEventType `json:"type"`
+	Tool       string            `json:"tool"`
+	ToolType   ToolType          `json:"tool_type"`
+	ServerName string            `json:"server_name,omitempty"`
+	Version    uint64            `json:"version"`
+}
+
+// RegistryListener 注册表变更监听器，在注册表锁释放后同步调用，不应阻塞
+type RegistryListener func(event RegistryEvent)
+
+// AddListener 添加变更监听器，返回取消监听的函数
+func (r *Registry) AddListener(listener RegistryListener) func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextListenerID++
+	id := r.nextListenerID
+	r.listeners[id] = listener
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.listeners, id)
+	}
+}
+
+// Version 返回注册表版本，每次添加、移除或替换工具时递增
+func (r *Registry) Version() uint64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.version
+}
+
+// Changes 返回版本 since 之后的变更事件（按版本递增），超出保留范围的早期事件不再返回
+func (r *Registry) Changes(since uint64) []RegistryEvent {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	index := sort.Search(len(r.changes), func(i int) bool {
+		return r.changes[i].Version > since
+	})
+	return append([]RegistryEvent(nil), r.changes[index:]...)
+}
+
+// recordLocked 记录一次变更：递增版本、使缓存的清单失效并保存事件，调用方需持有写锁
+func (r *Registry) recordLocked(eventType RegistryEventType, t Tool) RegistryEvent {
+	r.version++
+	r.manifest = nil
+	r.manifestIndex = nil
+
+	info := toolInfoOf(t)
+	event := RegistryEvent{
+		Type:       eventType,
+		Tool:       info.Name,
+		ToolType:   info.Type,
+		ServerName: info.ServerName,
+		Version:    r.version,
+	}
+	r.changes = append(r.changes, event)
+	if len(r.changes) > maxRegistryChanges {
+		r.changes = append([]RegistryEvent(nil), r.changes[len(r.changes)-maxRegistryChanges:]...)
+	}
+	return event
+}
+
+// notify 在锁外依次通知监听器，监听器的 panic 只记录不传播
+func (r *Registry) notify(events []RegistryEvent) {
+	if len(events) == 0 {
+		return
+	}
+
+	r.mu.RLock()
+	ids := make([]int, 0, len(r.listeners))
+	for id := range r.listeners {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	listeners := make([]RegistryListener, len(ids))
+	for i, id := range ids {
+		listeners[i] = r.listeners[id]
+	}
+	r.mu.RUnlock()
+
+	for _, event := range events {
+		for _, listener := range listeners {
+			func() {
+				defer func() {
+					if rec := recover(); rec != nil {
+						logger.Warnw("tool.registry.listener_panic", "tool", event.Tool, "event", event.Type, "panic", rec)
+					}
+				}()
+				listener(event)
+			}()
+		}
+	}
+}
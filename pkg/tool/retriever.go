@@ -0,0 +1,475 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"openmanus-go/pkg/llm"
+	"openmanus-go/pkg/logger"
+	"openmanus-go/pkg/state"
+)
+
+// SearchToolsName 检索工具的元工具名称
+const SearchToolsName = "search_tools"
+
+// 工具检索的默认值
+const (
+	DefaultRetrieverTopK     = 15
+	DefaultRetrieverMinTools = 30
+)
+
+// 向量化失败后的重试退避：每次连续失败翻倍，直到上限
+const (
+	embedRetryBaseDelay = 30 * time.Second
+	embedRetryMaxDelay  = 10 * time.Minute
+)
+
+// 关键词得分中不同字段的权重
+const (
+	nameTermWeight   = 3.0
+	descTermWeight   = 1.0
+	schemaTermWeight = 0.5
+)
+
+// coreTools 无论相关性如何都发送给模型的工具
+var coreTools = []string{"direct_answer", "stop", SearchToolsName}
+
+// retrievalStopWords 检索时忽略的英文停用词
+var retrievalStopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true,
+	"by": true, "for": true, "from": true, "in": true, "is": true, "it": true, "of": true,
+	"on": true, "or": true, "the": true, "this": true, "to": true, "with": true, "me": true,
+	"please": true, "what": true, "how": true, "use": true, "tool": true, "tools": true,
+}
+
+// RetrieverOptions 工具检索配置
+type RetrieverOptions struct {
+	TopK          int      // 每步按相关性选出的工具数（不含必选工具）
+	MinTools      int      // 注册表工具数不超过该值时发送全部工具
+	AlwaysInclude []string // 始终发送的工具，direct_answer、stop 和 search_tools 默认包含
+}
+
+// ToolMatch 检索命中的工具
+type ToolMatch struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Type        ToolType `json:"type"`
+	ServerName  string   `json:"server_name,omitempty"`
+	Score       float64  `json:"score"`
+}
+
+// toolEmbedding 缓存的工具向量，文本变化（如 MCP 工具重新注册）时重新计算
+type toolEmbedding struct {
+	text   string
+	vector []float32
+}
+
+// ToolRetriever 按与目标和最近步骤的相关性为每步规划挑选工具子集，
+// 避免工具很多（如接入大量 MCP 工具）时把全部定义发送给模型
+type ToolRetriever struct {
+	registry *Registry
+	embedder llm.Embedder // 可选，为 nil 时只使用关键词匹配
+	opts     RetrieverOptions
+
+	mu         sync.Mutex
+	loaded     map[string]bool // 通过 search_tools 加载的工具，后续步骤始终包含
+	embeddings map[string]toolEmbedding
+
+	// 向量化失败后在 embedRetryAt 之前只使用关键词匹配，连续失败次数决定退避时长
+	embedFailures int
+	embedRetryAt  time.Time
+}
+
+// NewToolRetriever 创建工具检索器，embedder 为 nil 时只使用关键词匹配
+func NewToolRetriever(registry *Registry, embedder llm.Embedder, opts RetrieverOptions) *ToolRetriever {
+	if opts.TopK <= 0 {
+		opts.TopK = DefaultRetrieverTopK
+	}
+	if opts.MinTools <= 0 {
+		opts.MinTools = DefaultRetrieverMinTools
+	}
+	retriever := &ToolRetriever{
+		registry:   registry,
+		embedder:   embedder,
+		opts:       opts,
+		loaded:     make(map[string]bool),
+		embeddings: make(map[string]toolEmbedding),
+	}
+	registry.AddListener(retriever.onRegistryEvent)
+	return retriever
+}
+
+// onRegistryEvent 工具被移除或替换时清理缓存的向量和加载状态
+func (t *ToolRetriever) onRegistryEvent(event RegistryEvent) {
+	if event.Type == RegistryEventAdded {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.embeddings, event.Tool)
+	if event.Type == RegistryEventRemoved {
+		delete(t.loaded, event.Tool)
+	}
+}
+
+// Select 返回本步发送给模型的工具：必选工具、pinned（如最近使用过的工具）、
+// 通过 search_tools 加载的工具，以及与 query 最相关的至多 TopK 个工具。
+// 工具总数不超过 MinTools 时返回全部工具
+func (t *ToolRetriever) Select(ctx context.Context, query string, pinned []string) []ToolInfo {
+	manifest := t.registry.GetToolsManifest()
+	if len(manifest) <= t.opts.MinTools {
+		return manifest
+	}
+
+	required := make(map[string]bool)
+	for _, names := range [][]string{coreTools, t.opts.AlwaysInclude, pinned} {
+		for _, name := range names {
+			required[name] = true
+		}
+	}
+	t.mu.Lock()
+	for name := range t.loaded {
+		required[name] = true
+	}
+	t.mu.Unlock()
+
+	selected := make([]ToolInfo, 0, len(required)+t.opts.TopK)
+	candidates := make([]ToolInfo, 0, len(manifest))
+	for _, info := range manifest {
+		if required[info.Name] {
+			selected = append(selected, info)
+		} else {
+			candidates = append(candidates, info)
+		}
+	}
+
+	// 与查询无关的工具不发送，模型可以通过 search_tools 检索
+	for i, match := range t.rank(ctx, query, candidates) {
+		if i >= t.opts.TopK || match.score <= 0 {
+			break
+		}
+		selected = append(selected, candidates[match.index])
+	}
+
+	logger.Debugw("tool.retriever.select", "selected", len(selected), "total", len(manifest))
+	return selected
+}
+
+// Search 按相关性检索工具（不含必选工具），返回至多 limit 个得分为正的结果
+func (t *ToolRetriever) Search(ctx context.Context, query string, limit int) []ToolMatch {
+	manifest := t.registry.GetToolsManifest()
+
+	candidates := make([]ToolInfo, 0, len(manifest))
+	for _, info := range manifest {
+		if !isCoreTool(info.Name) {
+			candidates = append(candidates, info)
+		}
+	}
+
+	matches := make([]ToolMatch, 0, limit)
+	for _, scored := range t.rank(ctx, query, candidates) {
+		if len(matches) >= limit || scored.score <= 0 {
+			break
+		}
+		info := candidates[scored.index]
+		matches = append(matches, ToolMatch{
+			Name:        info.Name,
+			Description: info.Description,
+			Type:        info.Type,
+			ServerName:  info.ServerName,
+			Score:       math.Round(scored.score*1000) / 1000,
+		})
+	}
+	return matches
+}
+
+// Load 标记工具为已加载，后续每步规划都会包含这些工具
+func (t *ToolRetriever) Load(names ...string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, name := range names {
+		t.loaded[name] = true
+	}
+}
+
+// Loaded 返回通过 search_tools 加载的工具名称
+func (t *ToolRetriever) Loaded() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	names := make([]string, 0, len(t.loaded))
+	for name := range t.loaded {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// scoredTool 候选工具的下标和得分
+type scoredTool struct {
+	index int
+	score float64
+}
+
+// rank 按得分从高到低排列候选工具，得分相同时保持名称顺序
+func (t *ToolRetriever) rank(ctx context.Context, query string, candidates []ToolInfo) []scoredTool {
+	scores := keywordScores(query, candidates)
+	if similarities := t.similarities(ctx, query, candidates); similarities != nil {
+		for i := range scores {
+			scores[i] = 0.5*scores[i] + 0.5*math.Max(similarities[i], 0)
+		}
+	}
+
+	ranked := make([]scoredTool, len(candidates))
+	for i := range candidates {
+		ranked[i] = scoredTool{index: i, score: scores[i]}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].score > ranked[j].score
+	})
+	return ranked
+}
+
+// similarities 计算 query 与候选工具的向量相似度，未配置、向量化失败或处于失败退避期时返回 nil。
+// 向量化请求不持有锁，避免阻塞注册表事件和并发的 Select
+func (t *ToolRetriever) similarities(ctx context.Context, query string, candidates []ToolInfo) []float64 {
+	if t.embedder == nil || strings.TrimSpace(query) == "" {
+		return nil
+	}
+
+	// 在锁内取出缓存的向量，只为新增或描述变化的工具计算向量
+	t.mu.Lock()
+	if time.Now().Before(t.embedRetryAt) {
+		t.mu.Unlock()
+		return nil
+	}
+	toolVectors := make([][]float32, len(candidates))
+	texts := []string{query}
+	pending := []int{} // 需要计算向量的候选工具下标，与 texts[1:] 一一对应
+	for i, info := range candidates {
+		text := toolEmbeddingText(info)
+		if cached, ok := t.embeddings[info.Name]; ok && cached.text == text {
+			toolVectors[i] = cached.vector
+			continue
+		}
+		texts = append(texts, text)
+		pending = append(pending, i)
+	}
+	t.mu.Unlock()
+
+	vectors, err := t.embed(ctx, texts)
+	if err == nil && len(vectors) != len(texts) {
+		err = fmt.Errorf("expected %d embeddings, got %d", len(texts), len(vectors))
+	}
+	if err != nil {
+		// 调用方取消或超时不计为向量化失败，下次调用照常重试
+		if ctx.Err() != nil {
+			return nil
+		}
+		t.mu.Lock()
+		t.embedFailures++
+		delay := embedRetryBaseDelay << min(t.embedFailures-1, 10)
+		if delay > embedRetryMaxDelay {
+			delay = embedRetryMaxDelay
+		}
+		t.embedRetryAt = time.Now().Add(delay)
+		t.mu.Unlock()
+		logger.Warnw("tool.retriever.embed_failed", "model", t.embedder.GetModel(), "error", err, "retry_in", delay.String())
+		return nil
+	}
+
+	t.mu.Lock()
+	t.embedFailures = 0
+	for j, i := range pending {
+		t.embeddings[candidates[i].Name] = toolEmbedding{text: texts[j+1], vector: vectors[j+1]}
+		toolVectors[i] = vectors[j+1]
+	}
+	t.mu.Unlock()
+
+	similarities := make([]float64, len(candidates))
+	for i, vector := range toolVectors {
+		similarities[i] = llm.CosineSimilarity(vectors[0], vector)
+	}
+	return similarities
+}
+
+// embed 计算向量，能返回用量的向量化客户端的调用通过 state.ReportLLMCall 计入调用方的用量
+func (t *ToolRetriever) embed(ctx context.Context, texts []string) ([][]float32, error) {
+	embedder, ok := t.embedder.(llm.UsageEmbedder)
+	if !ok {
+		return t.embedder.Embed(ctx, texts)
+	}
+	start := time.Now()
+	vectors, usage, err := embedder.EmbedWithUsage(ctx, texts)
+	if usage.PromptTokens > 0 {
+		state.ReportLLMCall(ctx, &state.LLMCall{
+			Model:        embedder.GetModel(),
+			PromptTokens: usage.PromptTokens,
+			Latency:      time.Since(start).Milliseconds(),
+		})
+	}
+	return vectors, err
+}
+
+// keywordScores 计算关键词得分并归一化到 [0, 1]：
+// 查询词按出现在工具名、描述、参数中的位置加权，并乘以逆文档频率
+func keywordScores(query string, candidates []ToolInfo) []float64 {
+	scores := make([]float64, len(candidates))
+	queryTerms := uniqueTerms(query)
+	if len(queryTerms) == 0 || len(candidates) == 0 {
+		return scores
+	}
+
+	type toolTerms struct {
+		name, desc, schema map[string]bool
+	}
+	terms := make([]toolTerms, len(candidates))
+	docFreq := make(map[string]int)
+	for i, info := range candidates {
+		terms[i] = toolTerms{
+			name:   termSet(info.Name + " " + info.ServerName),
+			desc:   termSet(info.Description),
+			schema: termSet(schemaText(info.InputSchema)),
+		}
+		for _, term := range queryTerms {
+			if terms[i].name[term] || terms[i].desc[term] || terms[i].schema[term] {
+				docFreq[term]++
+			}
+		}
+	}
+
+	var maxScore float64
+	for i := range candidates {
+		for _, term := range queryTerms {
+			if docFreq[term] == 0 {
+				continue
+			}
+			idf := math.Log(1 + float64(len(candidates))/float64(docFreq[term]))
+			switch {
+			case terms[i].name[term]:
+				scores[i] += nameTermWeight * idf
+			case terms[i].desc[term]:
+				scores[i] += descTermWeight * idf
+			case terms[i].schema[term]:
+				scores[i] += schemaTermWeight * idf
+			}
+		}
+		maxScore = math.Max(maxScore, scores[i])
+	}
+
+	if maxScore > 0 {
+		for i := range scores {
+			scores[i] /= maxScore
+		}
+	}
+	return scores
+}
+
+// retrievalTerms 将文本切分为检索词：小写英文单词（按下划线等拆分，去掉复数 s）和中日韩二字词
+func retrievalTerms(text string) []string {
+	var terms []string
+	var word strings.Builder
+	var han []rune
+	flushWord := func() {
+		if word.Len() > 1 {
+			term := word.String()
+			if len(term) > 3 && strings.HasSuffix(term, "s") && !strings.HasSuffix(term, "ss") {
+				term = strings.TrimSuffix(term, "s")
+			}
+			if !retrievalStopWords[term] {
+				terms = append(terms, term)
+			}
+		}
+		word.Reset()
+	}
+	flushHan := func() {
+		if len(han) == 1 {
+			terms = append(terms, string(han))
+		}
+		for i := 0; i+1 < len(han); i++ {
+			terms = append(terms, string(han[i:i+2]))
+		}
+		han = han[:0]
+	}
+
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+			unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r):
+			flushWord()
+			han = append(han, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushHan()
+			word.WriteRune(r)
+		default:
+			flushWord()
+			flushHan()
+		}
+	}
+	flushWord()
+	flushHan()
+	return terms
+}
+
+// uniqueTerms 返回去重后的检索词
+func uniqueTerms(text string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, term := range retrievalTerms(text) {
+		if !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// termSet 返回检索词集合
+func termSet(text string) map[string]bool {
+	set := make(map[string]bool)
+	for _, term := range retrievalTerms(text) {
+		set[term] = true
+	}
+	return set
+}
+
+// schemaText 输入 Schema 中的参数名和参数描述
+func schemaText(schema map[string]any) string {
+	properties, _ := schema["properties"].(map[string]any)
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name + " ")
+		if prop, ok := properties[name].(map[string]any); ok {
+			if description, ok := prop["description"].(string); ok {
+				b.WriteString(description + " ")
+			}
+		}
+	}
+	return b.String()
+}
+
+// toolEmbeddingText 用于向量化的工具文本
+func toolEmbeddingText(info ToolInfo) string {
+	return info.Name + ": " + info.Description + "\n" + schemaText(info.InputSchema)
+}
+
+// isCoreTool 是否为始终发送的核心工具
+func isCoreTool(name string) bool {
+	for _, core := range coreTools {
+		if core == name {
+			return true
+		}
+	}
+	return false
+}
@@ -2,7 +2,6 @@ package tool
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 )
 
@@ -21,13 +20,15 @@ type ToolType string
 const (
 	ToolTypeBuiltin ToolType = "builtin"
 	ToolTypeMCP     ToolType = "mcp"
+	ToolTypePlugin  ToolType = "plugin"  // 外部可执行插件
+	ToolTypeOpenAPI ToolType = "openapi" // 由 OpenAPI 规范生成
 )
 
 // ToolWithType 带类型的工具接口
 type ToolWithType interface {
 	Tool
 	Type() ToolType
-	ServerName() string // 对于MCP工具，返回服务器名称；对于内置工具，返回空字符串
+	ServerName() string // 对于MCP工具，返回服务器名称；对于插件和 OpenAPI 工具，返回插件或服务名称；对于内置工具，返回空字符串
 }
 
 // ToolInfo 表示工具信息
@@ -125,78 +126,25 @@ func (mt *MCPTool) Invoke(ctx context.Context, args map[string]any) (map[string]
 	return mt.executor.ExecuteMCPTool(ctx, mt.serverName, mt.name, args)
 }
 
-// ValidateInput 验证输入参数
-func (bt *BaseTool) ValidateInput(args map[string]any) error {
-	// 简单的 JSON Schema 验证
-	// 在生产环境中，应该使用更完整的 JSON Schema 验证库
-	if bt.inputSchema == nil {
-		return nil
-	}
-
-	properties, ok := bt.inputSchema["properties"].(map[string]any)
-	if !ok {
-		return nil
-	}
-
-	required, _ := bt.inputSchema["required"].([]string)
-
-	// 检查必需字段
-	for _, field := range required {
-		if _, exists := args[field]; !exists {
-			return fmt.Errorf("required field '%s' is missing", field)
-		}
-	}
-
-	// 检查字段类型
-	for field, value := range args {
-		if fieldSchema, exists := properties[field]; exists {
-			if err := validateFieldType(field, value, fieldSchema); err != nil {
-				return err
-			}
-		}
-	}
-
-	return nil
+// MCPHealthChecker 可检查 MCP 服务器及其工具是否可用的执行器
+type MCPHealthChecker interface {
+	PingMCPTool(ctx context.Context, serverName, toolName string) (HealthStatus, error)
 }
 
-// validateFieldType 验证字段类型
-func validateFieldType(field string, value any, schema any) error {
-	schemaMap, ok := schema.(map[string]any)
+// Ping 检查 MCP 服务器是否可用且仍提供该工具，执行器不支持时返回错误
+func (mt *MCPTool) Ping(ctx context.Context) (HealthStatus, error) {
+	checker, ok := mt.executor.(MCPHealthChecker)
 	if !ok {
-		return nil
-	}
-
-	expectedType, ok := schemaMap["type"].(string)
-	if !ok {
-		return nil
+		return HealthStatus{}, fmt.Errorf("MCP executor does not support health checks")
 	}
+	return checker.PingMCPTool(ctx, mt.serverName, mt.name)
+}
 
-	switch expectedType {
-	case "string":
-		if _, ok := value.(string); !ok {
-			return fmt.Errorf("field '%s' must be a string", field)
-		}
-	case "number":
-		switch value.(type) {
-		case int, int64, float64, json.Number:
-			// OK
-		default:
-			return fmt.Errorf("field '%s' must be a number", field)
-		}
-	case "boolean":
-		if _, ok := value.(bool); !ok {
-			return fmt.Errorf("field '%s' must be a boolean", field)
-		}
-	case "object":
-		if _, ok := value.(map[string]any); !ok {
-			return fmt.Errorf("field '%s' must be an object", field)
-		}
-	case "array":
-		if _, ok := value.([]any); !ok {
-			return fmt.Errorf("field '%s' must be an array", field)
-		}
+// ValidateInput 按输入 Schema 校验参数
+func (bt *BaseTool) ValidateInput(args map[string]any) error {
+	if errs := ValidateArgs(args, bt.inputSchema); len(errs) > 0 {
+		return &ValidationError{Tool: bt.name, Errors: errs}
 	}
-
 	return nil
 }
 
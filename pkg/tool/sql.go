@@ -0,0 +1,97 @@
+package tool
+
+import "strings"
+
+// SQLStatements 按分号拆分 SQL，字符串、带引号的标识符和注释中的分号不拆分；
+// 只含空白和注释的语句被忽略
+func SQLStatements(sqlStr string) []string {
+	var (
+		statements []string
+		start      int
+		quote      byte // 当前所在的引号：' " `
+		hasContent bool // 当前语句是否包含注释以外的内容
+	)
+	for i := 0; i < len(sqlStr); i++ {
+		c := sqlStr[i]
+		if quote != 0 {
+			switch {
+			case c == '\\' && quote != '`':
+				i++
+			case c == quote:
+				quote = 0
+			}
+			continue
+		}
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+			hasContent = true
+		case c == '#' || (c == '-' && strings.HasPrefix(sqlStr[i:], "--")):
+			if idx := strings.IndexByte(sqlStr[i:], '\n'); idx >= 0 {
+				i += idx
+			} else {
+				i = len(sqlStr)
+			}
+		case c == '/' && strings.HasPrefix(sqlStr[i:], "/*"):
+			if idx := strings.Index(sqlStr[i+2:], "*/"); idx >= 0 {
+				i += idx + 3
+			} else {
+				i = len(sqlStr)
+			}
+		case c == ';':
+			if hasContent {
+				statements = append(statements, strings.TrimSpace(sqlStr[start:i]))
+			}
+			start = i + 1
+			hasContent = false
+		case c != ' ' && c != '\t' && c != '\n' && c != '\r':
+			hasContent = true
+		}
+	}
+	if hasContent {
+		statements = append(statements, strings.TrimSpace(sqlStr[start:]))
+	}
+	return statements
+}
+
+// SQLVerbs 返回每条 SQL 语句的第一个关键字（大写）
+func SQLVerbs(sqlStr string) []string {
+	statements := SQLStatements(sqlStr)
+	verbs := make([]string, len(statements))
+	for i, statement := range statements {
+		verbs[i] = sqlVerb(statement)
+	}
+	return verbs
+}
+
+// sqlVerb 返回 SQL 语句的第一个关键字（大写），跳过注释和括号
+func sqlVerb(sqlStr string) string {
+	s := strings.TrimSpace(sqlStr)
+	for {
+		switch {
+		case strings.HasPrefix(s, "--") || strings.HasPrefix(s, "#"):
+			if idx := strings.IndexByte(s, '\n'); idx >= 0 {
+				s = strings.TrimSpace(s[idx+1:])
+				continue
+			}
+			return ""
+		case strings.HasPrefix(s, "/*"):
+			if idx := strings.Index(s, "*/"); idx >= 0 {
+				s = strings.TrimSpace(s[idx+2:])
+				continue
+			}
+			return ""
+		case strings.HasPrefix(s, "("):
+			s = strings.TrimSpace(s[1:])
+			continue
+		}
+		break
+	}
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '_')
+	})
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.ToUpper(fields[0])
+}
@@ -0,0 +1,641 @@
+package tool
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// SchemaError 表示一处 JSON Schema 校验失败
+type SchemaError struct {
+	Path    string `json:"path"`    // JSON Pointer 形式的实例路径，根为 ""
+	Keyword string `json:"keyword"` // 失败的 Schema 关键字，如 required、enum、minimum
+	Message string `json:"message"`
+}
+
+// String 返回 "path: message" 形式的描述
+func (e SchemaError) String() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// ValidationError 参数（或 Output 为 true 时的输出）校验失败，包含全部校验错误
+type ValidationError struct {
+	Tool   string
+	Errors []SchemaError
+	Output bool
+}
+
+// Error 实现 error 接口
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, schemaErr := range e.Errors {
+		messages[i] = schemaErr.String()
+	}
+	prefix := "invalid arguments"
+	if e.Output {
+		prefix = "invalid output"
+	}
+	if e.Tool != "" {
+		if e.Output {
+			prefix = fmt.Sprintf("invalid output from tool %s", e.Tool)
+		} else {
+			prefix = fmt.Sprintf("invalid arguments for tool %s", e.Tool)
+		}
+	}
+	return fmt.Sprintf("%s: %s", prefix, strings.Join(messages, "; "))
+}
+
+// ValidateArgs 按 JSON Schema（draft 2020-12）校验工具参数
+//
+// 支持的关键字：type, enum, const, properties, required, additionalProperties,
+// patternProperties, propertyNames, min/maxProperties, dependentRequired, items, prefixItems,
+// contains, min/maxContains, min/maxItems, uniqueItems, min/maxLength, pattern, format,
+// minimum, maximum, exclusiveMinimum, exclusiveMaximum, multipleOf, allOf, anyOf, oneOf, not,
+// if/then/else 以及指向 #/$defs、#/definitions 的本地 $ref。
+// 未识别的关键字（如 description、default）会被忽略。
+func ValidateArgs(args map[string]any, schema map[string]any) []SchemaError {
+	if schema == nil {
+		return nil
+	}
+	if args == nil {
+		args = map[string]any{}
+	}
+
+	v := &schemaValidator{root: schema}
+	v.validate("", normalizeValue(args), schema)
+	sort.SliceStable(v.errors, func(i, j int) bool {
+		return v.errors[i].Path < v.errors[j].Path
+	})
+	return v.errors
+}
+
+// schemaValidator 保存一次校验的根 Schema（用于解析 $ref）和收集到的错误
+type schemaValidator struct {
+	root   map[string]any
+	errors []SchemaError
+	depth  int
+}
+
+// maxSchemaDepth 防止循环 $ref 导致无限递归
+const maxSchemaDepth = 64
+
+func (v *schemaValidator) fail(path, keyword, format string, args ...any) {
+	v.errors = append(v.errors, SchemaError{Path: path, Keyword: keyword, Message: fmt.Sprintf(format, args...)})
+}
+
+// check 在独立的校验器中校验子 Schema，用于 anyOf/oneOf/not/if 等不直接报告错误的场景
+func (v *schemaValidator) check(path string, value any, schema any) []SchemaError {
+	sub := &schemaValidator{root: v.root, depth: v.depth}
+	sub.validate(path, value, schema)
+	return sub.errors
+}
+
+func (v *schemaValidator) validate(path string, value any, rawSchema any) {
+	// 布尔 Schema：true 接受任意值，false 拒绝任意值
+	switch s := rawSchema.(type) {
+	case bool:
+		if !s {
+			v.fail(path, "false", "value is not allowed here")
+		}
+		return
+	case nil:
+		return
+	}
+	schema, ok := rawSchema.(map[string]any)
+	if !ok {
+		return
+	}
+
+	v.depth++
+	defer func() { v.depth-- }()
+	if v.depth > maxSchemaDepth {
+		v.fail(path, "$ref", "schema nesting too deep (circular $ref?)")
+		return
+	}
+
+	if ref, ok := schema["$ref"].(string); ok {
+		target, err := v.resolveRef(ref)
+		if err != nil {
+			v.fail(path, "$ref", "%v", err)
+		} else {
+			v.validate(path, value, target)
+		}
+	}
+
+	// type
+	if types := SchemaTypes(schema); len(types) > 0 && !matchesAnyType(value, types) {
+		v.fail(path, "type", "must be %s, got %s", strings.Join(types, " or "), describeValue(value))
+		// 类型不符时其他关键字的错误没有意义
+		return
+	}
+
+	// enum / const
+	if enum, ok := schemaArray(schema["enum"]); ok {
+		matched := false
+		for _, candidate := range enum {
+			if jsonEqual(value, normalizeValue(candidate)) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.fail(path, "enum", "must be one of %s, got %s", compactJSON(enum), compactJSON(value))
+		}
+	}
+	if constValue, ok := schema["const"]; ok && !jsonEqual(value, normalizeValue(constValue)) {
+		v.fail(path, "const", "must be %s, got %s", compactJSON(constValue), compactJSON(value))
+	}
+
+	switch typed := value.(type) {
+	case map[string]any:
+		v.validateObject(path, typed, schema)
+	case []any:
+		v.validateArray(path, typed, schema)
+	case string:
+		v.validateString(path, typed, schema)
+	case float64:
+		v.validateNumber(path, typed, schema)
+	}
+
+	v.validateComposition(path, value, schema)
+}
+
+func (v *schemaValidator) validateObject(path string, obj map[string]any, schema map[string]any) {
+	for _, name := range SchemaRequired(schema) {
+		if _, ok := obj[name]; !ok {
+			v.fail(joinPointer(path, name), "required", "required property %q is missing", name)
+		}
+	}
+
+	if min, ok := schemaNumber(schema["minProperties"]); ok && float64(len(obj)) < min {
+		v.fail(path, "minProperties", "must have at least %v properties", min)
+	}
+	if max, ok := schemaNumber(schema["maxProperties"]); ok && float64(len(obj)) > max {
+		v.fail(path, "maxProperties", "must have at most %v properties", max)
+	}
+
+	if deps, ok := schema["dependentRequired"].(map[string]any); ok {
+		for name, rawDeps := range deps {
+			if _, present := obj[name]; !present {
+				continue
+			}
+			required, _ := schemaArray(rawDeps)
+			for _, dep := range required {
+				if depName, ok := dep.(string); ok {
+					if _, ok := obj[depName]; !ok {
+						v.fail(joinPointer(path, depName), "dependentRequired", "required when %q is present", name)
+					}
+				}
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]any)
+	patternProperties, _ := schema["patternProperties"].(map[string]any)
+	additional, hasAdditional := schema["additionalProperties"]
+	propertyNames, hasPropertyNames := schema["propertyNames"]
+
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := obj[name]
+		childPath := joinPointer(path, name)
+
+		if hasPropertyNames {
+			for _, err := range v.check(childPath, name, propertyNames) {
+				v.fail(childPath, "propertyNames", "invalid property name: %s", err.Message)
+			}
+		}
+
+		matched := false
+		if propSchema, ok := properties[name]; ok {
+			matched = true
+			v.validate(childPath, value, propSchema)
+		}
+		for pattern, patternSchema := range patternProperties {
+			re, err := compilePattern(pattern)
+			if err != nil || !re.MatchString(name) {
+				continue
+			}
+			matched = true
+			v.validate(childPath, value, patternSchema)
+		}
+		if !matched && hasAdditional {
+			if allowed, ok := additional.(bool); ok && !allowed {
+				v.fail(childPath, "additionalProperties", "unknown property %q is not allowed", name)
+			} else {
+				v.validate(childPath, value, additional)
+			}
+		}
+	}
+}
+
+func (v *schemaValidator) validateArray(path string, arr []any, schema map[string]any) {
+	if min, ok := schemaNumber(schema["minItems"]); ok && float64(len(arr)) < min {
+		v.fail(path, "minItems", "must have at least %v items, got %d", min, len(arr))
+	}
+	if max, ok := schemaNumber(schema["maxItems"]); ok && float64(len(arr)) > max {
+		v.fail(path, "maxItems", "must have at most %v items, got %d", max, len(arr))
+	}
+
+	if unique, _ := schema["uniqueItems"].(bool); unique {
+	outer:
+		for i := 0; i < len(arr); i++ {
+			for j := i + 1; j < len(arr); j++ {
+				if jsonEqual(arr[i], arr[j]) {
+					v.fail(path, "uniqueItems", "items %d and %d are equal", i, j)
+					break outer
+				}
+			}
+		}
+	}
+
+	prefix, _ := schemaArray(schema["prefixItems"])
+	for i, itemSchema := range prefix {
+		if i >= len(arr) {
+			break
+		}
+		v.validate(joinPointer(path, strconv.Itoa(i)), arr[i], itemSchema)
+	}
+	if items, ok := schema["items"]; ok {
+		// draft 2020-12 中 items 只约束 prefixItems 之后的元素；兼容旧版本的数组形式
+		if tuple, isTuple := schemaArray(items); isTuple {
+			for i, itemSchema := range tuple {
+				if i < len(arr) {
+					v.validate(joinPointer(path, strconv.Itoa(i)), arr[i], itemSchema)
+				}
+			}
+		} else {
+			for i := len(prefix); i < len(arr); i++ {
+				if allowed, ok := items.(bool); ok && !allowed {
+					v.fail(joinPointer(path, strconv.Itoa(i)), "items", "no items allowed beyond index %d", len(prefix)-1)
+					break
+				}
+				v.validate(joinPointer(path, strconv.Itoa(i)), arr[i], items)
+			}
+		}
+	}
+
+	if contains, ok := schema["contains"]; ok {
+		count := 0
+		for i, item := range arr {
+			if len(v.check(joinPointer(path, strconv.Itoa(i)), item, contains)) == 0 {
+				count++
+			}
+		}
+		minContains := 1.0
+		if min, ok := schemaNumber(schema["minContains"]); ok {
+			minContains = min
+		}
+		if float64(count) < minContains {
+			v.fail(path, "contains", "must contain at least %v matching items, found %d", minContains, count)
+		}
+		if max, ok := schemaNumber(schema["maxContains"]); ok && float64(count) > max {
+			v.fail(path, "maxContains", "must contain at most %v matching items, found %d", max, count)
+		}
+	}
+}
+
+func (v *schemaValidator) validateString(path string, s string, schema map[string]any) {
+	length := utf8.RuneCountInString(s)
+	if min, ok := schemaNumber(schema["minLength"]); ok && float64(length) < min {
+		v.fail(path, "minLength", "must be at least %v characters, got %d", min, length)
+	}
+	if max, ok := schemaNumber(schema["maxLength"]); ok && float64(length) > max {
+		v.fail(path, "maxLength", "must be at most %v characters, got %d", max, length)
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		re, err := compilePattern(pattern)
+		if err != nil {
+			v.fail(path, "pattern", "schema pattern %q is invalid: %v", pattern, err)
+		} else if !re.MatchString(s) {
+			v.fail(path, "pattern", "must match pattern %q", pattern)
+		}
+	}
+	if format, ok := schema["format"].(string); ok {
+		if err := checkFormat(format, s); err != nil {
+			v.fail(path, "format", "must be a valid %s: %v", format, err)
+		}
+	}
+}
+
+func (v *schemaValidator) validateNumber(path string, n float64, schema map[string]any) {
+	if min, ok := schemaNumber(schema["minimum"]); ok && n < min {
+		v.fail(path, "minimum", "must be >= %v, got %v", min, n)
+	}
+	if max, ok := schemaNumber(schema["maximum"]); ok && n > max {
+		v.fail(path, "maximum", "must be <= %v, got %v", max, n)
+	}
+	if min, ok := schemaNumber(schema["exclusiveMinimum"]); ok && n <= min {
+		v.fail(path, "exclusiveMinimum", "must be > %v, got %v", min, n)
+	}
+	if max, ok := schemaNumber(schema["exclusiveMaximum"]); ok && n >= max {
+		v.fail(path, "exclusiveMaximum", "must be < %v, got %v", max, n)
+	}
+	if multiple, ok := schemaNumber(schema["multipleOf"]); ok && multiple > 0 {
+		quotient := n / multiple
+		if math.Abs(quotient-math.Round(quotient)) > 1e-9 {
+			v.fail(path, "multipleOf", "must be a multiple of %v, got %v", multiple, n)
+		}
+	}
+}
+
+func (v *schemaValidator) validateComposition(path string, value any, schema map[string]any) {
+	if allOf, ok := schemaArray(schema["allOf"]); ok {
+		for _, sub := range allOf {
+			v.validate(path, value, sub)
+		}
+	}
+
+	if anyOf, ok := schemaArray(schema["anyOf"]); ok && len(anyOf) == 0 {
+		v.fail(path, "anyOf", "no schemas to match (anyOf is empty)")
+	} else if ok {
+		var firstErrs []SchemaError
+		matched := false
+		for _, sub := range anyOf {
+			errs := v.check(path, value, sub)
+			if len(errs) == 0 {
+				matched = true
+				break
+			}
+			if firstErrs == nil {
+				firstErrs = errs
+			}
+		}
+		if !matched {
+			detail := ""
+			if len(firstErrs) > 0 {
+				detail = fmt.Sprintf(" (first mismatch: %s)", firstErrs[0].String())
+			}
+			v.fail(path, "anyOf", "must match at least one of %d schemas%s", len(anyOf), detail)
+		}
+	}
+
+	if oneOf, ok := schemaArray(schema["oneOf"]); ok {
+		matches := 0
+		for _, sub := range oneOf {
+			if len(v.check(path, value, sub)) == 0 {
+				matches++
+			}
+		}
+		if len(oneOf) == 0 {
+			v.fail(path, "oneOf", "no schemas to match (oneOf is empty)")
+		} else if matches != 1 {
+			v.fail(path, "oneOf", "must match exactly one of %d schemas, matched %d", len(oneOf), matches)
+		}
+	}
+
+	if not, ok := schema["not"]; ok && len(v.check(path, value, not)) == 0 {
+		v.fail(path, "not", "must not match the schema in \"not\"")
+	}
+
+	if ifSchema, ok := schema["if"]; ok {
+		if len(v.check(path, value, ifSchema)) == 0 {
+			if thenSchema, ok := schema["then"]; ok {
+				v.validate(path, value, thenSchema)
+			}
+		} else if elseSchema, ok := schema["else"]; ok {
+			v.validate(path, value, elseSchema)
+		}
+	}
+}
+
+// resolveRef 解析本地 JSON Pointer 引用（#、#/$defs/x、#/definitions/x 等）
+func (v *schemaValidator) resolveRef(ref string) (any, error) {
+	return resolvePointer(v.root, ref)
+}
+
+// resolvePointer 在 root 文档中解析本地 JSON Pointer 引用
+func resolvePointer(root any, ref string) (any, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("unsupported remote $ref %q", ref)
+	}
+	current := root
+	pointer := strings.TrimPrefix(ref, "#")
+	if pointer == "" {
+		return current, nil
+	}
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		if unescaped, err := url.PathUnescape(token); err == nil {
+			token = unescaped
+		}
+		obj, ok := current.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("cannot resolve $ref %q", ref)
+		}
+		if current, ok = obj[token]; !ok {
+			return nil, fmt.Errorf("cannot resolve $ref %q", ref)
+		}
+	}
+	return current, nil
+}
+
+// checkFormat 校验常用的 format 值；未知的 format 视为注解，不做校验
+func checkFormat(format, s string) error {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, s)
+		return err
+	case "date":
+		_, err := time.Parse("2006-01-02", s)
+		return err
+	case "time":
+		if _, err := time.Parse("15:04:05Z07:00", s); err != nil {
+			_, err = time.Parse("15:04:05.999999999Z07:00", s)
+			return err
+		}
+	case "email":
+		addr, err := mail.ParseAddress(s)
+		if err != nil {
+			return err
+		}
+		if addr.Address != s {
+			return fmt.Errorf("unexpected display name")
+		}
+	case "uri", "url":
+		u, err := url.Parse(s)
+		if err != nil {
+			return err
+		}
+		if u.Scheme == "" {
+			return fmt.Errorf("missing scheme")
+		}
+	case "uri-reference":
+		_, err := url.Parse(s)
+		return err
+	case "uuid":
+		if !uuidPattern.MatchString(s) {
+			return fmt.Errorf("malformed UUID")
+		}
+	case "ipv4":
+		if ip := net.ParseIP(s); ip == nil || ip.To4() == nil || strings.Contains(s, ":") {
+			return fmt.Errorf("malformed IPv4 address")
+		}
+	case "ipv6":
+		if ip := net.ParseIP(s); ip == nil || !strings.Contains(s, ":") {
+			return fmt.Errorf("malformed IPv6 address")
+		}
+	case "hostname":
+		if len(s) == 0 || len(s) > 253 || !hostnamePattern.MatchString(s) {
+			return fmt.Errorf("malformed hostname")
+		}
+	case "regex":
+		_, err := regexp.Compile(s)
+		return err
+	case "duration":
+		if !durationPattern.MatchString(s) || s == "P" || strings.HasSuffix(s, "T") {
+			return fmt.Errorf("malformed ISO 8601 duration")
+		}
+	}
+	return nil
+}
+
+var (
+	uuidPattern     = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	hostnamePattern = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$`)
+	durationPattern = regexp.MustCompile(`^P(\d+Y)?(\d+M)?(\d+W)?(\d+D)?(T(\d+H)?(\d+M)?(\d+(\.\d+)?S)?)?$`)
+)
+
+// patternCache 缓存已编译的 pattern / patternProperties 正则
+var patternCache sync.Map
+
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if cached, ok := patternCache.Load(pattern); ok {
+		return cached.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patternCache.Store(pattern, re)
+	return re, nil
+}
+
+// normalizeValue 将 Go 原生类型转换为 JSON 解码后的等价形式（数字统一为 float64，切片统一为 []any）
+func normalizeValue(value any) any {
+	switch v := value.(type) {
+	case nil, bool, string, float64:
+		return v
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			out[key] = normalizeValue(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = normalizeValue(item)
+		}
+		return out
+	case json.Number:
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	case int:
+		return float64(v)
+	case int8:
+		return float64(v)
+	case int16:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint:
+		return float64(v)
+	case uint8:
+		return float64(v)
+	case uint16:
+		return float64(v)
+	case uint32:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	}
+
+	// 其他类型（如 []string、结构体）通过 JSON 往返转换
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return value
+	}
+	return decoded
+}
+
+// jsonEqual 按 JSON 语义比较两个值
+func jsonEqual(a, b any) bool {
+	return reflect.DeepEqual(normalizeValue(a), normalizeValue(b))
+}
+
+// schemaArray 读取 Schema 中的数组关键字，兼容 []any 和具体类型的切片
+func schemaArray(value any) ([]any, bool) {
+	switch v := value.(type) {
+	case []any:
+		return v, true
+	case nil:
+		return nil, false
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice {
+		return nil, false
+	}
+	out := make([]any, rv.Len())
+	for i := range out {
+		out[i] = rv.Index(i).Interface()
+	}
+	return out, true
+}
+
+// schemaNumber 读取 Schema 中的数值关键字
+func schemaNumber(value any) (float64, bool) {
+	if value == nil {
+		return 0, false
+	}
+	f, ok := normalizeValue(value).(float64)
+	return f, ok
+}
+
+// joinPointer 拼接 JSON Pointer 路径
+func joinPointer(path, token string) string {
+	token = strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+	return path + "/" + token
+}
+
+// compactJSON 将值格式化为单行 JSON，用于错误信息
+func compactJSON(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	text := string(data)
+	if len(text) > 80 {
+		text = text[:80] + "..."
+	}
+	return text
+}
@@ -0,0 +1,30 @@
+package tool
+
+import "testing"
+
+// TestValidateArgsEmptyComposition 空的 anyOf/oneOf 报告校验错误而不是 panic，空的 allOf 不限制
+func TestValidateArgsEmptyComposition(t *testing.T) {
+	args := map[string]any{"a": 1}
+	cases := []struct {
+		keyword string
+		wantErr bool
+	}{
+		{"anyOf", true},
+		{"oneOf", true},
+		{"allOf", false},
+	}
+	for _, c := range cases {
+		t.Run(c.keyword, func(t *testing.T) {
+			errs := ValidateArgs(args, map[string]any{"type": "object", c.keyword: []any{}})
+			if !c.wantErr {
+				if len(errs) != 0 {
+					t.Fatalf("expected no errors, got %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || errs[0].Keyword != c.keyword {
+				t.Fatalf("expected a single %s error, got %v", c.keyword, errs)
+			}
+		})
+	}
+}